@@ -9,6 +9,13 @@ type Expr interface {
 	exprNode()
 }
 
+// Precedencer is implemented by the expressions that know how tightly they
+// bind. Values match token.Type.Precedence: the higher, the tighter.
+type Precedencer interface {
+	Expr
+	Precedence() int
+}
+
 type Stmt interface {
 	Node
 	stmtNode()
@@ -0,0 +1,58 @@
+package ast
+
+import (
+	"reflect"
+
+	"github.com/xjslang/xjs/token"
+)
+
+var positionType = reflect.TypeFor[token.Position]()
+
+// Equal reports whether the trees rooted at a and b have the same structure
+// and tokens, ignoring where the tokens are. The leading trivia of the
+// tokens, such as comments and line breaks, must match too.
+//
+// As with Walk, only the exported fields of the nodes are compared.
+func Equal(a, b Node) bool {
+	return equalValue(reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem(), false)
+}
+
+// EqualWithPositions is like Equal, but the tokens must also be at the same
+// positions.
+func EqualWithPositions(a, b Node) bool {
+	return equalValue(reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem(), true)
+}
+
+func equalValue(x, y reflect.Value, positions bool) bool {
+	if x.Type() != y.Type() {
+		return false
+	}
+	if x.Type() == positionType && !positions {
+		return true
+	}
+	switch x.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if x.IsNil() || y.IsNil() {
+			return x.IsNil() == y.IsNil()
+		}
+		return equalValue(x.Elem(), y.Elem(), positions)
+	case reflect.Struct:
+		for i := range x.NumField() {
+			if x.Type().Field(i).IsExported() && !equalValue(x.Field(i), y.Field(i), positions) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice, reflect.Array:
+		if x.Len() != y.Len() {
+			return false
+		}
+		for i := range x.Len() {
+			if !equalValue(x.Index(i), y.Index(i), positions) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(x.Interface(), y.Interface())
+}
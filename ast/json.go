@@ -0,0 +1,139 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"unicode"
+
+	"github.com/xjslang/xjs/token"
+)
+
+var (
+	tokenType    = reflect.TypeFor[token.Token]()
+	baseNodeType = reflect.TypeFor[BaseNode]()
+)
+
+// ToJSON serializes the tree rooted at node, for tools that don't link Go.
+// Each node is an object whose "type" is the name of its Go type, such as
+// "LetStmt", followed by its exported fields, named in camel case. Tokens are
+// objects with their type, literal, position and, if any, leading trivia:
+//
+//	{"type":"identifier","literal":"a","position":{"line":0,"column":4}}
+//
+// As with Walk, nodes defined outside this module are serialized too.
+func ToJSON(node Node) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeValue(&buf, reflect.ValueOf(&node).Elem()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeValue(buf *bytes.Buffer, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if node, ok := v.Interface().(Node); ok {
+			return encodeNode(buf, node)
+		}
+		return encodeValue(buf, v.Elem())
+	case reflect.Struct:
+		if v.Type() == tokenType {
+			return encodeToken(buf, v.Interface().(token.Token))
+		}
+		buf.WriteByte('{')
+		err := encodeFields(buf, v, false)
+		buf.WriteByte('}')
+		return err
+	case reflect.Slice:
+		buf.WriteByte('[')
+		for i := range v.Len() {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeValue(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+	data, err := json.Marshal(v.Interface())
+	buf.Write(data)
+	return err
+}
+
+func encodeNode(buf *bytes.Buffer, node Node) error {
+	v := reflect.ValueOf(node)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	buf.WriteString(`{"type":`)
+	name, _ := json.Marshal(v.Type().Name())
+	buf.Write(name)
+	var err error
+	if v.Kind() == reflect.Struct {
+		err = encodeFields(buf, v, true)
+	}
+	buf.WriteByte('}')
+	return err
+}
+
+// encodeFields writes the exported fields of v as object members, skipping
+// the embedded base nodes. If more is true, members were written before.
+func encodeFields(buf *bytes.Buffer, v reflect.Value, more bool) error {
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+		if !f.IsExported() || f.Anonymous && f.Type.PkgPath() == baseNodeType.PkgPath() {
+			continue
+		}
+		if more {
+			buf.WriteByte(',')
+		}
+		more = true
+		key, _ := json.Marshal(camelCase(f.Name))
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err := encodeValue(buf, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeToken(buf *bytes.Buffer, tok token.Token) error {
+	data, err := json.Marshal(struct {
+		Type     string         `json:"type"`
+		Literal  string         `json:"literal"`
+		Position token.Position `json:"position"`
+	}{tok.Type.String(), tok.Literal, tok.Position})
+	if err != nil || len(tok.LeadingTrivia) == 0 {
+		buf.Write(data)
+		return err
+	}
+	// the trivia are tokens too
+	buf.Write(data[:len(data)-1])
+	buf.WriteString(`,"leadingTrivia":`)
+	if err := encodeValue(buf, reflect.ValueOf(tok.LeadingTrivia)); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// camelCase lowers the leading capitals of a field name, so that "Lparen"
+// becomes "lparen" and "EOF" becomes "eof".
+func camelCase(name string) string {
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsUpper(r) || i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(r)
+	}
+	return string(runes)
+}
@@ -0,0 +1,63 @@
+package ast
+
+// Brancher is implemented by the nodes that add decisions to the control
+// flow, such as if statements, loops or the && and || operators. Branches
+// returns the number of decisions, which is 0 for nodes that only sometimes
+// branch, such as a binary expression with another operator.
+type Brancher interface {
+	Node
+	Branches() int
+}
+
+// Function is implemented by the nodes that define a function.
+type Function interface {
+	Node
+	FuncName() string
+}
+
+// Metrics holds size and complexity measures of a tree, see Measure.
+type Metrics struct {
+	// Statements is the number of statements, blocks included.
+	Statements int
+	// MaxDepth is the deepest nesting of branching statements, such as an if
+	// statement inside a loop, which is 2.
+	MaxDepth int
+	// Functions is the number of functions defined.
+	Functions int
+	// Complexity is the cyclomatic complexity of the tree taken as a single
+	// function: the number of decisions plus one.
+	Complexity int
+}
+
+// Measure walks the tree rooted at node, not counting the node itself as a
+// statement, and returns its metrics. Nodes defined outside this module are
+// measured as long as they implement Brancher and Function as needed.
+func Measure(node Node) Metrics {
+	m := Metrics{Complexity: 1}
+	measure(node, &m, 0)
+	return m
+}
+
+func measure(root Node, m *Metrics, depth int) {
+	Walk(root, func(node Node) bool {
+		if node == root {
+			return true
+		}
+		if _, ok := node.(Function); ok {
+			m.Functions++
+		}
+		v, ok := node.(Brancher)
+		if ok {
+			m.Complexity += v.Branches()
+		}
+		if _, isStmt := node.(Stmt); isStmt {
+			m.Statements++
+			if ok && v.Branches() > 0 {
+				m.MaxDepth = max(m.MaxDepth, depth+1)
+				measure(node, m, depth+1)
+				return false
+			}
+		}
+		return true
+	})
+}
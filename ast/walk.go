@@ -0,0 +1,134 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var nodeType = reflect.TypeFor[Node]()
+
+// Walk traverses the tree rooted at node in depth-first order. It calls fn
+// for each node, and then, if fn returns true, walks the node's children.
+//
+// Children are found through the exported fields of the node structs, so
+// nodes defined outside this module are walked too.
+func Walk(node Node, fn func(node Node) bool) {
+	if isNil(node) || !fn(node) {
+		return
+	}
+	v := reflect.ValueOf(node)
+	if v.Kind() == reflect.Pointer {
+		walkValue(v.Elem(), fn)
+	}
+}
+
+func walkValue(v reflect.Value, fn func(node Node) bool) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		if node, ok := v.Interface().(Node); ok {
+			Walk(node, fn)
+		} else if v.Kind() == reflect.Pointer {
+			walkValue(v.Elem(), fn)
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				walkValue(v.Field(i), fn)
+			}
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			walkValue(v.Index(i), fn)
+		}
+	}
+}
+
+// Transform rewrites the tree rooted at node. The children of each node are
+// transformed first, and then fn is called with the node itself, which it can
+// return unchanged or replace with another node. Transform returns the result
+// of the call for the root node.
+//
+// Returning nil removes the node from the list that contains it, such as the
+// statements of a block, or clears the field that holds it. Transform panics
+// if the replacement doesn't fit in the field of its parent.
+//
+// The tree is modified in place.
+func Transform(node Node, fn func(node Node) Node) Node {
+	if isNil(node) {
+		return node
+	}
+	v := reflect.ValueOf(node)
+	if v.Kind() == reflect.Pointer {
+		transformValue(v.Elem(), fn)
+	}
+	return fn(node)
+}
+
+// transformValue transforms the nodes held by v. It reports whether v held a
+// node that was removed.
+func transformValue(v reflect.Value, fn func(node Node) Node) (removed bool) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return false
+		}
+		node, ok := v.Interface().(Node)
+		if !ok {
+			if v.Kind() == reflect.Pointer {
+				transformValue(v.Elem(), fn)
+			}
+			return false
+		}
+		if !v.CanSet() {
+			return false
+		}
+		result := Transform(node, fn)
+		if isNil(result) {
+			v.SetZero()
+			return true
+		}
+		rv := reflect.ValueOf(result)
+		if !rv.Type().AssignableTo(v.Type()) {
+			panic(fmt.Sprintf("ast: cannot replace %T with %T", node, result))
+		}
+		v.Set(rv)
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				transformValue(v.Field(i), fn)
+			}
+		}
+	case reflect.Slice:
+		if !v.Type().Elem().Implements(nodeType) {
+			for i := range v.Len() {
+				transformValue(v.Index(i), fn)
+			}
+			return false
+		}
+		// remove the nodes replaced with nil, but keep the existing nils,
+		// such as the holes of an array
+		n := 0
+		for i := range v.Len() {
+			if !transformValue(v.Index(i), fn) {
+				v.Index(n).Set(v.Index(i))
+				n++
+			}
+		}
+		for i := n; i < v.Len(); i++ {
+			v.Index(i).SetZero()
+		}
+		v.SetLen(n)
+	}
+	return false
+}
+
+func isNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
@@ -19,19 +19,25 @@ func usage() {
 	fmt.Println("\t-help  show this help")
 	fmt.Println("\t-stdin read input from stdin (pipe or redirect)")
 	fmt.Println("\t-check display only errors")
+	fmt.Println("\t-dump  display the tokens, syntax tree and errors, for bug reports")
+	fmt.Println("\t-ast   display the syntax tree as JSON")
 	fmt.Printf("\nExamples:\n\n")
 	fmt.Println("\txjscli example.js")
 	fmt.Println("\txjscli -check example.js")
+	fmt.Println("\txjscli -dump example.js")
+	fmt.Println("\txjscli -ast example.js")
 	fmt.Println("\techo \"code\" | xjscli -stdin")
 	fmt.Println("\techo \"code\" | xjscli -stdin -check")
 	fmt.Println()
 }
 
 func main() {
-	var helpFlag, stdinFlag, checkFlag bool
+	var helpFlag, stdinFlag, checkFlag, dumpFlag, astFlag bool
 	flag.BoolVar(&helpFlag, "help", false, "show help")
 	flag.BoolVar(&stdinFlag, "stdin", false, "read from stdin")
 	flag.BoolVar(&checkFlag, "check", false, "display only errors")
+	flag.BoolVar(&dumpFlag, "dump", false, "display the tokens, syntax tree and errors")
+	flag.BoolVar(&astFlag, "ast", false, "display the syntax tree as JSON")
 	flag.Parse()
 
 	if helpFlag {
@@ -66,6 +72,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	if dumpFlag {
+		fmt.Print(xjs.Dump(data))
+		return
+	}
+
+	if astFlag {
+		tree, err := xjs.ParseToJSON(data)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(string(tree))
+		return
+	}
+
 	program, err := xjs.Parse(data)
 
 	// prints errors
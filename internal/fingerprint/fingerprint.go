@@ -0,0 +1,54 @@
+// Package fingerprint hashes the configuration of the scanner and parser
+// builders, see parser.Builder.Fingerprint.
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"reflect"
+	"runtime"
+
+	"github.com/xjslang/xjs/token"
+)
+
+type Hash struct {
+	h hash.Hash
+}
+
+func New() *Hash {
+	return &Hash{h: sha256.New()}
+}
+
+// Add hashes a labeled value. Maps are hashed in key order, as fmt prints
+// them.
+func (h *Hash) Add(label string, value any) {
+	fmt.Fprintf(h.h, "%s: %v\n", label, value)
+}
+
+// AddFuncs hashes the names of funcs, which identify the middlewares and
+// rules installed in a builder. Closures are named after the function
+// defining them, as in "jsextended.Plugin.func1".
+func AddFuncs[F any](h *Hash, label string, funcs ...F) {
+	names := make([]string, len(funcs))
+	for i, f := range funcs {
+		if v := reflect.ValueOf(f); v.Kind() == reflect.Func && !v.IsNil() {
+			names[i] = runtime.FuncForPC(v.Pointer()).Name()
+		}
+	}
+	h.Add(label, names)
+}
+
+// AddTokenTypes hashes the spelling, precedence and kind of operator of
+// every token type.
+func (h *Hash) AddTokenTypes() {
+	for _, typ := range token.Types() {
+		fmt.Fprintf(h.h, "token %d: %q %d %t %t\n", typ, typ.String(), typ.Precedence(), typ.IsBinaryOp(), typ.IsUnaryOp())
+	}
+}
+
+// Sum returns the hash as a hex string.
+func (h *Hash) Sum() string {
+	return hex.EncodeToString(h.h.Sum(nil))
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/xjslang/xjs"
 	"github.com/xjslang/xjs/ast"
 	"github.com/xjslang/xjs/js"
-	"github.com/xjslang/xjs/jsextended"
 	"github.com/xjslang/xjs/printer"
 	"github.com/xjslang/xjs/token"
 )
@@ -125,13 +124,29 @@ func NodeString(node ast.Node) string {
 	return print(node)
 }
 
+// MustParse parses input with the Extended dialect, failing the test if the
+// input has errors.
+func MustParse(t testing.TB, input string) *js.Program {
+	t.Helper()
+	result, err := xjs.Extended.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("parsing %q: %v", input, err)
+	}
+	return result
+}
+
 func ParseExtended(input []byte) (*js.Program, error) {
-	p := xjs.PluginBuilder().Install(jsextended.Plugin).Build(input)
-	return js.ParseProgram(p)
+	return xjs.Extended.Parse(input)
+}
+
+// ParseModule parses input as an ES module with the Extended dialect, for the
+// inputs using module only constructs such as import.meta.
+func ParseModule(input []byte) (*js.Program, error) {
+	b := xjs.Extended.PluginBuilder()
+	b.WithModule()
+	return js.ParseProgram(b.Build(input))
 }
 
 func PrintExtended(result ast.Node, opts ...printer.Option) (string, error) {
-	pr := xjs.PrinterBuilder().UsePrinter(jsextended.Printer).Build(opts...)
-	pr.Print(result)
-	return pr.Output()
+	return xjs.Extended.Print(result, opts...)
 }
@@ -1,8 +1,12 @@
 package js
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/xjslang/xjs/ast"
 	"github.com/xjslang/xjs/parser"
+	"github.com/xjslang/xjs/printer"
 	"github.com/xjslang/xjs/token"
 )
 
@@ -11,20 +15,43 @@ type Variable struct {
 	token.Token
 }
 
+// IsGlobalConstant reports whether the variable refers to one of the
+// read-only globals undefined, NaN or Infinity. They aren't keywords, so they
+// are parsed and printed as any other variable.
+func (node *Variable) IsGlobalConstant() bool {
+	switch node.Literal {
+	case "undefined", "NaN", "Infinity":
+		return true
+	}
+	return false
+}
+
 type Literal struct {
 	ast.BaseExpr
 	Value token.Token
 }
 
+// StringValue returns the value of a single or double quoted string literal,
+// with its escape sequences decoded. The Value token keeps the raw spelling.
+func (node *Literal) StringValue() (string, error) {
+	return UnquoteString(node.Value.Literal)
+}
+
 func ParseExpr(p *parser.Parser) (val ast.Expr, err error) {
 	if val, err = ParseValue(p); err != nil {
 		return
 	}
+	if err = p.SkipTypeAssertion(); err != nil {
+		return
+	}
 	typ := p.CurrentToken.Type
 	for typ.IsBinaryOp() && !p.CurrentToken.AfterNewline {
 		if val, err = p.ParseBinaryExpr(val); err != nil {
 			return
 		}
+		if err = p.SkipTypeAssertion(); err != nil {
+			return
+		}
 		typ = p.CurrentToken.Type
 	}
 	return
@@ -35,8 +62,11 @@ func ParseRightExpr(p *parser.Parser, precedence int) (val ast.Expr, err error)
 		return
 	}
 	for {
+		if err = p.SkipTypeAssertion(); err != nil {
+			return
+		}
 		typ := p.CurrentToken.Type
-		if !typ.IsBinaryOp() || p.CurrentToken.AfterNewline || precedence >= typ.Precedence() {
+		if !typ.IsBinaryOp() || p.CurrentToken.AfterNewline || precedence >= p.Precedence(typ) {
 			break
 		}
 		if val, err = p.ParseBinaryExpr(val); err != nil {
@@ -54,12 +84,70 @@ func ParseValue(p *parser.Parser) (ast.Expr, error) {
 	switch typ {
 	case token.IDENT:
 		val := p.CurrentToken
+		if p.IsReserved(val.Literal) {
+			return nil, p.Error(reservedWordError)
+		}
 		p.AdvanceToken()
 		return &Variable{Token: val}, nil
-	case token.NUMBER, token.STRING:
-		val := p.CurrentToken
-		p.AdvanceToken()
-		return &Literal{Value: val}, nil
+	case token.NUMBER, token.STRING, token.BIGINT:
+		return ParseLiteral(p)
 	}
 	return nil, p.Error("expression expected")
 }
+
+// ParseLiteral parses a string, numeric or BigInt literal. The original
+// spelling is kept (quotes and n suffix included), so the literal is printed
+// back verbatim.
+func ParseLiteral(p *parser.Parser) (node *Literal, err error) {
+	tok := p.CurrentToken
+	if !tok.Type.IsLiteral() {
+		err = p.Error("literal expected")
+		return
+	}
+	node = &Literal{Value: tok}
+	p.AdvanceToken()
+	return
+}
+
+func PrintLiteral(pr *printer.Printer, node *Literal) error {
+	pr.Print(literalToken(pr, node))
+	return nil
+}
+
+// literalToken returns the token the literal is printed with, which is
+// normalized if the printer was configured so.
+func literalToken(pr *printer.Printer, node *Literal) token.Token {
+	tok := node.Value
+	if tok.Type == token.NUMBER && pr.NormalizeNumbers() {
+		tok.Literal = NormalizeNumber(tok.Literal)
+	}
+	return tok
+}
+
+// NormalizeNumber returns the shortest spelling of a decimal number, either
+// in decimal or exponential notation, such as 1e20 for 100000000000000000000
+// or .5 for 0.50. Other numbers, such as hexadecimal or legacy octal ones,
+// are returned as they are.
+func NormalizeNumber(lit string) string {
+	if len(lit) > 1 && lit[0] == '0' && lit[1] != '.' && lit[1] != 'e' && lit[1] != 'E' {
+		return lit
+	}
+	v, err := strconv.ParseFloat(lit, 64)
+	if err != nil {
+		return lit
+	}
+	short := strings.TrimPrefix(strconv.FormatFloat(v, 'f', -1, 64), "0")
+	if short == "" {
+		short = "0"
+	}
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(v, 'e', -1, 64), "e")
+	if n, _ := strconv.Atoi(exp); n != 0 {
+		if s := mantissa + "e" + strconv.Itoa(n); len(s) < len(short) {
+			short = s
+		}
+	}
+	if len(short) < len(lit) {
+		return short
+	}
+	return lit
+}
@@ -22,6 +22,9 @@ func ParseArrayExpr(p *parser.Parser) (node *ArrayExpr, err error) {
 		return
 	}
 	for p.CurrentToken.Type != token.RBRACKET {
+		if err = p.CheckArgumentCount(len(node.Values)); err != nil {
+			return
+		}
 		var val ast.Expr
 		if val, err = p.ParseExpr(); err != nil {
 			return
@@ -39,17 +42,27 @@ func ParseArrayExpr(p *parser.Parser) (node *ArrayExpr, err error) {
 }
 
 func PrintArrayExpr(pr *printer.Printer, node *ArrayExpr) error {
+	fits := pr.Fits(node)
 	pr.Print(node.Layout.Lbracket)
 	if len(node.Values) > 0 {
 		pr.IncreaseIndent()
 		for i, val := range node.Values {
-			if i > 0 {
+			if !fits {
+				// one element per line
+				if i > 0 {
+					pr.Print(",")
+				}
+				pr.Line()
+			} else if i > 0 {
 				pr.Print(",")
 				pr.Space()
 			}
 			pr.Print(val)
 		}
 		pr.DecreaseIndent()
+		if !fits {
+			pr.Line()
+		}
 	}
 	pr.Print(node.Layout.Rbracket)
 	return nil
@@ -3,6 +3,7 @@ package js
 import (
 	"github.com/xjslang/xjs/ast"
 	"github.com/xjslang/xjs/parser"
+	"github.com/xjslang/xjs/plugin"
 	"github.com/xjslang/xjs/printer"
 	"github.com/xjslang/xjs/token"
 )
@@ -35,3 +36,27 @@ func PrintAssignExpr(pr *printer.Printer, node *AssignExpr) error {
 	pr.Space().Print(node.Right)
 	return nil
 }
+
+// NoAssignInCondition rejects assignments used as the condition of an if,
+// while or for statement, such as `if (a = b)`, which are usually meant to be
+// comparisons. Parenthesized assignments, as in `if ((a = b))`, are accepted.
+func NoAssignInCondition(b *plugin.Builder) {
+	b.UseStmtParser(func(p *parser.Parser, next func() (ast.Stmt, error)) (node ast.Stmt, err error) {
+		if node, err = next(); err != nil {
+			return
+		}
+		var cond ast.Expr
+		switch v := node.(type) {
+		case *IfStmt:
+			cond = v.Cond
+		case *WhileStmt:
+			cond = v.Cond
+		case *ForStmt:
+			cond = v.Cond
+		}
+		if v, ok := cond.(*AssignExpr); ok {
+			err = p.ErrorAt(v.Layout.Assign, "assignment in condition")
+		}
+		return
+	})
+}
@@ -3,6 +3,7 @@ package js
 import (
 	"github.com/xjslang/xjs/ast"
 	"github.com/xjslang/xjs/parser"
+	"github.com/xjslang/xjs/plugin"
 	"github.com/xjslang/xjs/printer"
 	"github.com/xjslang/xjs/token"
 )
@@ -16,9 +17,15 @@ type BinaryExpr struct {
 
 func ParseBinaryExpr(p *parser.Parser, left ast.Expr) (node *BinaryExpr, err error) {
 	op := p.CurrentToken
+	if p.StrictEquality() && (op.Type == token.EQ || op.Type == token.NOT_EQ) {
+		e := p.NewError(op, op.Literal+" is not allowed, use "+op.Literal+"=")
+		e.Code = LooseEqualityCode
+		e.Fix = &parser.Fix{Range: e.Range, Text: op.Literal + "="}
+		return nil, e
+	}
 	node = &BinaryExpr{Left: left, Op: op}
 	p.AdvanceToken()
-	if node.Right, err = ParseRightExpr(p, op.Type.Precedence()); err != nil {
+	if node.Right, err = ParseRightExpr(p, p.Precedence(op.Type)); err != nil {
 		return
 	}
 	return node, nil
@@ -32,3 +39,27 @@ func PrintBinaryExpr(pr *printer.Printer, node *BinaryExpr) error {
 	pr.Space().Print(node.Right)
 	return nil
 }
+
+// NoComparisonChaining rejects comparisons whose operand is another
+// comparison, such as `a < b < c`, which is valid but almost always a bug.
+// Parenthesized comparisons are accepted.
+func NoComparisonChaining(b *plugin.Builder) {
+	b.UseBinaryParser(func(p *parser.Parser, left ast.Expr, next func(left ast.Expr) (ast.Expr, error)) (node ast.Expr, err error) {
+		if node, err = next(left); err != nil {
+			return
+		}
+		if v, ok := node.(*BinaryExpr); ok && isComparison(v.Op) {
+			if l, ok := v.Left.(*BinaryExpr); ok && isComparison(l.Op) {
+				err = p.ErrorAt(v.Op, "chained comparison")
+			} else if r, ok := v.Right.(*BinaryExpr); ok && isComparison(r.Op) {
+				err = p.ErrorAt(r.Op, "chained comparison")
+			}
+		}
+		return
+	})
+}
+
+func isComparison(op token.Token) bool {
+	prec := op.Type.Precedence()
+	return prec == token.EQ.Precedence() || prec == token.LT.Precedence()
+}
@@ -1,6 +1,10 @@
 package js
 
 import (
+	"maps"
+	"slices"
+	"strconv"
+
 	"github.com/xjslang/xjs/ast"
 	"github.com/xjslang/xjs/parser"
 	"github.com/xjslang/xjs/printer"
@@ -23,6 +27,9 @@ func ParseCallExpr(p *parser.Parser, left ast.Expr) (node *CallExpr, err error)
 		return
 	}
 	for p.CurrentToken.Type != token.RPAREN {
+		if err = p.CheckArgumentCount(len(node.Args)); err != nil {
+			return
+		}
 		var val ast.Expr
 		if val, err = p.ParseExpr(); err != nil {
 			return
@@ -40,7 +47,48 @@ func ParseCallExpr(p *parser.Parser, left ast.Expr) (node *CallExpr, err error)
 }
 
 func PrintCallExpr(pr *printer.Printer, node *CallExpr) error {
-	pr.Print(node.Callee, node.Layout.Lparen)
+	if links := chainLinks(pr); links > 0 {
+		if _, ok := node.Callee.(*MemberExpr); ok {
+			printChainLink(pr, node, links)
+			return nil
+		}
+		// a printer replaced the link, which is now the base of the chain
+		pushChainLinks(pr, 0)
+		defer pr.PopContext()
+		pr.DecreaseIndent()
+		defer pr.IncreaseIndent()
+	}
+	if n := pr.ChainBreaking(); n > 0 {
+		if _, chain := methodChain(node); len(chain) > n {
+			pr.IncreaseIndent()
+			printChainLink(pr, node, len(chain))
+			pr.DecreaseIndent()
+			return nil
+		}
+	}
+	fits := pr.Fits(node)
+	pr.Print(node.Callee)
+	printArgs(pr, node, fits)
+	return nil
+}
+
+// printArgs prints the arguments of node, one per line if the call doesn't
+// fit in the line.
+func printArgs(pr *printer.Printer, node *CallExpr, fits bool) {
+	pr.Print(node.Layout.Lparen)
+	if len(node.Args) > 0 && !fits {
+		// one argument per line
+		pr.IncreaseIndent()
+		for i, arg := range node.Args {
+			if i > 0 {
+				pr.Print(",")
+			}
+			pr.Line().Print(arg)
+		}
+		pr.DecreaseIndent()
+		pr.Line().Print(node.Layout.Rparen)
+		return
+	}
 	for i, arg := range node.Args {
 		if i > 0 {
 			pr.Print(",")
@@ -49,5 +97,78 @@ func PrintCallExpr(pr *printer.Printer, node *CallExpr) error {
 		pr.Print(arg)
 	}
 	pr.Print(node.Layout.Rparen)
-	return nil
+}
+
+// methodChain splits `base.a().b()` into base and the calls to a and b, in
+// that order.
+func methodChain(node *CallExpr) (base ast.Expr, chain []*CallExpr) {
+	base = node
+	for {
+		call, ok := base.(*CallExpr)
+		if !ok {
+			break
+		}
+		member, ok := call.Callee.(*MemberExpr)
+		if !ok {
+			break
+		}
+		chain = append(chain, call)
+		base = member.Left
+	}
+	slices.Reverse(chain)
+	return
+}
+
+// chainKey is the printer context key holding the number of links left to
+// print in a broken method chain, counting the one being printed. The links
+// are printed with Print, like any node, and the inner calls, which are
+// chains themselves, read it so that they aren't broken again.
+const chainKey = "js.chainLinks"
+
+func chainLinks(pr *printer.Printer) int {
+	n, _ := strconv.Atoi(pr.Context()[chainKey])
+	return n
+}
+
+// pushChainLinks pushes a copy of the context with chainKey set to n, or
+// unset if n is 0. The caller must pop it.
+func pushChainLinks(pr *printer.Printer, n int) {
+	ctx := maps.Clone(pr.Context())
+	pr.PushContext()
+	maps.Copy(pr.Context(), ctx)
+	if n > 0 {
+		pr.Context()[chainKey] = strconv.Itoa(n)
+	} else {
+		delete(pr.Context(), chainKey)
+	}
+}
+
+// printChainLink prints node and the links of the broken method chain it
+// ends, each on its own line, with the indentation set by the caller.
+func printChainLink(pr *printer.Printer, node *CallExpr, links int) {
+	pushChainLinks(pr, links)
+	pr.Print(node.Callee)
+	pr.PopContext()
+	pushChainLinks(pr, 0)
+	printArgs(pr, node, true)
+	pr.PopContext()
+}
+
+// printChainMember prints the callee of a link of a broken method chain,
+// starting the line of the link. The base of the chain keeps the indentation
+// of the chain.
+func printChainMember(pr *printer.Printer, node *MemberExpr, links int) {
+	pushChainLinks(pr, links-1)
+	if links == 1 {
+		pr.DecreaseIndent()
+		pr.Print(node.Left)
+		pr.IncreaseIndent()
+		if isIntegerLiteral(pr, node.Left) {
+			pr.Space()
+		}
+	} else {
+		pr.Print(node.Left)
+	}
+	pr.PopContext()
+	pr.Line().Print(node.Layout.Dot, node.Right)
 }
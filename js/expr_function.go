@@ -15,10 +15,23 @@ type FunctionExpr struct {
 		Rparen   token.Token
 	}
 	Name   *Ident
-	Params []*Ident
+	Params []ast.Node // identifiers, or patterns in dialects with destructuring
 	Body   *BlockStmt
 }
 
+// FuncName returns the name of the function, or "" if it has none. Anonymous functions have no name.
+func (node *FunctionExpr) FuncName() string {
+	if node.Name == nil {
+		return ""
+	}
+	return node.Name.Literal
+}
+
+// Arity returns the number of declared parameters.
+func (node *FunctionExpr) Arity() int {
+	return len(node.Params)
+}
+
 func ParseFunctionExpr(p *parser.Parser) (node *FunctionExpr, err error) {
 	node = &FunctionExpr{}
 	if node.Layout.Function, err = p.Expect(FUNCTION); err != nil {
@@ -32,11 +45,17 @@ func ParseFunctionExpr(p *parser.Parser) (node *FunctionExpr, err error) {
 		return
 	}
 	for p.CurrentToken.Type != token.RPAREN {
-		var name *Ident
-		if name, err = ParseIdent(p); err != nil {
+		if err = p.CheckArgumentCount(len(node.Params)); err != nil {
 			return
 		}
-		node.Params = append(node.Params, name)
+		var param ast.Node
+		if param, err = p.ParsePattern(); err != nil {
+			return
+		}
+		if err = p.SkipTypeAnnotation(); err != nil {
+			return
+		}
+		node.Params = append(node.Params, param)
 		if p.CurrentToken.Type != token.COMMA {
 			break
 		}
@@ -45,7 +64,14 @@ func ParseFunctionExpr(p *parser.Parser) (node *FunctionExpr, err error) {
 	if node.Layout.Rparen, err = p.Expect(token.RPAREN); err != nil {
 		return
 	}
-	if node.Body, err = ParseBlockStmt(p); err != nil {
+	// the return type, if any
+	if err = p.SkipTypeAnnotation(); err != nil {
+		return
+	}
+	p.EnterNamedScope(FunctionScope, node.FuncName())
+	node.Body, err = ParseBlockStmt(p)
+	p.ExitScope(FunctionScope)
+	if err != nil {
 		return
 	}
 	return node, nil
@@ -28,6 +28,30 @@ func ParseMemberExpr(p *parser.Parser, left ast.Expr) (node *MemberExpr, err err
 }
 
 func PrintMemberExpr(pr *printer.Printer, node *MemberExpr) error {
-	pr.Print(node.Left, node.Layout.Dot, node.Right)
+	if links := chainLinks(pr); links > 0 {
+		printChainMember(pr, node, links)
+		return nil
+	}
+	pr.Print(node.Left)
+	if isIntegerLiteral(pr, node.Left) {
+		// "5.toString()" would read as the number "5."
+		pr.Space()
+	}
+	pr.Print(node.Layout.Dot, node.Right)
 	return nil
 }
+
+// isIntegerLiteral reports whether expr is printed as a decimal number without
+// a decimal point or an exponent, such as 5.
+func isIntegerLiteral(pr *printer.Printer, expr ast.Expr) bool {
+	v, ok := expr.(*Literal)
+	if !ok || v.Value.Type != token.NUMBER {
+		return false
+	}
+	for _, r := range literalToken(pr, v).Literal {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -20,6 +20,10 @@ type ComputedExpr struct {
 }
 
 type ObjEntry struct {
+	Layout struct {
+		Colon token.Token
+		Comma token.Token // the comma following the entry, if any
+	}
 	Key   ast.Node
 	Value ast.Expr
 }
@@ -39,14 +43,17 @@ func ParseObjExpr(p *parser.Parser) (node *ObjExpr, err error) {
 		return
 	}
 	for p.CurrentToken.Type != token.RBRACE {
+		if err = p.CheckArgumentCount(len(node.Entries)); err != nil {
+			return
+		}
 		entry := ObjEntry{}
 		switch p.CurrentToken.Type {
 		case token.LBRACKET:
 			if entry.Key, err = ParseComputedExpr(p); err != nil {
 				return
 			}
-		case token.STRING, token.NUMBER:
-			if entry.Key, err = ParseValue(p); err != nil {
+		case token.STRING, token.NUMBER, token.BIGINT:
+			if entry.Key, err = ParseLiteral(p); err != nil {
 				return
 			}
 		default:
@@ -54,16 +61,18 @@ func ParseObjExpr(p *parser.Parser) (node *ObjExpr, err error) {
 				return
 			}
 		}
-		if _, err = p.Expect(token.COLON); err != nil {
+		if entry.Layout.Colon, err = p.Expect(token.COLON); err != nil {
 			return
 		}
 		if entry.Value, err = p.ParseExpr(); err != nil {
 			return
 		}
-		node.Entries = append(node.Entries, entry)
 		if p.CurrentToken.Type != token.COMMA {
+			node.Entries = append(node.Entries, entry)
 			break
 		}
+		entry.Layout.Comma = p.CurrentToken
+		node.Entries = append(node.Entries, entry)
 		p.AdvanceToken()
 	}
 	if node.Layout.Rbrace, err = p.Expect(token.RBRACE); err != nil {
@@ -74,7 +83,8 @@ func ParseObjExpr(p *parser.Parser) (node *ObjExpr, err error) {
 
 func ParseObjKey(p *parser.Parser) (node *Ident, err error) {
 	tok := p.CurrentToken
-	if r, s := utf8.DecodeRuneInString(tok.Literal); s == 0 || !scanner.IsLetter(r) {
+	// identifiers, or keywords, which are valid keys too
+	if r, s := utf8.DecodeRuneInString(tok.Literal); tok.Type != token.IDENT && (s == 0 || !scanner.IsLetter(r)) {
 		err = p.Error("key expected")
 		return
 	}
@@ -97,12 +107,17 @@ func ParseComputedExpr(p *parser.Parser) (node *ComputedExpr, err error) {
 }
 
 func PrintObjExpr(pr *printer.Printer, node *ObjExpr) error {
+	fits := pr.Fits(node)
 	pr.Print(node.Layout.Lbrace)
 	if len(node.Entries) > 0 {
 		pr.IncreaseIndent()
 		for i, entry := range node.Entries {
 			if i > 0 {
-				pr.Print(",")
+				PrintComma(pr, node.Entries[i-1].Layout.Comma)
+			}
+			if !fits {
+				// one entry per line
+				pr.Line()
 			}
 			switch v := entry.Key.(type) {
 			case *ComputedExpr:
@@ -111,10 +126,15 @@ func PrintObjExpr(pr *printer.Printer, node *ObjExpr) error {
 			default:
 				pr.Space().Print(v)
 			}
-			pr.Print(":")
+			PrintToken(pr, entry.Layout.Colon, ":")
 			pr.Space().Print(entry.Value)
 		}
+		// the trailing comma is dropped, but not its comments
+		pr.PrintTrivia(node.Entries[len(node.Entries)-1].Layout.Comma.LeadingTrivia)
 		pr.DecreaseIndent()
+		if !fits {
+			pr.Line()
+		}
 		pr.Space()
 	}
 	pr.Print(node.Layout.Rbrace)
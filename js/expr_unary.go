@@ -24,6 +24,11 @@ func ParseUnaryExpr(p *parser.Parser) (node *UnaryExpr, err error) {
 }
 
 func PrintUnaryExpr(pr *printer.Printer, node *UnaryExpr) error {
-	pr.Print(node.Op, node.Value)
+	pr.Print(node.Op)
+	if v, ok := node.Value.(*UnaryExpr); ok && v.Op.Type == node.Op.Type && (v.Op.Type == token.PLUS || v.Op.Type == token.MINUS) {
+		// - -x is not --x
+		pr.Space()
+	}
+	pr.Print(node.Value)
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/xjslang/xjs/ast"
 	"github.com/xjslang/xjs/parser"
 	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/scanner"
 	"github.com/xjslang/xjs/token"
 )
 
@@ -12,8 +13,18 @@ type Ident struct {
 	token.Token
 }
 
+const reservedWordError = "reserved word used as identifier"
+
 func ParseIdent(p *parser.Parser) (node *Ident, err error) {
 	node = &Ident{}
+	if tok := p.CurrentToken; tok.Type != token.IDENT && scanner.IsIdentifier(tok.Literal) {
+		err = p.Error("unexpected keyword used as identifier")
+		return
+	}
+	if p.IsReserved(p.CurrentToken.Literal) {
+		err = p.Error(reservedWordError)
+		return
+	}
 	if node.Token, err = p.Expect(token.IDENT); err != nil {
 		return
 	}
@@ -5,7 +5,6 @@ import (
 	"github.com/xjslang/xjs/parser"
 	"github.com/xjslang/xjs/plugin"
 	"github.com/xjslang/xjs/printer"
-	"github.com/xjslang/xjs/scanner"
 	"github.com/xjslang/xjs/token"
 )
 
@@ -13,39 +12,19 @@ func Plugin(b *plugin.Builder) {
 	token.RegisterUnaryType(FUNCTION)
 	token.RegisterUnaryType(DELETE)
 
-	b.UseScanner(func(sc *scanner.Scanner, next func() (token.Token, error)) (tok token.Token, err error) {
-		if tok, err = next(); err != nil {
-			return
-		}
-		if tok.Type == token.IDENT {
-			switch tok.Literal {
-			case "function":
-				tok.Type = FUNCTION
-			case "let":
-				tok.Type = LET
-			case "if":
-				tok.Type = IF
-			case "else":
-				tok.Type = ELSE
-			case "while":
-				tok.Type = WHILE
-			case "for":
-				tok.Type = FOR
-			case "return":
-				tok.Type = RETURN
-			case "break":
-				tok.Type = BREAK
-			case "continue":
-				tok.Type = CONTINUE
-			case "import":
-				tok.Type = IMPORT
-			case "export":
-				tok.Type = EXPORT
-			case "delete":
-				tok.Type = DELETE
-			}
-		}
-		return
+	b.UseKeywords(map[string]token.Type{
+		"function": FUNCTION,
+		"let":      LET,
+		"if":       IF,
+		"else":     ELSE,
+		"while":    WHILE,
+		"for":      FOR,
+		"return":   RETURN,
+		"break":    BREAK,
+		"continue": CONTINUE,
+		"import":   IMPORT,
+		"export":   EXPORT,
+		"delete":   DELETE,
 	})
 	b.UseStmtParser(func(p *parser.Parser, next func() (ast.Stmt, error)) (ast.Stmt, error) {
 		switch p.CurrentToken.Type {
@@ -73,6 +52,19 @@ func Plugin(b *plugin.Builder) {
 			switch p.PeekToken.Type {
 			case token.COLON:
 				return ParseLabelStmt(p)
+			case token.LPAREN:
+				// with is a reserved word, so this can't be a call
+				if p.CurrentToken.Literal == "with" {
+					return nil, p.Error("with statements are not supported")
+				}
+			case token.IDENT:
+				// a class declaration, rather than an identifier followed by
+				// a missing semicolon
+				if p.CurrentToken.Literal == "class" {
+					err := p.Error("class declarations are not supported")
+					skipClassDecl(p)
+					return nil, err
+				}
 			}
 		case token.SEMICOLON:
 			return ParseSemiStmt(p)
@@ -82,6 +74,9 @@ func Plugin(b *plugin.Builder) {
 	b.UseExprParser(func(p *parser.Parser, next func() (ast.Expr, error)) (ast.Expr, error) {
 		return ParseExpr(p)
 	})
+	b.UsePatternParser(func(p *parser.Parser, next func() (ast.Node, error)) (ast.Node, error) {
+		return ParseIdent(p)
+	})
 	b.UseUnaryParser(func(p *parser.Parser, next func() (ast.Expr, error)) (ast.Expr, error) {
 		switch p.CurrentToken.Type {
 		case FUNCTION:
@@ -160,8 +155,7 @@ func Printer(pr *printer.Printer, node ast.Node, next func(node ast.Node) error)
 		pr.Print(v.Token)
 		return nil
 	case *Literal:
-		pr.Print(v.Value)
-		return nil
+		return PrintLiteral(pr, v)
 	case *ExprStmt:
 		return PrintExprStmt(pr, v)
 	case *ReturnStmt:
@@ -0,0 +1,14 @@
+package js
+
+import "github.com/xjslang/xjs/token"
+
+func (*IfStmt) Branches() int    { return 1 }
+func (*WhileStmt) Branches() int { return 1 }
+func (*ForStmt) Branches() int   { return 1 }
+
+func (node *BinaryExpr) Branches() int {
+	if node.Op.Type == token.AND || node.Op.Type == token.OR {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,33 @@
+package js
+
+import "github.com/xjslang/xjs/token"
+
+// PrimaryPrecedence is the precedence of the expressions that never need
+// parentheses, such as variables, literals or groups.
+func PrimaryPrecedence() int {
+	return token.LPAREN.Precedence() + 1
+}
+
+// UnaryPrecedence is the precedence of the prefix operators.
+func UnaryPrecedence() int {
+	return token.LPAREN.Precedence() - 1
+}
+
+func (*Variable) Precedence() int     { return PrimaryPrecedence() }
+func (*Literal) Precedence() int      { return PrimaryPrecedence() }
+func (*GroupExpr) Precedence() int    { return PrimaryPrecedence() }
+func (*ArrayExpr) Precedence() int    { return PrimaryPrecedence() }
+func (*ObjExpr) Precedence() int      { return PrimaryPrecedence() }
+func (*FunctionExpr) Precedence() int { return PrimaryPrecedence() }
+func (*CallExpr) Precedence() int     { return token.LPAREN.Precedence() }
+func (*IndexExpr) Precedence() int    { return token.LBRACKET.Precedence() }
+func (*MemberExpr) Precedence() int   { return token.DOT.Precedence() }
+func (*IncExpr) Precedence() int      { return token.INCREMENT.Precedence() }
+func (*DecExpr) Precedence() int      { return token.DECREMENT.Precedence() }
+func (*UnaryExpr) Precedence() int    { return UnaryPrecedence() }
+func (*DeleteExpr) Precedence() int   { return UnaryPrecedence() }
+func (*AssignExpr) Precedence() int   { return token.ASSIGN.Precedence() }
+
+func (node *BinaryExpr) Precedence() int {
+	return node.Op.Type.Precedence()
+}
@@ -1,12 +1,16 @@
 package js
 
 import (
+	"slices"
+
 	"github.com/xjslang/xjs/ast"
 	"github.com/xjslang/xjs/parser"
 	"github.com/xjslang/xjs/printer"
 	"github.com/xjslang/xjs/token"
 )
 
+var BlockScope = parser.RegisterScope()
+
 type BlockStmt struct {
 	ast.BaseStmt
 	Layout struct {
@@ -21,6 +25,8 @@ func ParseBlockStmt(p *parser.Parser) (node *BlockStmt, err error) {
 	if node.Layout.Lbrace, err = p.Expect(token.LBRACE); err != nil {
 		return
 	}
+	p.EnterScope(BlockScope)
+	defer p.ExitScope(BlockScope)
 	var errList parser.ErrorList
 	for p.CurrentToken.Type != token.RBRACE && p.CurrentToken.Type != token.EOF {
 		prevToken := p.CurrentToken
@@ -51,19 +57,59 @@ func ParseBlockStmt(p *parser.Parser) (node *BlockStmt, err error) {
 
 func PrintBlockStmt(pr *printer.Printer, node *BlockStmt) error {
 	pr.Print(node.Layout.Lbrace)
-	if len(node.Stmts) > 0 {
-		pr.IncreaseIndent()
-		var stmt ast.Stmt
-		for _, stmt = range node.Stmts {
-			pr.Print(stmt)
+	if len(node.Stmts) == 0 {
+		// empty blocks are printed as {}, unless they contain comments
+		rbrace := node.Layout.Rbrace
+		if !slices.ContainsFunc(rbrace.LeadingTrivia, isComment) {
+			rbrace.LeadingTrivia = nil
+			if pr.EmptyBlockSpace() {
+				pr.Space()
+			}
 		}
-		pr.DecreaseIndent()
-		pr.Line()
+		pr.Print(rbrace)
+		return nil
+	}
+	pr.IncreaseIndent()
+	var stmt ast.Stmt
+	for _, stmt = range node.Stmts {
+		pr.Print(stmt)
 	}
+	pr.DecreaseIndent()
+	pr.Line()
 	pr.Print(node.Layout.Rbrace)
 	return nil
 }
 
+func isComment(tok token.Token) bool {
+	return tok.Type == token.LINE_COMMENT || tok.Type == token.BLOCK_COMMENT
+}
+
+// skipClassDecl skips a class declaration, up to the end of its body, so
+// that the body isn't reported as a block full of errors. Without a body
+// after the name and heritage clause, it stops at the end of the statement.
+func skipClassDecl(p *parser.Parser) {
+	p.AdvanceToken()
+	for p.CurrentToken.Type != token.LBRACE {
+		if p.CurrentToken.Type == token.SEMICOLON || p.CurrentToken.Type == token.EOF || p.CurrentToken.AfterNewline {
+			return
+		}
+		p.AdvanceToken()
+	}
+	depth := 0
+	for p.CurrentToken.Type != token.EOF {
+		switch p.CurrentToken.Type {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			depth--
+		}
+		p.AdvanceToken()
+		if depth == 0 {
+			break
+		}
+	}
+}
+
 func advanceToStmtEnd(p *parser.Parser) {
 	for {
 		typ := p.CurrentToken.Type
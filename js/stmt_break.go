@@ -9,6 +9,13 @@ import (
 
 var BREAK = token.RegisterType("break")
 
+var (
+	// LoopScope is entered while parsing the body of an iteration statement.
+	LoopScope = parser.RegisterScope()
+	// SwitchScope is entered while parsing the clauses of a switch statement.
+	SwitchScope = parser.RegisterScope()
+)
+
 type BreakStmt struct {
 	ast.BaseStmt
 	Layout struct {
@@ -31,6 +38,11 @@ func ParseBreakStmt(p *parser.Parser) (node *BreakStmt, err error) {
 	if node.Layout.Semi, err = ExpectSemi(p); err != nil {
 		return
 	}
+	if node.Label != nil {
+		err = checkLabel(p, node.Label, LabelScope)
+	} else if !inFunctionScope(p, LoopScope) && !inFunctionScope(p, SwitchScope) {
+		err = p.ErrorAt(node.Layout.Break, "illegal break statement")
+	}
 	return
 }
 
@@ -39,6 +51,6 @@ func PrintBreakStmt(pr *printer.Printer, node *BreakStmt) error {
 	if node.Label != nil {
 		pr.Space().Print(node.Label)
 	}
-	pr.Print(node.Layout.Semi)
+	pr.PrintSemi(node.Layout.Semi)
 	return nil
 }
@@ -31,6 +31,11 @@ func ParseContinueStmt(p *parser.Parser) (node *ContinueStmt, err error) {
 	if node.Layout.Semi, err = ExpectSemi(p); err != nil {
 		return
 	}
+	if node.Label != nil {
+		err = checkLabel(p, node.Label, LoopLabelScope)
+	} else if !inFunctionScope(p, LoopScope) {
+		err = p.ErrorAt(node.Layout.Continue, "illegal continue statement")
+	}
 	return
 }
 
@@ -39,6 +44,6 @@ func PrintContinueStmt(pr *printer.Printer, node *ContinueStmt) error {
 	if node.Label != nil {
 		pr.Space().Print(node.Label)
 	}
-	pr.Print(node.Layout.Semi)
+	pr.PrintSemi(node.Layout.Semi)
 	return nil
 }
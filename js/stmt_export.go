@@ -12,13 +12,26 @@ var EXPORT = token.RegisterType("export")
 type ExportStmt struct {
 	ast.BaseStmt
 	Layout struct {
-		Export token.Token
-		Lbrace token.Token
-		Rbrace token.Token
-		Semi   token.Token
+		Export   token.Token
+		Default  token.Token // default exports only
+		Lbrace   token.Token
+		Rbrace   token.Token
+		Multiply token.Token
+		As       token.Token
+		From     token.Token
+		Semi     token.Token
 	}
-	Decl    ast.Decl
+	Decl ast.Decl
+	// Value is the expression of a default export, such as `42` in
+	// `export default 42`, or the function in `export default function () {}`.
+	// Named functions are exported as a Decl instead.
+	Value   ast.Expr
 	Exports []*ExportNode
+	// Namespace is the name in `export * as ns from 'lib.js'`.
+	Namespace *Ident
+	// Path is the module of a re-export, such as `export { a } from 'lib.js'`
+	// or `export * from 'lib.js'`. Its type is EOF for local exports.
+	Path token.Token
 }
 
 type ExportNode struct {
@@ -30,12 +43,44 @@ type ExportNode struct {
 	Alias *Ident
 }
 
+// IsDefault reports whether the statement is a default export.
+func (node *ExportStmt) IsDefault() bool {
+	return node.Layout.Default.Literal == "default"
+}
+
 func ParseExportStmt(p *parser.Parser) (node *ExportStmt, err error) {
 	node = &ExportStmt{}
 	if node.Layout.Export, err = p.Expect(EXPORT); err != nil {
 		return
 	}
-	if p.CurrentToken.Type == token.LBRACE {
+	if p.CurrentToken.Literal == "default" {
+		err = parseDefaultExport(p, node)
+		return
+	}
+	switch p.CurrentToken.Type {
+	case token.MULTIPLY:
+		// re-export all:
+		// export * from 'lib.js'
+		// export * as lib from 'lib.js'
+		node.Layout.Multiply = p.CurrentToken
+		p.AdvanceToken()
+		if p.CurrentToken.Type == token.IDENT && p.CurrentToken.Literal == "as" {
+			node.Layout.As = p.CurrentToken
+			p.AdvanceToken()
+			if node.Namespace, err = ParseIdent(p); err != nil {
+				return
+			}
+		}
+		if node.Layout.From, err = p.ExpectString("from"); err != nil {
+			return
+		}
+		if node.Path, err = p.Expect(token.STRING); err != nil {
+			return
+		}
+		if node.Layout.Semi, err = ExpectSemi(p); err != nil {
+			return
+		}
+	case token.LBRACE:
 		node.Layout.Lbrace = p.CurrentToken
 		p.AdvanceToken()
 		for p.CurrentToken.Type != token.RBRACE {
@@ -59,10 +104,19 @@ func ParseExportStmt(p *parser.Parser) (node *ExportStmt, err error) {
 		if node.Layout.Rbrace, err = p.Expect(token.RBRACE); err != nil {
 			return
 		}
+		if p.CurrentToken.Type == token.IDENT && p.CurrentToken.Literal == "from" {
+			// re-export:
+			// export { a, b as c } from 'lib.js'
+			node.Layout.From = p.CurrentToken
+			p.AdvanceToken()
+			if node.Path, err = p.Expect(token.STRING); err != nil {
+				return
+			}
+		}
 		if node.Layout.Semi, err = ExpectSemi(p); err != nil {
 			return
 		}
-	} else {
+	default:
 		tok := p.CurrentToken
 		var stmt ast.Stmt
 		if stmt, err = p.ParseStmt(); err != nil {
@@ -77,10 +131,52 @@ func ParseExportStmt(p *parser.Parser) (node *ExportStmt, err error) {
 	return
 }
 
+// parseDefaultExport parses the rest of a default export:
+//
+//	export default function foo() {}
+//	export default function () {}
+//	export default 42;
+//
+// Like declarations, functions don't need a semicolon after them.
+func parseDefaultExport(p *parser.Parser, node *ExportStmt) (err error) {
+	node.Layout.Default = p.CurrentToken
+	p.AdvanceToken()
+	if p.CurrentToken.Type == FUNCTION {
+		if p.PeekToken.Type == token.IDENT {
+			node.Decl, err = ParseFunctionDecl(p)
+			return
+		}
+		node.Value, err = ParseFunctionExpr(p)
+		return
+	}
+	if node.Value, err = p.ParseExpr(); err != nil {
+		return
+	}
+	node.Layout.Semi, err = ExpectSemi(p)
+	return
+}
+
 func PrintExportStmt(pr *printer.Printer, node *ExportStmt) error {
 	pr.Line().Print(node.Layout.Export)
-	if node.Decl != nil {
+	if node.IsDefault() {
+		pr.Space().Print(node.Layout.Default)
+	}
+	if node.Value != nil {
+		pr.Space().Print(node.Value)
+		if node.Layout.Semi.Type == token.SEMICOLON {
+			pr.PrintSemi(node.Layout.Semi)
+		}
+	} else if node.Decl != nil {
 		pr.Space().Print(node.Decl)
+	} else if node.Layout.Multiply.Type == token.MULTIPLY {
+		pr.Space().Print(node.Layout.Multiply)
+		if node.Namespace != nil {
+			pr.Space().Print(node.Layout.As)
+			pr.Space().Print(node.Namespace)
+		}
+		pr.Space().Print(node.Layout.From)
+		pr.Space().Print(node.Path)
+		pr.PrintSemi(node.Layout.Semi)
 	} else {
 		pr.Space().Print(node.Layout.Lbrace)
 		pr.IncreaseIndent()
@@ -98,7 +194,12 @@ func PrintExportStmt(pr *printer.Printer, node *ExportStmt) error {
 		if len(node.Exports) > 0 {
 			pr.Space()
 		}
-		pr.Print(node.Layout.Rbrace, node.Layout.Semi)
+		pr.Print(node.Layout.Rbrace)
+		if node.Path.Type == token.STRING {
+			pr.Space().Print(node.Layout.From)
+			pr.Space().Print(node.Path)
+		}
+		pr.PrintSemi(node.Layout.Semi)
 	}
 	return nil
 }
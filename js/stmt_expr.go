@@ -28,6 +28,6 @@ func ParseExprStmt(p *parser.Parser) (node *ExprStmt, err error) {
 
 func PrintExprStmt(pr *printer.Printer, node *ExprStmt) error {
 	pr.Line().Print(node.Expr)
-	pr.Print(node.Layout.Semi)
+	pr.PrintSemi(node.Layout.Semi)
 	return nil
 }
@@ -9,6 +9,10 @@ import (
 
 var FOR = token.RegisterType("for")
 
+// ForInitScope is entered while parsing the init clause of for loops, where
+// dialects with for-in loops don't allow `in` operators outside of brackets.
+var ForInitScope = parser.RegisterScope()
+
 type ForStmt struct {
 	ast.BaseStmt
 	Layout struct {
@@ -20,10 +24,19 @@ type ForStmt struct {
 	}
 	Init  ast.Stmt
 	Cond  ast.Expr
-	After ast.Expr
+	After []ast.Expr // comma-separated, as in `i++, j--`
 	Then  ast.Stmt
 }
 
+// DeclKind returns the keyword of the variables declared in the init clause.
+// It reports false if the clause doesn't declare variables.
+func (node *ForStmt) DeclKind() (token.Type, bool) {
+	if v, ok := node.Init.(BindingDecl); ok {
+		return v.Kind(), true
+	}
+	return 0, false
+}
+
 func ParseForStmt(p *parser.Parser) (node *ForStmt, err error) {
 	node = &ForStmt{}
 	if node.Layout.For, err = p.Expect(FOR); err != nil {
@@ -33,7 +46,10 @@ func ParseForStmt(p *parser.Parser) (node *ForStmt, err error) {
 		return
 	}
 	if p.CurrentToken.Type != token.SEMICOLON {
-		if node.Init, err = p.ParseStmt(); err != nil {
+		p.EnterScope(ForInitScope)
+		node.Init, err = p.ParseStmt()
+		p.ExitScope(ForInitScope)
+		if err != nil {
 			return
 		}
 	} else {
@@ -48,15 +64,24 @@ func ParseForStmt(p *parser.Parser) (node *ForStmt, err error) {
 	if node.Layout.Semi2, err = p.Expect(token.SEMICOLON); err != nil {
 		return
 	}
-	if p.CurrentToken.Type != token.RPAREN {
-		if node.After, err = p.ParseExpr(); err != nil {
+	for p.CurrentToken.Type != token.RPAREN {
+		var after ast.Expr
+		if after, err = p.ParseExpr(); err != nil {
 			return
 		}
+		node.After = append(node.After, after)
+		if p.CurrentToken.Type != token.COMMA {
+			break
+		}
+		p.AdvanceToken()
 	}
 	if node.Layout.Rparen, err = p.Expect(token.RPAREN); err != nil {
 		return
 	}
-	if node.Then, err = p.ParseStmt(); err != nil {
+	p.EnterScope(LoopScope)
+	node.Then, err = p.ParseStmt()
+	p.ExitScope(LoopScope)
+	if err != nil {
 		return
 	}
 	return node, nil
@@ -74,8 +99,11 @@ func PrintForStmt(pr *printer.Printer, node *ForStmt) error {
 		pr.Space().Print(node.Cond)
 	}
 	pr.Print(node.Layout.Semi2)
-	if node.After != nil {
-		pr.Space().Print(node.After)
+	for i, after := range node.After {
+		if i > 0 {
+			pr.Print(",")
+		}
+		pr.Space().Print(after)
 	}
 	pr.DecreaseIndent()
 	pr.Print(node.Layout.Rparen)
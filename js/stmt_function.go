@@ -9,6 +9,10 @@ import (
 
 var FUNCTION = token.RegisterType("function")
 
+// FunctionScope is entered while parsing the body of a function. Its name is
+// the name of the function, if any (see parser.Parser.ScopeNames).
+var FunctionScope = parser.RegisterScope()
+
 type FunctionDecl struct {
 	ast.BaseDecl
 	Layout struct {
@@ -17,10 +21,23 @@ type FunctionDecl struct {
 		Rparen   token.Token
 	}
 	Name   *Ident
-	Params []*Ident
+	Params []ast.Node // identifiers, or patterns in dialects with destructuring
 	Body   *BlockStmt
 }
 
+// FuncName returns the name of the function, or "" if it has none.
+func (node *FunctionDecl) FuncName() string {
+	if node.Name == nil {
+		return ""
+	}
+	return node.Name.Literal
+}
+
+// Arity returns the number of declared parameters.
+func (node *FunctionDecl) Arity() int {
+	return len(node.Params)
+}
+
 func ParseFunctionDecl(p *parser.Parser) (node *FunctionDecl, err error) {
 	node = &FunctionDecl{}
 	if node.Layout.Function, err = p.Expect(FUNCTION); err != nil {
@@ -33,11 +50,17 @@ func ParseFunctionDecl(p *parser.Parser) (node *FunctionDecl, err error) {
 		return
 	}
 	for p.CurrentToken.Type != token.RPAREN {
-		var name *Ident
-		if name, err = ParseIdent(p); err != nil {
+		if err = p.CheckArgumentCount(len(node.Params)); err != nil {
 			return
 		}
-		node.Params = append(node.Params, name)
+		var param ast.Node
+		if param, err = p.ParsePattern(); err != nil {
+			return
+		}
+		if err = p.SkipTypeAnnotation(); err != nil {
+			return
+		}
+		node.Params = append(node.Params, param)
 		if p.CurrentToken.Type != token.COMMA {
 			break
 		}
@@ -46,7 +69,14 @@ func ParseFunctionDecl(p *parser.Parser) (node *FunctionDecl, err error) {
 	if node.Layout.Rparen, err = p.Expect(token.RPAREN); err != nil {
 		return
 	}
-	if node.Body, err = ParseBlockStmt(p); err != nil {
+	// the return type, if any
+	if err = p.SkipTypeAnnotation(); err != nil {
+		return
+	}
+	p.EnterNamedScope(FunctionScope, node.Name.Literal)
+	node.Body, err = ParseBlockStmt(p)
+	p.ExitScope(FunctionScope)
+	if err != nil {
 		return
 	}
 	return node, nil
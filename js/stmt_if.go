@@ -1,6 +1,8 @@
 package js
 
 import (
+	"slices"
+
 	"github.com/xjslang/xjs/ast"
 	"github.com/xjslang/xjs/parser"
 	"github.com/xjslang/xjs/printer"
@@ -42,20 +44,16 @@ func ParseIfStmt(p *parser.Parser) (node *IfStmt, err error) {
 		return
 	}
 	// then
-	if p.CurrentToken.Type == token.LBRACE {
-		if node.Then, err = p.ParseStmt(); err != nil {
+	if node.Then, err = p.ParseStmt(); err != nil {
+		return
+	}
+	// else (binds to the nearest if)
+	if p.CurrentToken.Type == ELSE {
+		node.Layout.Else = p.CurrentToken
+		p.AdvanceToken()
+		if node.Else, err = p.ParseStmt(); err != nil {
 			return
 		}
-		// else
-		if p.CurrentToken.Type == ELSE {
-			node.Layout.Else = p.CurrentToken
-			p.AdvanceToken()
-			if node.Else, err = p.ParseStmt(); err != nil {
-				return
-			}
-		}
-	} else if node.Then, err = p.ParseStmt(); err != nil {
-		return
 	}
 	return
 }
@@ -65,11 +63,43 @@ func PrintIfStmt(pr *printer.Printer, node *IfStmt) error {
 	pr.Line().Print(node.Layout.If)
 	pr.Space().Print(node.Layout.Lparen)
 	pr.Print(node.Cond, node.Layout.Rparen)
-	pr.Space().Print(node.Then)
+	PrintBody(pr, node.Then)
 	// else
-	if node.Else != nil {
-		pr.Space().Print(node.Layout.Else)
-		pr.Space().Print(node.Else)
+	if node.Else == nil {
+		return nil
+	}
+	pr.Space().Print(node.Layout.Else)
+	if v := elseIf(node.Else); v != nil {
+		pr.Space().Print(v)
+	} else {
+		PrintBody(pr, node.Else)
+	}
+	return nil
+}
+
+// elseIf returns the if statement that stmt consists of, so that
+// `else { if (a) {} }` can be printed as `else if (a) {}`.
+func elseIf(stmt ast.Stmt) *IfStmt {
+	switch v := stmt.(type) {
+	case *IfStmt:
+		return v
+	case *BlockStmt:
+		if len(v.Stmts) != 1 {
+			return nil
+		}
+		inner, ok := v.Stmts[0].(*IfStmt)
+		if !ok {
+			return nil
+		}
+		// keep the braces if comments would be lost or misplaced without them
+		for _, tok := range []token.Token{v.Layout.Lbrace, inner.Layout.If, v.Layout.Rbrace} {
+			if slices.ContainsFunc(tok.LeadingTrivia, isComment) {
+				return nil
+			}
+		}
+		node := *inner
+		node.Layout.If.LeadingTrivia = nil
+		return &node
 	}
 	return nil
 }
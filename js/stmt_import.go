@@ -140,6 +140,7 @@ func PrintImportStmt(pr *printer.Printer, node *ImportStmt) error {
 		pr.Print(node.Layout.Rbrace)
 		pr.Space().Print(node.Layout.From)
 	}
-	pr.Space().Print(node.Path, node.Layout.Semi)
+	pr.Space().Print(node.Path)
+	pr.PrintSemi(node.Layout.Semi)
 	return nil
 }
@@ -1,12 +1,23 @@
 package js
 
 import (
+	"slices"
+
 	"github.com/xjslang/xjs/ast"
 	"github.com/xjslang/xjs/parser"
 	"github.com/xjslang/xjs/printer"
 	"github.com/xjslang/xjs/token"
 )
 
+var (
+	// LabelScope is entered, named after the label, while parsing a labeled
+	// statement.
+	LabelScope = parser.RegisterScope()
+	// LoopLabelScope is entered too when the labeled statement is a loop,
+	// which continue statements can refer to.
+	LoopLabelScope = parser.RegisterScope()
+)
+
 type LabelStmt struct {
 	ast.BaseStmt
 	Layout struct {
@@ -24,14 +35,91 @@ func ParseLabelStmt(p *parser.Parser) (node *LabelStmt, err error) {
 	if node.Layout.Colon, err = p.Expect(token.COLON); err != nil {
 		return
 	}
-	if node.Stmt, err = p.ParseStmt(); err != nil {
+	name := node.Name.Literal
+	if slices.Contains(functionLabels(p, LabelScope), name) {
+		err = p.ErrorAt(node.Name.Token, "label "+name+" already declared")
+		return
+	}
+	loop := isLoopStart(p)
+	p.EnterNamedScope(LabelScope, name)
+	if loop {
+		p.EnterNamedScope(LoopLabelScope, name)
+	}
+	node.Stmt, err = p.ParseStmt()
+	if loop {
+		p.ExitScope(LoopLabelScope)
+	}
+	p.ExitScope(LabelScope)
+	if err != nil {
 		return
 	}
 	return node, nil
 }
 
+// isLoopStart reports whether the statement starting at the current token,
+// after any other labels, is an iteration statement.
+func isLoopStart(p *parser.Parser) bool {
+	n := 0
+	for p.PeekTokenN(n).Type == token.IDENT && p.PeekTokenN(n+1).Type == token.COLON {
+		n += 2
+	}
+	switch tok := p.PeekTokenN(n); tok.Type {
+	case FOR, WHILE:
+		return true
+	case token.IDENT:
+		return false
+	default:
+		// do-while loops are defined by extended dialects
+		return tok.Literal == "do"
+	}
+}
+
+// functionLabels returns the names of the enclosing instances of sc, one of
+// the label scopes, within the innermost function, as labels can't be
+// referred to from nested functions.
+func functionLabels(p *parser.Parser, sc parser.Scope) []string {
+	names := p.ScopeNames(sc)
+	count, skip := 0, 0
+	for _, s := range p.EnclosingScopes() {
+		switch s {
+		case sc:
+			count++
+		case FunctionScope:
+			skip = count
+		}
+	}
+	return names[skip:]
+}
+
+// inFunctionScope reports whether the parser is in sc within the innermost
+// function, as break and continue can't cross a function boundary.
+func inFunctionScope(p *parser.Parser, sc parser.Scope) bool {
+	in := false
+	for _, s := range p.EnclosingScopes() {
+		switch s {
+		case sc:
+			in = true
+		case FunctionScope:
+			in = false
+		}
+	}
+	return in
+}
+
+// checkLabel reports an error if label doesn't refer to an enclosing
+// statement of the kind sc stands for, one of the label scopes.
+func checkLabel(p *parser.Parser, label *Ident, sc parser.Scope) error {
+	if slices.Contains(functionLabels(p, sc), label.Literal) {
+		return nil
+	}
+	if slices.Contains(functionLabels(p, LabelScope), label.Literal) {
+		return p.ErrorAt(label.Token, "label "+label.Literal+" does not denote a loop")
+	}
+	return p.ErrorAt(label.Token, "undefined label "+label.Literal)
+}
+
 func PrintLabelStmt(pr *printer.Printer, node *LabelStmt) error {
-	pr.Print(node.Name, node.Layout.Colon)
+	pr.Line().Print(node.Name, node.Layout.Colon)
 	pr.Space().Print(node.Stmt)
 	return nil
 }
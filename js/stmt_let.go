@@ -18,6 +18,32 @@ type LetStmt struct {
 	}
 	Name  *Ident
 	Value ast.Expr
+	More  []*LetDecl // declarations after the first one, as in `let a = 1, b = 2;`
+}
+
+// BindingDecl is implemented by the variable declarations, which report the
+// keyword they were made with, such as let, or const and var in extended
+// dialects. Declarations made with var aren't block scoped, which matters,
+// for instance, for the closures created in a loop.
+type BindingDecl interface {
+	ast.Decl
+	Kind() token.Type
+}
+
+// Kind returns the declaration keyword.
+func (node *LetStmt) Kind() token.Type {
+	return node.Layout.Let.Type
+}
+
+// LetDecl is a declaration following a comma in a let statement. Unlike the
+// first one, it may have no initial value, as in `let a = 1, b;`.
+type LetDecl struct {
+	Layout struct {
+		Comma  token.Token
+		Assign token.Token
+	}
+	Name  *Ident
+	Value ast.Expr
 }
 
 func ParseLetStmt(p *parser.Parser) (node *LetStmt, err error) {
@@ -28,6 +54,12 @@ func ParseLetStmt(p *parser.Parser) (node *LetStmt, err error) {
 	if node.Name, err = ParseIdent(p); err != nil {
 		return
 	}
+	if err = p.SkipTypeAnnotation(); err != nil {
+		return
+	}
+	if err = CheckDeclAssign(p); err != nil {
+		return
+	}
 	if node.Layout.Assign, err = p.Expect(token.ASSIGN); err != nil {
 		return
 	}
@@ -35,6 +67,29 @@ func ParseLetStmt(p *parser.Parser) (node *LetStmt, err error) {
 	if err != nil {
 		return
 	}
+	for p.CurrentToken.Type == token.COMMA {
+		decl := &LetDecl{}
+		decl.Layout.Comma = p.CurrentToken
+		p.AdvanceToken()
+		if decl.Name, err = ParseIdent(p); err != nil {
+			return
+		}
+		if err = p.SkipTypeAnnotation(); err != nil {
+			return
+		}
+		if err = CheckDeclAssign(p); err != nil {
+			return
+		}
+		if p.CurrentToken.Type == token.ASSIGN || p.RequireInitializers() {
+			if decl.Layout.Assign, err = p.Expect(token.ASSIGN); err != nil {
+				return
+			}
+			if decl.Value, err = p.ParseExpr(); err != nil {
+				return
+			}
+		}
+		node.More = append(node.More, decl)
+	}
 	if node.Layout.Semi, err = ExpectSemi(p); err != nil {
 		return
 	}
@@ -46,6 +101,14 @@ func PrintLetStmt(pr *printer.Printer, node *LetStmt) error {
 	pr.Space().Print(node.Name)
 	pr.Space().Print(node.Layout.Assign)
 	pr.Space().Print(node.Value)
-	pr.Print(node.Layout.Semi)
+	for _, decl := range node.More {
+		pr.Print(decl.Layout.Comma)
+		pr.Space().Print(decl.Name)
+		if decl.Value != nil {
+			pr.Space().Print(decl.Layout.Assign)
+			pr.Space().Print(decl.Value)
+		}
+	}
+	pr.PrintSemi(node.Layout.Semi)
 	return nil
 }
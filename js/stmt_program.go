@@ -1,6 +1,8 @@
 package js
 
 import (
+	"slices"
+
 	"github.com/xjslang/xjs/ast"
 	"github.com/xjslang/xjs/parser"
 	"github.com/xjslang/xjs/printer"
@@ -13,15 +15,22 @@ type Program struct {
 		EOF token.Token
 	}
 	Stmts []ast.Stmt
+	// Filename is the name of the file the program was parsed from, if
+	// known. The parser doesn't set it, see xjs.Dialect.ParseFile.
+	Filename string
+	// Prelude is the number of statements at the top of Stmts that come
+	// from parser.Builder.WithPrelude rather than from the input.
+	Prelude int
 }
 
 func ParseProgram(p *parser.Parser) (node *Program, err error) {
-	node = &Program{}
+	node = &Program{Stmts: slices.Clone(p.Prelude()), Prelude: len(p.Prelude())}
 	var errList parser.ErrorList
 	for p.CurrentToken.Type != token.EOF {
 		prevToken := p.CurrentToken
 		var stmt ast.Stmt
 		if stmt, err = p.ParseStmt(); err != nil {
+			p.ReportError(err)
 			if eList, ok := err.(parser.ErrorList); ok {
 				errList = append(errList, eList...)
 			} else {
@@ -32,14 +32,24 @@ func ParseReturnStmt(p *parser.Parser) (node *ReturnStmt, err error) {
 	if node.Layout.Semi, err = ExpectSemi(p); err != nil {
 		return
 	}
-	return node, nil
+	if p.StrictReturn() && !p.InScope(FunctionScope) {
+		err = p.ErrorAt(node.Layout.Return, "illegal return statement")
+	}
+	return
 }
 
 func PrintReturnStmt(pr *printer.Printer, node *ReturnStmt) error {
 	pr.Line().Print(node.Layout.Return)
-	if node.Value != nil {
-		pr.Space().Print(node.Value)
+	switch v := node.Value.(type) {
+	case nil:
+	case *BinaryExpr:
+		// operands continued on the next lines are indented
+		pr.IncreaseIndent()
+		pr.Space().Print(v)
+		pr.DecreaseIndent()
+	default:
+		pr.Space().Print(v)
 	}
-	pr.Print(node.Layout.Semi)
+	pr.PrintSemi(node.Layout.Semi)
 	return nil
 }
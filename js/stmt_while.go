@@ -37,7 +37,10 @@ func ParseWhileStmt(p *parser.Parser) (node *WhileStmt, err error) {
 		return
 	}
 	// then
-	if node.Then, err = p.ParseStmt(); err != nil {
+	p.EnterScope(LoopScope)
+	node.Then, err = p.ParseStmt()
+	p.ExitScope(LoopScope)
+	if err != nil {
 		return
 	}
 	return node, nil
@@ -47,6 +50,6 @@ func PrintWhileStmt(pr *printer.Printer, node *WhileStmt) error {
 	pr.Line().Print(node.Layout.While)
 	pr.Space().Print(node.Layout.Lparen)
 	pr.Print(node.Cond, node.Layout.Rparen)
-	pr.Space().Print(node.Then)
+	PrintBody(pr, node.Then)
 	return nil
 }
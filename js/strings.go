@@ -0,0 +1,188 @@
+package js
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	errInvalidEscape     = errors.New("invalid escape sequence")
+	errUnpairedSurrogate = errors.New("unpaired surrogate escape sequence")
+)
+
+// UnquoteString returns the value of a single or double quoted string
+// literal, with its escape sequences decoded. Template literals aren't
+// supported, since their value depends on the substitutions. Neither are
+// \u escapes of unpaired surrogates, which a Go string can't hold.
+func UnquoteString(lit string) (string, error) {
+	if len(lit) < 2 || lit[0] != lit[len(lit)-1] || lit[0] != '"' && lit[0] != '\'' {
+		return "", errors.New("quoted string expected")
+	}
+	s := lit[1 : len(lit)-1]
+	var sb strings.Builder
+	var units []uint16 // pending \u escapes, which may form surrogate pairs
+	var unpaired bool
+	flush := func() {
+		for i := 0; i < len(units); i++ {
+			r := rune(units[i])
+			if utf16.IsSurrogate(r) {
+				if i+1 < len(units) {
+					r = utf16.DecodeRune(r, rune(units[i+1]))
+					i++
+				}
+				if r == utf8.RuneError || utf16.IsSurrogate(r) {
+					unpaired = true
+				}
+			}
+			sb.WriteRune(r)
+		}
+		units = units[:0]
+	}
+	for len(s) > 0 {
+		if s[0] != '\\' {
+			flush()
+			r, size := utf8.DecodeRuneInString(s)
+			sb.WriteRune(r)
+			s = s[size:]
+			continue
+		}
+		if len(s) < 2 {
+			return "", errInvalidEscape
+		}
+		c := s[1]
+		s = s[2:]
+		if c == 'u' {
+			n, rest, err := unquoteUnicode(s)
+			if err != nil {
+				return "", err
+			}
+			if n <= 0xffff {
+				units = append(units, uint16(n))
+			} else {
+				units = append(units, utf16.Encode([]rune{n})...)
+			}
+			s = rest
+			continue
+		}
+		flush()
+		switch c {
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'v':
+			sb.WriteByte('\v')
+		case 'x':
+			if len(s) < 2 {
+				return "", errInvalidEscape
+			}
+			n, err := strconv.ParseUint(s[:2], 16, 8)
+			if err != nil {
+				return "", errInvalidEscape
+			}
+			sb.WriteRune(rune(n))
+			s = s[2:]
+		case '\n':
+			// line continuation
+		case '\r':
+			// line continuation
+			s = strings.TrimPrefix(s, "\n")
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			// legacy octal escape, up to \377
+			digits, maxLen := string(c), 3
+			if c > '3' {
+				maxLen = 2
+			}
+			for len(digits) < maxLen && len(s) > 0 && '0' <= s[0] && s[0] <= '7' {
+				digits += s[:1]
+				s = s[1:]
+			}
+			n, _ := strconv.ParseUint(digits, 8, 8)
+			sb.WriteRune(rune(n))
+		default:
+			r, size := utf8.DecodeRuneInString(string(c) + s)
+			if r == '\u2028' || r == '\u2029' {
+				// line continuation
+				s = s[size-1:]
+				continue
+			}
+			sb.WriteRune(r)
+			s = s[size-1:]
+		}
+	}
+	flush()
+	if unpaired {
+		return "", errUnpairedSurrogate
+	}
+	return sb.String(), nil
+}
+
+// unquoteUnicode decodes the digits of a \u escape, either \uXXXX or \u{X...}.
+func unquoteUnicode(s string) (rune, string, error) {
+	digits := s
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 2 {
+			return 0, "", errInvalidEscape
+		}
+		digits, s = s[1:end], s[end+1:]
+	} else if len(s) >= 4 {
+		digits, s = s[:4], s[4:]
+	} else {
+		return 0, "", errInvalidEscape
+	}
+	n, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || n > utf8.MaxRune {
+		return 0, "", errInvalidEscape
+	}
+	return rune(n), s, nil
+}
+
+// QuoteString returns a string literal for s, delimited by quote, which must
+// be either a single or a double quote. Control characters are escaped,
+// whereas other non-ASCII characters are written as is.
+func QuoteString(s string, quote byte) string {
+	var sb strings.Builder
+	sb.WriteByte(quote)
+	for _, r := range s {
+		switch r {
+		case rune(quote), '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\v':
+			sb.WriteString(`\v`)
+		case '\u2028':
+			sb.WriteString(`\u2028`)
+		case '\u2029':
+			sb.WriteString(`\u2029`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				sb.WriteString(`\x`)
+				sb.WriteString(strconv.FormatUint(uint64(r)|0x100, 16)[1:])
+				continue
+			}
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte(quote)
+	return sb.String()
+}
@@ -1,10 +1,38 @@
 package js
 
 import (
+	"github.com/xjslang/xjs/ast"
 	"github.com/xjslang/xjs/parser"
+	"github.com/xjslang/xjs/printer"
 	"github.com/xjslang/xjs/token"
 )
 
+// MissingSemiCode is the code of the errors reported by ExpectSemi. They
+// come with a fix that inserts the semicolon after the previous token.
+const MissingSemiCode = "missing-semicolon"
+
+// EqualityInDeclCode is the code of the errors reported by
+// CheckDeclAssign. They come with a fix that replaces the operator with =.
+const EqualityInDeclCode = "equality-in-declaration"
+
+// LooseEqualityCode is the code of the errors reported for == and != when
+// the parser requires strict equality. They come with a fix that replaces
+// the operator with === or !==.
+const LooseEqualityCode = "loose-equality"
+
+// CheckDeclAssign reports an error if the current token, following the name
+// in a declaration, is == or ===, as in `let x == 1`, a frequent typo for =.
+func CheckDeclAssign(p *parser.Parser) error {
+	tok := p.CurrentToken
+	if tok.Literal != "==" && tok.Literal != "===" {
+		return nil
+	}
+	e := p.NewError(tok, "unexpected "+tok.Literal+", did you mean =?")
+	e.Code = EqualityInDeclCode
+	e.Fix = &parser.Fix{Range: e.Range, Text: token.ASSIGN.String()}
+	return e
+}
+
 // ExpectSemi expects a semicolon or any other symbol that acts as a
 // "statement terminator", such as '}' or ')'. If the statement terminator is a
 // semicolon, then it consumes it and advances to the next token.
@@ -22,7 +50,7 @@ func ExpectSemi(p *parser.Parser) (tok token.Token, err error) {
 		}
 		return
 	default:
-		if tok.AfterNewline {
+		if tok.AfterNewline || p.SmartSemicolons() && tok.Type != token.ILLEGAL {
 			tok = token.Token{
 				Type:     token.SEMICOLON,
 				Literal:  token.SEMICOLON.String(),
@@ -31,6 +59,45 @@ func ExpectSemi(p *parser.Parser) (tok token.Token, err error) {
 			return
 		}
 	}
-	err = p.Error(token.SEMICOLON.String() + " expected")
+	e := p.NewError(tok, token.SEMICOLON.String()+" expected")
+	if e.Code == "" {
+		// illegal tokens may come with their own error
+		e.Code = MissingSemiCode
+		end := p.PrevEnd()
+		e.Fix = &parser.Fix{Range: parser.Range{Start: end, End: end}, Text: token.SEMICOLON.String()}
+	}
+	err = e
 	return
 }
+
+// PrintBody prints the body of a control statement. Bodies other than blocks
+// are indented, so they remain readable when written on their own line.
+func PrintBody(pr *printer.Printer, body ast.Stmt) {
+	switch v := body.(type) {
+	case *BlockStmt:
+		pr.Space().Print(v)
+	case *SemiStmt:
+		// empty body, as in `while (busy());`
+		pr.Beside().Print(v)
+	default:
+		pr.IncreaseIndent()
+		pr.Space().Print(v)
+		pr.DecreaseIndent()
+	}
+}
+
+// PrintToken prints tok, or lit if tok is missing, as happens with the nodes
+// that aren't created by the parser.
+func PrintToken(pr *printer.Printer, tok token.Token, lit string) {
+	if tok.Literal == "" {
+		pr.Print(lit)
+		return
+	}
+	pr.Print(tok)
+}
+
+// PrintComma prints the comma separating two elements of a list, keeping its
+// comments.
+func PrintComma(pr *printer.Printer, tok token.Token) {
+	PrintToken(pr, tok, ",")
+}
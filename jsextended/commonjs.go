@@ -0,0 +1,189 @@
+package jsextended
+
+import (
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+// CommonJS prints import and export statements as the require calls and
+// exports assignments of CommonJS modules, as run by Node:
+//
+//	import { a, b as c } from 'm';  ->  const { a, b: c } = require('m');
+//	import * as m from 'm';         ->  const m = require('m');
+//	import m from 'm';              ->  const m = require('m').default;
+//	export let a = 1;               ->  let a = 1; exports.a = a;
+//	export default a + 1;           ->  exports.default = a + 1;
+//	export { a as b };              ->  exports.b = a;
+//	export * from 'm';              ->  Object.assign(exports, require('m'));
+//
+// Exports are assigned once, where they are declared, so later changes to
+// exported variables aren't seen by the importing modules.
+func CommonJS(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	switch v := node.(type) {
+	case *js.ImportStmt:
+		printRequire(pr, v)
+		return nil
+	case *js.ExportStmt:
+		printExports(pr, v)
+		return nil
+	}
+	return next(node)
+}
+
+func printRequire(pr *printer.Printer, node *js.ImportStmt) {
+	if node.Namespace == nil && node.Default == nil && node.Layout.Lbrace.Type != token.LBRACE {
+		// side-effects import
+		pr.Line().Print(keyword(node.Layout.Import, "require"), "(", node.Path, ")")
+		pr.PrintSemi(node.Layout.Semi)
+		return
+	}
+	pr.Line().Print(keyword(node.Layout.Import, CONST.Spelling()))
+	if node.Namespace != nil {
+		pr.Space().Print(node.Namespace)
+	} else if node.Default != nil {
+		pr.Space().Print(node.Default)
+	} else {
+		pr.Space().Print(node.Layout.Lbrace)
+		pr.IncreaseIndent()
+		for i, imp := range node.Imports {
+			if i > 0 {
+				pr.Print(',')
+			}
+			pr.Space().Print(imp.Name)
+			if imp.Alias != nil {
+				pr.Print(":")
+				pr.Space().Print(imp.Alias)
+			}
+		}
+		pr.DecreaseIndent()
+		if len(node.Imports) > 0 {
+			pr.Space()
+		}
+		pr.Print(node.Layout.Rbrace)
+	}
+	pr.Space().Print("=")
+	pr.Space().Print("require(", node.Path, ")")
+	if node.Default != nil {
+		pr.Print(".default")
+	}
+	pr.PrintSemi(node.Layout.Semi)
+}
+
+func printExports(pr *printer.Printer, node *js.ExportStmt) {
+	semi := token.Token{Type: token.SEMICOLON, Literal: token.SEMICOLON.String()}
+	export := keyword(node.Layout.Export, "")
+	if node.Value != nil {
+		pr.Line().Print(export, "exports.default = ", node.Value)
+		if node.Layout.Semi.Type == token.SEMICOLON {
+			pr.PrintSemi(node.Layout.Semi)
+		} else {
+			pr.PrintSemi(semi)
+		}
+		return
+	}
+	if node.Decl != nil {
+		// the comments before `export` go before the declaration
+		pr.PrintTrivia(node.Layout.Export.LeadingTrivia)
+		pr.Print(node.Decl)
+		for _, name := range declNames(node.Decl) {
+			if node.IsDefault() {
+				pr.Line().Print("exports.default = ", name)
+			} else {
+				pr.Line().Print("exports.", name, " = ", name)
+			}
+			pr.PrintSemi(semi)
+		}
+		return
+	}
+	if node.Layout.Multiply.Type == token.MULTIPLY {
+		if node.Namespace != nil {
+			pr.Line().Print(export, "exports.", node.Namespace.Literal, " = require(", node.Path, ")")
+		} else {
+			pr.Line().Print(export, "Object.assign(exports, require(", node.Path, "))")
+		}
+		pr.PrintSemi(node.Layout.Semi)
+		return
+	}
+	if len(node.Exports) == 0 {
+		pr.PrintTrivia(export.LeadingTrivia)
+		return
+	}
+	for i, exp := range node.Exports {
+		name := exp.Name.Literal
+		if exp.Alias != nil {
+			name = exp.Alias.Literal
+		}
+		pr.Line()
+		if i == 0 {
+			pr.Print(export)
+		}
+		pr.Print("exports.", name, " = ")
+		if node.Path.Type == token.STRING {
+			// re-export
+			pr.Print("require(", node.Path, ").", exp.Name.Literal)
+		} else {
+			pr.Print(exp.Name.Literal)
+		}
+		if i < len(node.Exports)-1 {
+			pr.PrintSemi(semi)
+		} else {
+			pr.PrintSemi(node.Layout.Semi)
+		}
+	}
+}
+
+// keyword returns a token spelled as literal with the comments of tok.
+func keyword(tok token.Token, literal string) token.Token {
+	tok.Literal = literal
+	return tok
+}
+
+// declNames returns the names declared by decl.
+func declNames(decl ast.Decl) (names []string) {
+	switch v := decl.(type) {
+	case *js.FunctionDecl:
+		names = append(names, v.Name.Literal)
+	case *js.LetStmt:
+		names = append(names, v.Name.Literal)
+		for _, d := range v.More {
+			names = append(names, d.Name.Literal)
+		}
+	case *VarStmt:
+		names = patternNames(names, v.Pattern)
+		for _, d := range v.More {
+			names = patternNames(names, d.Pattern)
+		}
+	}
+	return
+}
+
+// patternNames appends the names declared by a destructuring pattern to
+// names.
+func patternNames(names []string, pattern ast.Node) []string {
+	switch v := pattern.(type) {
+	case *js.Ident:
+		names = append(names, v.Literal)
+	case *js.Variable:
+		names = append(names, v.Literal)
+	case *js.AssignExpr:
+		// default value, as in `[a = 1]`
+		names = patternNames(names, v.Left)
+	case *SpreadExpr:
+		names = patternNames(names, v.Value)
+	case *js.ArrayExpr:
+		for _, value := range v.Values {
+			names = patternNames(names, value)
+		}
+	case *ObjExpr:
+		for _, entry := range v.Entries {
+			if entry.Value != nil {
+				names = patternNames(names, entry.Value)
+			} else {
+				names = patternNames(names, entry.Key)
+			}
+		}
+	}
+	return names
+}
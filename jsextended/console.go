@@ -0,0 +1,55 @@
+package jsextended
+
+import (
+	"strings"
+
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+// RewriteConsoleLog returns a printer that prints the calls to console.log
+// as calls to logger, a dotted name:
+//
+//	console.log('x', x);  ->  myLogger.debug('x', x);
+//
+// Only direct calls are rewritten; console.log passed as a value, or called
+// through an optional chain or brackets, is kept.
+func RewriteConsoleLog(logger string) func(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	return func(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+		v, ok := node.(*js.CallExpr)
+		if !ok || !isConsoleLog(v.Callee) {
+			return next(node)
+		}
+		call := *v
+		call.Callee = dottedName(logger, v.Callee.(*js.MemberExpr).Left.(*js.Variable).Token)
+		return next(&call)
+	}
+}
+
+func isConsoleLog(expr ast.Expr) bool {
+	member, ok := expr.(*js.MemberExpr)
+	if !ok || member.Right.Literal != "log" {
+		return false
+	}
+	console, ok := member.Left.(*js.Variable)
+	return ok && console.Literal == "console"
+}
+
+// dottedName returns the expression of a name such as "a.b.c". The first
+// identifier keeps the position and comments of tok.
+func dottedName(name string, tok token.Token) ast.Expr {
+	parts := strings.Split(name, ".")
+	tok.Type, tok.Literal = token.IDENT, parts[0]
+	var expr ast.Expr = &js.Variable{Token: tok}
+	for _, part := range parts[1:] {
+		member := &js.MemberExpr{
+			Left:  expr,
+			Right: &js.Ident{Token: token.Token{Type: token.IDENT, Literal: part}},
+		}
+		member.Layout.Dot = token.Token{Type: token.DOT, Literal: "."}
+		expr = member
+	}
+	return expr
+}
@@ -0,0 +1,225 @@
+package jsextended
+
+import (
+	"slices"
+
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+// MinifyConstants prints the constants true, false and undefined with
+// shorter expressions of the same value, as minifiers do:
+//
+//	a = [true, false, undefined];  ->  a = [!0, !1, void 0];
+//
+// They are meant for minified output only, as they are harder to read. The
+// constants are wrapped in parentheses where the shorter expressions would
+// bind differently, as in `(!0).toString()`. Functions that declare a
+// variable named undefined, and assignments to it, keep it as written.
+func MinifyConstants(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	switch v := node.(type) {
+	case *js.Variable:
+		if lit, ok := minifiedConstant(v); ok && !(v.Literal == "undefined" && pr.Context()[undefinedKey] != "") {
+			tok := v.Token
+			tok.Literal = lit
+			pr.Print(tok)
+			return nil
+		}
+	case *constantTarget:
+		pr.Print(v.Token)
+		return nil
+	case *js.Program, *js.FunctionDecl, *js.FunctionExpr, *ArrowFuncExpr:
+		if declaresUndefined(v) {
+			pr.PushContext()[undefinedKey] = "declared"
+			defer pr.PopContext()
+		}
+	case *js.AssignExpr:
+		expr := *v
+		expr.Left = keepConstants(v.Left)
+		return next(&expr)
+	case *js.IncExpr:
+		expr := *v
+		expr.Left = keepConstants(v.Left)
+		return next(&expr)
+	case *js.DecExpr:
+		expr := *v
+		expr.Left = keepConstants(v.Left)
+		return next(&expr)
+	case *ForofStmt:
+		if v.Layout.Var.Type == 0 {
+			stmt := *v
+			stmt.Pattern = keepConstants(v.Pattern.(ast.Expr))
+			return next(&stmt)
+		}
+	case *js.BinaryExpr:
+		// a prefix operation can't be the left operand of **
+		if v.Op.Type == EXPONENT {
+			expr := *v
+			expr.Left = groupConstant(v.Left)
+			return next(&expr)
+		}
+	case *js.MemberExpr:
+		expr := *v
+		expr.Left = groupConstant(v.Left)
+		return next(&expr)
+	case *js.IndexExpr:
+		expr := *v
+		expr.Value = groupConstant(v.Value)
+		return next(&expr)
+	case *js.CallExpr:
+		expr := *v
+		expr.Callee = groupConstant(v.Callee)
+		return next(&expr)
+	case *OptionalChainingExpr:
+		expr := *v
+		expr.Left = groupConstant(v.Left)
+		return next(&expr)
+	}
+	return next(node)
+}
+
+func minifiedConstant(v *js.Variable) (string, bool) {
+	switch v.Literal {
+	case "true":
+		return "!0", true
+	case "false":
+		return "!1", true
+	case "undefined":
+		return "void 0", true
+	}
+	return "", false
+}
+
+// undefinedKey is set in the printer context while printing a function that
+// declares a variable named undefined.
+const undefinedKey = "jsextended.MinifyConstants.undefined"
+
+// constantTarget is a constant that is assigned to, as in `undefined = 1`,
+// and so is printed as written.
+type constantTarget struct {
+	ast.BaseExpr
+	*js.Variable
+}
+
+// keepConstants replaces the constants assigned to by target, a variable or
+// a destructuring pattern, with constantTargets.
+func keepConstants(target ast.Expr) ast.Expr {
+	switch v := target.(type) {
+	case *js.Variable:
+		if _, ok := minifiedConstant(v); ok {
+			return &constantTarget{Variable: v}
+		}
+	case *js.AssignExpr:
+		// default value, as in `[a = 1] = b`
+		expr := *v
+		expr.Left = keepConstants(v.Left)
+		return &expr
+	case *SpreadExpr:
+		expr := *v
+		expr.Value = keepConstants(v.Value)
+		return &expr
+	case *js.ArrayExpr:
+		expr := *v
+		expr.Values = slices.Clone(v.Values)
+		for i, value := range expr.Values {
+			expr.Values[i] = keepConstants(value)
+		}
+		return &expr
+	case *ObjExpr:
+		expr := *v
+		expr.Entries = slices.Clone(v.Entries)
+		for i, entry := range expr.Entries {
+			if entry.Value != nil {
+				expr.Entries[i].Value = keepConstants(entry.Value)
+			}
+		}
+		return &expr
+	}
+	return target
+}
+
+// declaresUndefined reports whether the variables, parameters and functions
+// declared in root, a program or a function, include one named undefined.
+// Nested functions are left to their own calls, except for their names.
+func declaresUndefined(root ast.Node) bool {
+	var names []string
+	ast.Walk(root, func(node ast.Node) bool {
+		switch v := node.(type) {
+		case *js.FunctionDecl:
+			if v.Name != nil {
+				names = append(names, v.Name.Literal)
+			}
+			if node != root {
+				return false
+			}
+			for _, param := range v.Params {
+				names = patternNames(names, param)
+			}
+		case *js.FunctionExpr:
+			if node != root {
+				return false
+			}
+			if v.Name != nil {
+				names = append(names, v.Name.Literal)
+			}
+			for _, param := range v.Params {
+				names = patternNames(names, param)
+			}
+		case *ArrowFuncExpr:
+			if node != root {
+				return false
+			}
+			params := []ast.Expr{v.Params}
+			if seq, ok := v.Params.(*SequenceExpr); ok {
+				params = seq.Values
+			}
+			for _, param := range params {
+				if group, ok := param.(*js.GroupExpr); ok {
+					param = group.Value
+				}
+				names = patternNames(names, param)
+			}
+		case *js.LetStmt, *VarStmt:
+			names = append(names, declNames(v.(ast.Decl))...)
+		case *ForofStmt:
+			if v.Layout.Var.Type != 0 {
+				names = patternNames(names, v.Pattern)
+			}
+		case *TryStmt:
+			names = patternNames(names, v.CatchParam)
+		case *js.ImportStmt:
+			for _, ident := range []*js.Ident{v.Default, v.Namespace} {
+				if ident != nil {
+					names = append(names, ident.Literal)
+				}
+			}
+			for _, imp := range v.Imports {
+				if imp.Alias != nil {
+					names = append(names, imp.Alias.Literal)
+				} else {
+					names = append(names, imp.Name.Literal)
+				}
+			}
+		}
+		return true
+	})
+	return slices.Contains(names, "undefined")
+}
+
+// groupConstant wraps expr in parentheses if it is a constant printed by
+// MinifyConstants.
+func groupConstant(expr ast.Expr) ast.Expr {
+	v, ok := expr.(*js.Variable)
+	if !ok {
+		return expr
+	}
+	if _, ok := minifiedConstant(v); !ok {
+		return expr
+	}
+	group := &js.GroupExpr{Value: v}
+	group.Layout.Lparen = token.Token{Type: token.LPAREN, Literal: "("}
+	group.Layout.Rparen = token.Token{Type: token.RPAREN, Literal: ")"}
+	return group
+}
@@ -0,0 +1,414 @@
+package jsextended
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+// ES5 downlevels the constructs that ES5 environments don't understand. It must
+// be installed after Printer, so it can rewrite the nodes before they reach it:
+//
+//	let a = 1; const b = 2;  ->  var a = 1; var b = 2;
+//	(a, b) => a + b          ->  (function (a, b) { return a + b; }).bind(this)
+//	const {a, b: [c]} = d    ->  var a = d.a, _ref = d.b, c = _ref[0];
+//	for (const x of xs) f(x) ->  for (var _i = 0, _a = xs; _i < _a.length; _i++) {var x = _a[_i]; f(x);}
+//	a ** b                   ->  Math.pow(a, b)
+//	a ?? b                   ->  (a != null ? a : b)
+//	a?.b                     ->  (a == null ? void 0 : a.b)
+//	`a${b}`                  ->  ("a" + b)
+//	f(...a)                  ->  f.apply(void 0, a)
+//	[a, ...b]                ->  [a].concat(b)
+//	{a}                      ->  {a: a}
+//
+// Temporary variables are prefixed with underscores, as many as needed for
+// no identifier of the program to start with them. The spread values and
+// the values of for-of loops must be arrays, since ES5 doesn't have
+// iterators. Constructs evaluating an operand twice, such as ??, require it
+// to be a variable or a property of one. The constructs ES5 can't express,
+// such as async functions, destructuring assignments, BigInt literals and
+// new.target, are reported as errors.
+//
+// Unlike var, a let or const declared in a for loop gets a fresh binding for
+// each iteration, which the closures created in the loop capture. So when
+// the body of such a loop creates closures over the loop variables, it's
+// wrapped in a function that takes them as parameters:
+//
+//	for (let i = 0; i < n; i++) { fs.push(() => i); }
+//	->
+//	for (var i = 0; i < n; i++) {
+//	  (function (i) { fs.push(...); }).call(this, i);
+//	}
+//
+// The same goes for the let and const declared in the body of any loop, which
+// become var variables of the function, so each iteration gets its own.
+//
+// The body must not leave the function early with return, break or
+// continue, assign the loop variables, declare var variables or use
+// arguments, or an error is reported. The other dialects print let loops as
+// written, keeping their per-iteration bindings.
+func ES5(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	switch v := node.(type) {
+	case *js.Program:
+		pushES5Context(pr, es5TempKey, es5TempPrefix(v))
+		defer pr.PopContext()
+		return next(node)
+	case *es5Text:
+		pr.Print(v.Text)
+		return nil
+	case *js.LetStmt:
+		stmt := *v
+		stmt.Layout.Let.Type = VAR
+		stmt.Layout.Let.Literal = VAR.String()
+		return next(&stmt)
+	case *VarStmt:
+		if hasDestructuring(v) {
+			return printES5Destructuring(pr, v)
+		}
+		stmt := *v
+		stmt.SetKind(VAR)
+		return next(&stmt)
+	case *ArrowFuncExpr:
+		if err := checkES5Params(arrowParams(v)); err != nil {
+			return err
+		}
+		return printES5ArrowFunc(pr, v)
+	case *js.FunctionExpr:
+		if err := checkES5Params(v.Params); err != nil {
+			return err
+		}
+	case *js.FunctionDecl:
+		if err := checkES5Params(v.Params); err != nil {
+			return err
+		}
+	case *AsyncExpr:
+		return printer.ErrorAt(v.Layout.Async.Position, "async"+notES5)
+	case *AwaitExpr:
+		return printer.ErrorAt(v.Layout.Await.Position, "await"+notES5)
+	case *MetaPropertyExpr:
+		return printer.ErrorAt(v.Layout.Meta.Position, v.Layout.Meta.Literal+"."+v.Property.Literal+notES5)
+	case *js.BinaryExpr:
+		switch v.Op.Type {
+		case EXPONENT:
+			return printES5Exponent(pr, v)
+		case NULLISH:
+			return printES5Nullish(pr, v)
+		}
+	case *js.AssignExpr:
+		switch v.Layout.Assign.Type {
+		case NULLISH_ASSIGN, OR_ASSIGN, AND_ASSIGN:
+			return printES5LogicalAssign(pr, v)
+		}
+		if pos, ok := patternPosition(v.Left); ok {
+			return printer.ErrorAt(pos, "destructuring assignment"+notES5)
+		}
+	case *js.MemberExpr, *js.IndexExpr, *OptionalChainingExpr:
+		if ok, err := printES5OptionalChain(pr, v.(ast.Expr)); ok {
+			return err
+		}
+	case *js.CallExpr:
+		if ok, err := printES5OptionalChain(pr, v); ok {
+			return err
+		}
+		if slices.ContainsFunc(v.Args, isSpread) {
+			return printES5SpreadCall(pr, v)
+		}
+	case *NewExpr:
+		if err := checkES5New(v); err != nil {
+			return err
+		}
+	case *js.ArrayExpr:
+		if slices.ContainsFunc(v.Values, isSpread) {
+			printES5Spread(pr, v.Values)
+			return nil
+		}
+	case *ObjExpr:
+		obj, err := es5Obj(v)
+		if err != nil {
+			return err
+		}
+		return next(obj)
+	case *js.Literal:
+		if v.Value.Type == token.BIGINT {
+			return printer.ErrorAt(v.Value.Position, "BigInt literal"+notES5)
+		}
+		if v.Value.Type == token.STRING && strings.HasPrefix(v.Value.Literal, "`") {
+			return printES5Template(pr, v, next)
+		}
+	case *TryStmt:
+		if v.Catch == nil {
+			break
+		}
+		if pos, ok := patternPosition(v.CatchParam); ok {
+			return printer.ErrorAt(pos, "catch parameter destructuring"+notES5)
+		}
+		if v.CatchParam == nil {
+			// the catch binding is required
+			stmt := *v
+			stmt.CatchParam = &js.Ident{Token: token.Token{Type: token.IDENT, Literal: es5Temp(pr, "e", 1)}}
+			stmt.Layout.Lparen = token.Token{Type: token.LPAREN, Literal: token.LPAREN.String()}
+			stmt.Layout.Rparen = token.Token{Type: token.RPAREN, Literal: token.RPAREN.String()}
+			return next(&stmt)
+		}
+	case *ForofStmt:
+		if v.IsAwait() {
+			return printer.ErrorAt(v.Layout.Await.Position, "for await"+notES5)
+		}
+		if v.IsIn() {
+			stmt, err := es5ForIn(v)
+			if err != nil {
+				return err
+			}
+			return next(stmt)
+		}
+		return printES5Forof(pr, v)
+	case *js.ForStmt:
+		var names []string
+		if kind, ok := v.DeclKind(); ok && kind != VAR {
+			names = declNames(v.Init.(ast.Decl))
+		}
+		body, err := es5LoopStmt(names, v.Then)
+		if err != nil {
+			return err
+		}
+		stmt := *v
+		stmt.Then = body
+		return next(&stmt)
+	case *js.WhileStmt:
+		body, err := es5LoopStmt(nil, v.Then)
+		if err != nil {
+			return err
+		}
+		stmt := *v
+		stmt.Then = body
+		return next(&stmt)
+	case *DoWhileStmt:
+		body, err := es5LoopStmt(nil, v.Stmt)
+		if err != nil {
+			return err
+		}
+		stmt := *v
+		stmt.Stmt = body
+		return next(&stmt)
+	case *es5LoopBody:
+		return printES5LoopBody(pr, v)
+	}
+	return next(node)
+}
+
+// es5LoopBody is the body of a loop wrapped in a function, which takes the
+// loop variables as parameters. It's the statement calling the function,
+// within the block es5LoopStmt returns.
+type es5LoopBody struct {
+	ast.BaseStmt
+	Params []string
+	Body   ast.Stmt
+}
+
+func printES5LoopBody(pr *printer.Printer, node *es5LoopBody) error {
+	pr.Line().Print("(", js.FUNCTION.String())
+	pr.Space().Print("(")
+	for i, name := range node.Params {
+		if i > 0 {
+			pr.Print(",")
+			pr.Space()
+		}
+		pr.Print(name)
+	}
+	pr.Print(")")
+	pr.Space()
+	switch v := node.Body.(type) {
+	case *js.BlockStmt:
+		pr.Print(v)
+	default:
+		pr.Print("{")
+		pr.IncreaseIndent()
+		pr.Line().Print(v)
+		pr.DecreaseIndent()
+		pr.Line().Print("}")
+	}
+	pr.Print(").call(this")
+	for _, name := range node.Params {
+		pr.Print(",")
+		pr.Space().Print(name)
+	}
+	pr.Print(");")
+	return nil
+}
+
+// es5LoopStmt returns the body of a loop, wrapped in a function taking the
+// let or const variables of the loop, names, if it creates closures over
+// them or over the let and const variables it declares itself.
+func es5LoopStmt(names []string, body ast.Stmt) (ast.Stmt, error) {
+	if !closesOver(body, append(slices.Clip(names), blockNames(body)...)) {
+		return body, nil
+	}
+	if err := (loopBodyCheck{names: names}).walk(body); err != nil {
+		return nil, err
+	}
+	return es5Block(&es5LoopBody{Params: names, Body: body}), nil
+}
+
+// es5Block returns a block of stmts.
+func es5Block(stmts ...ast.Stmt) *js.BlockStmt {
+	block := &js.BlockStmt{Stmts: stmts}
+	block.Layout.Lbrace = token.Token{Type: token.LBRACE, Literal: token.LBRACE.String()}
+	block.Layout.Rbrace = token.Token{Type: token.RBRACE, Literal: token.RBRACE.String()}
+	return block
+}
+
+// blockNames returns the names declared with let or const in a loop body,
+// outside nested functions and loops, which declare their own.
+func blockNames(body ast.Stmt) (names []string) {
+	ast.Walk(body, func(node ast.Node) bool {
+		switch v := node.(type) {
+		case *js.FunctionExpr, *js.FunctionDecl, *ArrowFuncExpr,
+			*js.ForStmt, *js.WhileStmt, *DoWhileStmt, *ForofStmt:
+			return false
+		case *js.LetStmt:
+			names = append(names, declNames(v)...)
+		case *VarStmt:
+			if v.Kind() != VAR {
+				names = append(names, declNames(v)...)
+			}
+		}
+		return true
+	})
+	return
+}
+
+// closesOver reports whether a function within node refers to one of names.
+func closesOver(node ast.Node, names []string) (found bool) {
+	ast.Walk(node, func(node ast.Node) bool {
+		switch node.(type) {
+		case *js.FunctionExpr, *js.FunctionDecl, *ArrowFuncExpr:
+			ast.Walk(node, func(node ast.Node) bool {
+				if v, ok := node.(*js.Variable); ok && slices.Contains(names, v.Literal) {
+					found = true
+				}
+				return !found
+			})
+			return false
+		}
+		return !found
+	})
+	return
+}
+
+const wrapLoopError = " prevents wrapping the loop body in a function"
+
+// loopBodyCheck finds the statements and expressions of a loop body that
+// would behave differently if the body were wrapped in a function.
+type loopBodyCheck struct {
+	names    []string // the loop variables
+	function bool     // within a nested function
+	arrow    bool     // within a nested arrow function
+	loop     bool     // within a nested loop
+	switch_  bool     // within a nested switch
+}
+
+func (c loopBodyCheck) walk(root ast.Node) (err error) {
+	ast.Walk(root, func(node ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		if node == root {
+			return true
+		}
+		inner := c
+		switch node.(type) {
+		case *js.FunctionExpr, *js.FunctionDecl:
+			inner.function = true
+		case *ArrowFuncExpr:
+			inner.arrow = true
+		case *js.ForStmt, *js.WhileStmt, *DoWhileStmt, *ForofStmt:
+			inner.loop = true
+		case *SwitchStmt:
+			inner.switch_ = true
+		default:
+			err = c.check(node)
+			return err == nil
+		}
+		err = inner.walk(node)
+		return false
+	})
+	return
+}
+
+func (c loopBodyCheck) check(node ast.Node) error {
+	nested := c.function || c.arrow
+	switch v := node.(type) {
+	case *js.ReturnStmt:
+		if !nested {
+			return printer.ErrorAt(v.Layout.Return.Position, "return"+wrapLoopError)
+		}
+	case *js.BreakStmt:
+		if !nested && (v.Label != nil || !c.loop && !c.switch_) {
+			return printer.ErrorAt(v.Layout.Break.Position, "break"+wrapLoopError)
+		}
+	case *js.ContinueStmt:
+		if !nested && (v.Label != nil || !c.loop) {
+			return printer.ErrorAt(v.Layout.Continue.Position, "continue"+wrapLoopError)
+		}
+	case *VarStmt:
+		if !nested && v.Kind() == VAR {
+			return printer.ErrorAt(v.Layout.Var.Position, "var"+wrapLoopError)
+		}
+	case *js.Variable:
+		if !c.function && v.Literal == "arguments" {
+			return printer.ErrorAt(v.Position, "arguments"+wrapLoopError)
+		}
+	case *js.AssignExpr:
+		for _, name := range patternNames(nil, v.Left) {
+			if slices.Contains(c.names, name) {
+				return printer.ErrorAt(v.Layout.Assign.Position, "assignment to "+name+wrapLoopError)
+			}
+		}
+	case *js.IncExpr:
+		return c.checkUpdate(v.Left, v.Layout.Increment)
+	case *js.DecExpr:
+		return c.checkUpdate(v.Left, v.Layout.Decrement)
+	case *js.UnaryExpr:
+		if v.Op.Type == token.INCREMENT || v.Op.Type == token.DECREMENT {
+			return c.checkUpdate(v.Value, v.Op)
+		}
+	}
+	return nil
+}
+
+// checkUpdate reports an error if expr, updated by op, is a loop variable.
+func (c loopBodyCheck) checkUpdate(expr ast.Expr, op token.Token) error {
+	if v, ok := expr.(*js.Variable); ok && slices.Contains(c.names, v.Literal) {
+		return printer.ErrorAt(op.Position, "update of "+v.Literal+wrapLoopError)
+	}
+	return nil
+}
+
+func printES5ArrowFunc(pr *printer.Printer, node *ArrowFuncExpr) error {
+	pr.Print("(", js.FUNCTION.String())
+	pr.Space()
+	switch v := node.Params.(type) {
+	case *js.GroupExpr, *SequenceExpr:
+		pr.Print(v)
+	default:
+		pr.Print("(", v, ")")
+	}
+	pr.Space()
+	switch v := node.Body.(type) {
+	case *js.BlockStmt:
+		pr.Print(v)
+	default:
+		pr.Print("{")
+		pr.IncreaseIndent()
+		pr.Line().Print("return")
+		pr.Space().Print(v, ";")
+		pr.DecreaseIndent()
+		pr.Line().Print("}")
+	}
+	pr.Print(").bind(this)")
+	return nil
+}
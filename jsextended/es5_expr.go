@@ -0,0 +1,436 @@
+package jsextended
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+const notES5 = " is not supported in ES5"
+
+// isSimpleRef reports whether expr can be evaluated twice without side
+// effects: a variable, or properties read from one, as in `a.b.c`.
+func isSimpleRef(expr ast.Expr) bool {
+	switch v := expr.(type) {
+	case *js.Variable:
+		return true
+	case *js.MemberExpr:
+		return isSimpleRef(v.Left)
+	case *js.GroupExpr:
+		return isSimpleRef(v.Value)
+	}
+	return false
+}
+
+// printES5Exponent prints `a ** b` as `Math.pow(a, b)`.
+func printES5Exponent(pr *printer.Printer, node *js.BinaryExpr) error {
+	pr.Print("Math.pow(", node.Left, ",")
+	pr.Space().Print(node.Right, ")")
+	return nil
+}
+
+// printES5Nullish prints `a ?? b` as `(a != null ? a : b)`, which evaluates a
+// twice, so a must be a simple reference.
+func printES5Nullish(pr *printer.Printer, node *js.BinaryExpr) error {
+	if !isSimpleRef(node.Left) {
+		return printer.ErrorAt(node.Op.Position, "?? with a complex left operand"+notES5)
+	}
+	pr.Print("(", node.Left)
+	pr.Space().Print("!=")
+	pr.Space().Print("null")
+	pr.Space().Print("?")
+	pr.Space().Print(node.Left)
+	pr.Space().Print(":")
+	pr.Space().Print(node.Right, ")")
+	return nil
+}
+
+// printES5LogicalAssign prints `a ||= b`, `a &&= b` and `a ??= b` as
+// `(a || (a = b))`, `(a && (a = b))` and `(a != null ? a : a = b)`.
+func printES5LogicalAssign(pr *printer.Printer, node *js.AssignExpr) error {
+	if !isSimpleRef(node.Left) {
+		return printer.ErrorAt(node.Layout.Assign.Position, node.Layout.Assign.Literal+" with a complex target"+notES5)
+	}
+	pr.Print("(", node.Left)
+	switch node.Layout.Assign.Type {
+	case NULLISH_ASSIGN:
+		pr.Space().Print("!=")
+		pr.Space().Print("null")
+		pr.Space().Print("?")
+		pr.Space().Print(node.Left)
+		pr.Space().Print(":")
+		pr.Space().Print(node.Left)
+	case OR_ASSIGN:
+		pr.Space().Print("||")
+		pr.Space().Print("(", node.Left)
+	default:
+		pr.Space().Print("&&")
+		pr.Space().Print("(", node.Left)
+	}
+	pr.Space().Print("=")
+	pr.Space().Print(node.Right)
+	if node.Layout.Assign.Type != NULLISH_ASSIGN {
+		pr.Print(")")
+	}
+	pr.Print(")")
+	return nil
+}
+
+// withoutOptional returns the chain ending with expr, with its innermost
+// optional link made a plain one, and the value that link is read from.
+func withoutOptional(expr ast.Expr) (chain, base ast.Expr, ok bool) {
+	switch v := expr.(type) {
+	case *js.MemberExpr:
+		if left, base, ok := withoutOptional(v.Left); ok {
+			link := *v
+			link.Left = left
+			return &link, base, true
+		}
+	case *js.IndexExpr:
+		if left, base, ok := withoutOptional(v.Value); ok {
+			link := *v
+			link.Value = left
+			return &link, base, true
+		}
+	case *js.CallExpr:
+		if v.Callee == nil {
+			break
+		}
+		if left, base, ok := withoutOptional(v.Callee); ok {
+			link := *v
+			link.Callee = left
+			return &link, base, true
+		}
+	case *OptionalChainingExpr:
+		if left, base, ok := withoutOptional(v.Left); ok {
+			link := *v
+			link.Left = left
+			return &link, base, true
+		}
+		switch right := v.Right.(type) {
+		case *PropertyExpr:
+			link := &js.MemberExpr{Left: v.Left, Right: right.Name}
+			link.Layout.Dot = token.Token{Type: token.DOT, Literal: token.DOT.String()}
+			return link, v.Left, true
+		case *js.ComputedExpr:
+			link := &js.IndexExpr{Value: v.Left, Index: right.Expr}
+			link.Layout.Lbracket, link.Layout.Rbracket = right.Layout.Lbracket, right.Layout.Rbracket
+			return link, v.Left, true
+		case *js.CallExpr:
+			link := *right
+			link.Callee = v.Left
+			return &link, v.Left, true
+		}
+	}
+	return nil, nil, false
+}
+
+// printES5OptionalChain prints the chain ending with node, if it has an
+// optional link, as a conditional on the value the innermost optional link
+// reads, which must be a simple reference:
+//
+//	a.b?.c.d()  ->  (a.b == null ? void 0 : a.b.c.d())
+//
+// The rest of the chain, which may have optional links of its own, is
+// printed the same way. It reports whether node had an optional link.
+func printES5OptionalChain(pr *printer.Printer, node ast.Expr) (bool, error) {
+	chain, base, ok := withoutOptional(node)
+	if !ok {
+		return false, nil
+	}
+	if !isSimpleRef(base) {
+		var tok token.Token
+		ast.Walk(node, func(node ast.Node) bool {
+			if v, ok := node.(*OptionalChainingExpr); ok && v.Left == base {
+				tok = v.Layout.OptionalChaining
+			}
+			return tok.Literal == ""
+		})
+		return true, printer.ErrorAt(tok.Position, "?. after a complex expression"+notES5)
+	}
+	pr.Print("(", base)
+	pr.Space().Print("==")
+	pr.Space().Print("null")
+	pr.Space().Print("?")
+	pr.Space().Print("void 0")
+	pr.Space().Print(":")
+	pr.Space().Print(chain, ")")
+	return true, nil
+}
+
+// printES5Spread prints values, which have spread elements, as an array
+// built with concat:
+//
+//	[a, ...b, c]  ->  [a].concat(b, [c])
+//
+// The spread values must be arrays, since concat doesn't spread other
+// iterables.
+func printES5Spread(pr *printer.Printer, values []ast.Expr) {
+	printItems := func(items []ast.Expr) {
+		pr.Print("[")
+		for i, item := range items {
+			if i > 0 {
+				pr.Print(",")
+				pr.Space()
+			}
+			if item != nil {
+				pr.Print(item)
+			}
+		}
+		if len(items) > 0 && items[len(items)-1] == nil {
+			// a trailing hole needs its own comma
+			pr.Print(",")
+		}
+		pr.Print("]")
+	}
+	first := len(values)
+	for i, value := range values {
+		if _, ok := value.(*SpreadExpr); ok {
+			first = i
+			break
+		}
+	}
+	printItems(values[:first])
+	pr.Print(".concat(")
+	for i := first; i < len(values); {
+		if i > first {
+			pr.Print(",")
+			pr.Space()
+		}
+		if v, ok := values[i].(*SpreadExpr); ok {
+			pr.Print(v.Value)
+			i++
+			continue
+		}
+		j := i
+		for j < len(values) && !isSpread(values[j]) {
+			j++
+		}
+		printItems(values[i:j])
+		i = j
+	}
+	pr.Print(")")
+}
+
+func isSpread(expr ast.Expr) bool {
+	_, ok := expr.(*SpreadExpr)
+	return ok
+}
+
+// printES5SpreadCall prints a call with spread arguments with apply:
+//
+//	f(...a)         ->  f.apply(void 0, a)
+//	o.m(a, ...b)    ->  o.m.apply(o, [a].concat(b))
+//
+// The object of a method call is evaluated twice, so it must be a simple
+// reference.
+func printES5SpreadCall(pr *printer.Printer, node *js.CallExpr) error {
+	var this ast.Expr
+	switch v := node.Callee.(type) {
+	case *js.MemberExpr:
+		this = v.Left
+	case *js.IndexExpr:
+		this = v.Value
+	}
+	if this != nil && !isSimpleRef(this) {
+		for _, arg := range node.Args {
+			if v, ok := arg.(*SpreadExpr); ok {
+				return printer.ErrorAt(v.Layout.Spread.Position, "spread arguments to a method of a complex expression"+notES5)
+			}
+		}
+	}
+	pr.Print(node.Callee, ".apply(")
+	if this != nil {
+		pr.Print(this)
+	} else {
+		pr.Print("void 0")
+	}
+	pr.Print(",")
+	pr.Space()
+	if v, ok := node.Args[0].(*SpreadExpr); ok && len(node.Args) == 1 {
+		pr.Print(v.Value)
+	} else {
+		printES5Spread(pr, node.Args)
+	}
+	pr.Print(")")
+	return nil
+}
+
+// checkES5New reports an error for spread arguments to new, which apply
+// can't pass.
+func checkES5New(node *NewExpr) error {
+	if v, ok := node.Value.(*js.CallExpr); ok {
+		for _, arg := range v.Args {
+			if v, ok := arg.(*SpreadExpr); ok {
+				return printer.ErrorAt(v.Layout.Spread.Position, "spread arguments to new"+notES5)
+			}
+		}
+	}
+	return nil
+}
+
+// es5Obj returns node with its shorthand properties written in full, as in
+// `{a}` -> `{a: a}`, or an error for the entries ES5 doesn't have.
+func es5Obj(node *ObjExpr) (*ObjExpr, error) {
+	obj := *node
+	obj.Entries = make([]ObjEntry, len(node.Entries))
+	for i, entry := range node.Entries {
+		switch v := entry.Key.(type) {
+		case *SpreadExpr:
+			return nil, printer.ErrorAt(v.Layout.Spread.Position, "object spread"+notES5)
+		case *js.ComputedExpr:
+			return nil, printer.ErrorAt(v.Layout.Lbracket.Position, "computed property name"+notES5)
+		case *js.Ident:
+			if entry.Value == nil {
+				entry.Layout.Colon = token.Token{Type: token.COLON, Literal: token.COLON.String()}
+				entry.Value = &js.Variable{Token: v.Token}
+			}
+		}
+		obj.Entries[i] = entry
+	}
+	return &obj, nil
+}
+
+// printES5Template prints a template literal as a string literal, or as a
+// concatenation if it has substitutions:
+//
+//	`a ${b} c`  ->  ("a " + b + " c")
+func printES5Template(pr *printer.Printer, node *js.Literal, next func(node ast.Node) error) error {
+	quasis, exprs, err := splitTemplate(node.Value.Literal)
+	if err != nil {
+		return printer.ErrorAt(node.Value.Position, err.Error())
+	}
+	strs := make([]string, len(quasis))
+	for i, quasi := range quasis {
+		// line terminators are normalized, as they are in templates
+		quasi = strings.ReplaceAll(strings.ReplaceAll(quasi, "\r\n", "\n"), "\r", "\n")
+		value, err := js.UnquoteString(`"` + quasi + `"`)
+		if err != nil {
+			return printer.ErrorAt(node.Value.Position, err.Error())
+		}
+		strs[i] = js.QuoteString(value, '"')
+	}
+	if len(exprs) == 0 {
+		lit := *node
+		lit.Value.Literal = strs[0]
+		return next(&lit)
+	}
+	values := make([]ast.Expr, len(exprs))
+	for i, src := range exprs {
+		if values[i], err = parseSubstitution(pr, src); err != nil {
+			return printer.ErrorAt(node.Value.Position, "invalid template substitution: "+err.Error())
+		}
+	}
+	pr.Print("(", strs[0])
+	for i, value := range values {
+		pr.Space().Print("+")
+		pr.Space().Print(value)
+		if s := strs[i+1]; s != `""` {
+			pr.Space().Print("+")
+			pr.Space().Print(s)
+		}
+	}
+	pr.Print(")")
+	return nil
+}
+
+// parseSubstitution parses the expression of a template substitution with
+// the parser of the printer, see printer.WithParser. It's parsed
+// parenthesized, and the parentheses are kept unless the expression binds
+// tighter than +.
+func parseSubstitution(pr *printer.Printer, src string) (ast.Expr, error) {
+	result, err := pr.Parse([]byte("(" + src + ")"))
+	if err != nil {
+		return nil, err
+	}
+	program, ok := result.(*js.Program)
+	if !ok || len(program.Stmts) != program.Prelude+1 {
+		return nil, errors.New("expression expected")
+	}
+	stmt, ok := program.Stmts[program.Prelude].(*js.ExprStmt)
+	if !ok {
+		return nil, errors.New("expression expected")
+	}
+	group, ok := stmt.Expr.(*js.GroupExpr)
+	if !ok {
+		return stmt.Expr, nil
+	}
+	switch group.Value.(type) {
+	case *js.Variable, *js.Literal, *js.MemberExpr, *js.IndexExpr, *js.CallExpr:
+		return group.Value, nil
+	}
+	return group, nil
+}
+
+// splitTemplate splits the literal of a template into the raw text around
+// its substitutions and the source of the substitutions.
+func splitTemplate(lit string) (quasis, exprs []string, err error) {
+	s := lit[1 : len(lit)-1]
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			end, err := skipSubstitution(s, i+2)
+			if err != nil {
+				return nil, nil, err
+			}
+			quasis = append(quasis, s[start:i])
+			exprs = append(exprs, s[i+2:end-1])
+			start = end
+			i = end - 1
+		}
+	}
+	quasis = append(quasis, s[start:])
+	return
+}
+
+// skipSubstitution returns the offset following the closing brace of the
+// substitution starting at offset i of s, as scanned by the scanner.
+func skipSubstitution(s string, i int) (int, error) {
+	depth := 0
+	for ; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i + 1, nil
+			}
+			depth--
+		case '\'', '"', '`':
+			end, err := skipQuoted(s, i)
+			if err != nil {
+				return 0, err
+			}
+			i = end - 1
+		}
+	}
+	return 0, errors.New("unterminated template substitution")
+}
+
+// skipQuoted returns the offset following the string or template literal
+// starting at offset i of s.
+func skipQuoted(s string, i int) (int, error) {
+	quote := s[i]
+	for i++; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == quote:
+			return i + 1, nil
+		case quote == '`' && s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			end, err := skipSubstitution(s, i+2)
+			if err != nil {
+				return 0, err
+			}
+			i = end - 1
+		}
+	}
+	return 0, errors.New("unterminated string literal")
+}
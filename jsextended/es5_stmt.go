@@ -0,0 +1,373 @@
+package jsextended
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+const (
+	// es5TempKey is the context key holding the prefix of the temporary
+	// variables, which no identifier of the program starts with.
+	es5TempKey = "jsextended.es5Temp"
+	// es5LoopKey is the context key holding the number of for-of loops the
+	// printed node is in, which number their temporary variables.
+	es5LoopKey = "jsextended.es5Loops"
+)
+
+// pushES5Context pushes a copy of the context, with key set to value.
+func pushES5Context(pr *printer.Printer, key, value string) {
+	ctx := maps.Clone(pr.Context())
+	pr.PushContext()
+	maps.Copy(pr.Context(), ctx)
+	pr.Context()[key] = value
+}
+
+// es5TempPrefix returns the prefix of the temporary variables for program:
+// the shortest run of underscores no identifier of the program starts with.
+func es5TempPrefix(program *js.Program) string {
+	prefix := "_"
+	ast.Walk(program, func(node ast.Node) bool {
+		var name string
+		switch v := node.(type) {
+		case *js.Variable:
+			name = v.Literal
+		case *js.Ident:
+			name = v.Literal
+		}
+		for strings.HasPrefix(name, prefix) {
+			prefix += "_"
+		}
+		return true
+	})
+	return prefix
+}
+
+// es5Temp returns the name of a temporary variable.
+func es5Temp(pr *printer.Printer, name string, n int) string {
+	prefix := pr.Context()[es5TempKey]
+	if prefix == "" {
+		prefix = "_"
+	}
+	if n > 1 {
+		name += strconv.Itoa(n)
+	}
+	return prefix + name
+}
+
+// es5Text is a piece of code printed as is, such as a temporary variable.
+type es5Text struct {
+	ast.BaseExpr
+	Text string
+}
+
+// patternPosition returns the position of a destructuring pattern, or of a
+// parameter with a default value or a rest parameter.
+func patternPosition(node ast.Node) (token.Position, bool) {
+	switch v := node.(type) {
+	case *ObjExpr:
+		return v.Layout.Lbrace.Position, true
+	case *js.ArrayExpr:
+		return v.Layout.Lbracket.Position, true
+	case *js.AssignExpr:
+		return v.Layout.Assign.Position, true
+	case *SpreadExpr:
+		return v.Layout.Spread.Position, true
+	}
+	return token.Position{}, false
+}
+
+// checkES5Params reports an error for the parameters ES5 doesn't have:
+// destructuring patterns, default values and rest parameters.
+func checkES5Params(params []ast.Node) error {
+	for _, param := range params {
+		if pos, ok := patternPosition(param); ok {
+			return printer.ErrorAt(pos, describePattern(param)+" parameter"+notES5)
+		}
+	}
+	return nil
+}
+
+// arrowParams returns the parameters of an arrow function.
+func arrowParams(node *ArrowFuncExpr) []ast.Node {
+	switch v := node.Params.(type) {
+	case *js.GroupExpr:
+		return []ast.Node{v.Value}
+	case *SequenceExpr:
+		params := make([]ast.Node, len(v.Values))
+		for i, value := range v.Values {
+			params[i] = value
+		}
+		return params
+	}
+	return []ast.Node{node.Params}
+}
+
+func describePattern(node ast.Node) string {
+	switch node.(type) {
+	case *js.AssignExpr:
+		return "default"
+	case *SpreadExpr:
+		return "rest"
+	}
+	return "destructuring"
+}
+
+// es5Decl is a declaration of a var statement, whose value is printed from
+// strings and nodes.
+type es5Decl struct {
+	name  string
+	value []any
+}
+
+// es5Destructuring flattens the destructuring patterns of a declaration into
+// plain declarations, reading the values through temporary variables:
+//
+//	var {a, b: [c]} = d  ->  var a = d.a, _ref = d.b, c = _ref[0]
+type es5Destructuring struct {
+	pr    *printer.Printer
+	decls []es5Decl
+	temps int
+}
+
+func (d *es5Destructuring) add(pattern ast.Node, value ast.Expr) error {
+	switch v := pattern.(type) {
+	case *js.Ident:
+		if value == nil {
+			d.decls = append(d.decls, es5Decl{name: v.Literal})
+			return nil
+		}
+		d.decls = append(d.decls, es5Decl{v.Literal, []any{value}})
+		return nil
+	case *ObjExpr, *js.ArrayExpr:
+		if value == nil {
+			pos, _ := patternPosition(pattern)
+			return printer.ErrorAt(pos, "destructuring declaration without a value")
+		}
+		// the value may be read directly, unless the pattern assigns it
+		if v, ok := value.(*js.Variable); ok && !slices.Contains(patternNames(nil, pattern), v.Literal) {
+			return d.bind(pattern, []any{v.Literal})
+		}
+		return d.bind(pattern, []any{value})
+	}
+	return nil
+}
+
+// ref returns a name value can be read from more than once, declaring a
+// temporary variable for it unless it's a name already. Properties are read
+// into a temporary variable too, so that their getters run once.
+func (d *es5Destructuring) ref(value []any) string {
+	if s, ok := value[0].(string); ok && len(value) == 1 && !strings.ContainsAny(s, ".[") {
+		return s
+	}
+	d.temps++
+	name := es5Temp(d.pr, "ref", d.temps)
+	d.decls = append(d.decls, es5Decl{name, value})
+	return name
+}
+
+// bind declares the names of pattern, read from value.
+func (d *es5Destructuring) bind(pattern ast.Node, value []any) error {
+	switch v := pattern.(type) {
+	case *js.Ident:
+		d.decls = append(d.decls, es5Decl{v.Literal, value})
+	case *js.Variable:
+		d.decls = append(d.decls, es5Decl{v.Literal, value})
+	case *js.AssignExpr:
+		return d.bindDefault(v.Left, value, v.Right)
+	case *js.ArrayExpr:
+		src := d.ref(value)
+		for i, elem := range v.Values {
+			var err error
+			switch elem := elem.(type) {
+			case nil:
+			case *SpreadExpr:
+				err = d.bind(elem.Value, []any{src + ".slice(" + strconv.Itoa(i) + ")"})
+			default:
+				err = d.bind(elem, []any{src + "[" + strconv.Itoa(i) + "]"})
+			}
+			if err != nil {
+				return err
+			}
+		}
+	case *ObjExpr:
+		src := d.ref(value)
+		for _, entry := range v.Entries {
+			var access []any
+			switch key := entry.Key.(type) {
+			case *SpreadExpr:
+				return printer.ErrorAt(key.Layout.Spread.Position, "object rest"+notES5)
+			case *js.Ident:
+				access = []any{src + "." + key.Literal}
+			case *js.ComputedExpr:
+				access = []any{src + "[", key.Expr, "]"}
+			default:
+				access = []any{src + "[", key, "]"}
+			}
+			target := entry.Value
+			if target == nil {
+				target = &js.Variable{Token: entry.Key.(*js.Ident).Token}
+			}
+			var err error
+			if entry.Default != nil {
+				err = d.bindDefault(target, access, entry.Default)
+			} else {
+				err = d.bind(target, access)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		pos, _ := patternPosition(pattern)
+		return printer.ErrorAt(pos, "invalid destructuring target")
+	}
+	return nil
+}
+
+// bindDefault declares the names of pattern, read from value, or from def
+// if value is undefined.
+func (d *es5Destructuring) bindDefault(pattern ast.Node, value []any, def ast.Expr) error {
+	src := d.ref(value)
+	return d.bind(pattern, []any{src + " === void 0 ? ", def, " : " + src})
+}
+
+// printES5Destructuring prints a var, let or const statement with
+// destructuring patterns as a var statement with plain declarations.
+func printES5Destructuring(pr *printer.Printer, node *VarStmt) error {
+	d := es5Destructuring{pr: pr}
+	if err := d.add(node.Pattern, node.Value); err != nil {
+		return err
+	}
+	for _, decl := range node.More {
+		if err := d.add(decl.Pattern, decl.Value); err != nil {
+			return err
+		}
+	}
+	stmt := *node
+	stmt.SetKind(VAR)
+	pr.Line().Print(stmt.Layout.Var)
+	for i, decl := range d.decls {
+		if i > 0 {
+			pr.Print(",")
+		}
+		pr.Space().Print(decl.name)
+		if decl.value != nil {
+			pr.Space().Print("=")
+			pr.Space().Print(decl.value...)
+		}
+	}
+	pr.PrintSemi(node.Layout.Semi)
+	return nil
+}
+
+// hasDestructuring reports whether a declaration has destructuring
+// patterns.
+func hasDestructuring(node *VarStmt) bool {
+	if _, ok := node.Pattern.(*js.Ident); !ok {
+		return true
+	}
+	for _, decl := range node.More {
+		if _, ok := decl.Pattern.(*js.Ident); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// printES5Forof prints a for-of loop as a for loop over the indexes of the
+// array, declaring the loop variables at the start of each iteration:
+//
+//	for (const x of xs) f(x);
+//	->
+//	for (var _i = 0, _a = xs; _i < _a.length; _i++) {var x = _a[_i]; f(x);}
+//
+// The value must be an array, or an array-like object such as a string,
+// since ES5 doesn't have iterators. The temporary variables of nested loops
+// are numbered. A let or const loop body creating closures over the loop
+// variables is wrapped in a function, as a for loop's.
+func printES5Forof(pr *printer.Printer, node *ForofStmt) error {
+	depth, _ := strconv.Atoi(pr.Context()[es5LoopKey])
+	i, arr := es5Temp(pr, "i", depth+1), es5Temp(pr, "a", depth+1)
+	elem := &es5Text{Text: arr + "[" + i + "]"}
+	semi := token.Token{Type: token.SEMICOLON, Literal: token.SEMICOLON.String()}
+	var init ast.Stmt
+	var names []string
+	switch v := node.Pattern.(type) {
+	case *ObjExpr, *js.ArrayExpr:
+		if node.Layout.Var.Type == 0 {
+			pos, _ := patternPosition(v)
+			return printer.ErrorAt(pos, "destructuring assignment"+notES5)
+		}
+	}
+	if node.Layout.Var.Type != 0 {
+		decl := &VarStmt{Pattern: node.Pattern, Value: elem}
+		decl.Layout.Var = token.Token{Type: VAR, Literal: VAR.Spelling()}
+		decl.Layout.Assign = token.Token{Type: token.ASSIGN, Literal: token.ASSIGN.String()}
+		decl.Layout.Semi = semi
+		init = decl
+		if node.Layout.Var.Type != VAR {
+			names = patternNames(nil, node.Pattern)
+		}
+	} else {
+		assign := &js.AssignExpr{Left: node.Pattern.(ast.Expr), Right: elem}
+		assign.Layout.Assign = token.Token{Type: token.ASSIGN, Literal: token.ASSIGN.String()}
+		stmt := &js.ExprStmt{Expr: assign}
+		stmt.Layout.Semi = semi
+		init = stmt
+	}
+	body, err := es5LoopStmt(names, node.Then)
+	if err != nil {
+		return err
+	}
+	if v, ok := body.(*js.BlockStmt); ok {
+		block := *v
+		block.Stmts = append([]ast.Stmt{init}, v.Stmts...)
+		body = &block
+	} else {
+		body = es5Block(init, body)
+	}
+	pr.Line().Print(node.Layout.For)
+	pr.Space().Print(node.Layout.Lparen, VAR.Spelling())
+	pr.Space().Print(i)
+	pr.Space().Print("=")
+	pr.Space().Print("0,")
+	pr.Space().Print(arr)
+	pr.Space().Print("=")
+	pr.Space().Print(node.Value, ";")
+	pr.Space().Print(i)
+	pr.Space().Print("<")
+	pr.Space().Print(arr, ".length;")
+	pr.Space().Print(i, "++", node.Layout.Rparen)
+	pushES5Context(pr, es5LoopKey, strconv.Itoa(depth+1))
+	defer pr.PopContext()
+	pr.Space().Print(body)
+	return nil
+}
+
+// es5ForIn returns a for-in loop declaring its variable with var, with its
+// body wrapped in a function if it creates closures over a let or const
+// variable.
+func es5ForIn(node *ForofStmt) (*ForofStmt, error) {
+	stmt := *node
+	var names []string
+	if kind := stmt.Layout.Var.Type; kind != 0 && kind != VAR {
+		if pos, ok := patternPosition(node.Pattern); ok {
+			return nil, printer.ErrorAt(pos, "destructuring in for-in"+notES5)
+		}
+		stmt.Layout.Var.Type = VAR
+		stmt.Layout.Var.Literal = VAR.Spelling()
+		names = patternNames(nil, node.Pattern)
+	}
+	var err error
+	if stmt.Then, err = es5LoopStmt(names, node.Then); err != nil {
+		return nil, err
+	}
+	return &stmt, nil
+}
@@ -0,0 +1,30 @@
+package jsextended
+
+import (
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+// EscapeStrings prints string literals re-escaped from their decoded value,
+// rather than with their original spelling. Unnecessary escapes are dropped
+// and the remaining ones are written in a single canonical form:
+//
+//	'it\'s \x41\u{42}'  ->  'it\'s AB'
+//	"\q\u000A"          ->  "q\n"
+//
+// Quotes are preserved, and template literals are printed as is.
+func EscapeStrings(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	v, ok := node.(*js.Literal)
+	if !ok || v.Value.Type != token.STRING {
+		return next(node)
+	}
+	value, err := v.StringValue()
+	if err != nil {
+		return next(node)
+	}
+	lit := *v
+	lit.Value.Literal = js.QuoteString(value, v.Value.Literal[0])
+	return next(&lit)
+}
@@ -21,6 +21,9 @@ func ParseArrayExpr(p *parser.Parser) (node *js.ArrayExpr, err error) {
 					return
 				}
 			} else if p.CurrentToken.Type == token.COMMA {
+				if err = p.CheckArgumentCount(len(node.Values)); err != nil {
+					return
+				}
 				p.AdvanceToken()
 			} else {
 				break
@@ -33,6 +36,9 @@ func ParseArrayExpr(p *parser.Parser) (node *js.ArrayExpr, err error) {
 		if p.CurrentToken.Type == token.RBRACKET {
 			break
 		}
+		if err = p.CheckArgumentCount(len(node.Values)); err != nil {
+			return
+		}
 		var val ast.Expr
 		if val, err = p.ParseExpr(); err != nil {
 			return
@@ -47,11 +53,18 @@ func ParseArrayExpr(p *parser.Parser) (node *js.ArrayExpr, err error) {
 }
 
 func PrintArrayExpr(pr *printer.Printer, node *js.ArrayExpr) error {
+	fits := pr.Fits(node)
 	pr.Print(node.Layout.Lbracket)
 	if len(node.Values) > 0 {
 		pr.IncreaseIndent()
 		for i, val := range node.Values {
-			if i > 0 {
+			if !fits {
+				// one element per line
+				if i > 0 {
+					pr.Print(",")
+				}
+				pr.Line()
+			} else if i > 0 {
 				pr.Print(",")
 				pr.Space()
 			}
@@ -62,6 +75,9 @@ func PrintArrayExpr(pr *printer.Printer, node *js.ArrayExpr) error {
 			}
 		}
 		pr.DecreaseIndent()
+		if !fits {
+			pr.Line()
+		}
 	}
 	pr.Print(node.Layout.Rbracket)
 	return nil
@@ -8,7 +8,8 @@ import (
 	"github.com/xjslang/xjs/token"
 )
 
-var ARROW = token.RegisterType("=>")
+// ARROW is kept for compatibility; the scanner produces token.ARROW itself.
+var ARROW = token.ARROW
 
 type ArrowFuncExpr struct {
 	ast.BaseExpr
@@ -21,9 +22,18 @@ type ArrowFuncExpr struct {
 
 func ParseArrowFunc(p *parser.Parser, left ast.Expr) (node *ArrowFuncExpr, err error) {
 	node = &ArrowFuncExpr{Params: left}
+	// the parameters have been parsed as an expression already, so they
+	// are counted at the arrow
+	if n := arrowParamCount(left); n > 0 {
+		if err = p.CheckArgumentCount(n - 1); err != nil {
+			return
+		}
+	}
 	if node.Layout.Arrow, err = p.Expect(ARROW); err != nil {
 		return
 	}
+	p.EnterScope(js.FunctionScope)
+	defer p.ExitScope(js.FunctionScope)
 	switch p.CurrentToken.Type {
 	case token.LBRACE:
 		if node.Body, err = js.ParseBlockStmt(p); err != nil {
@@ -37,6 +47,14 @@ func ParseArrowFunc(p *parser.Parser, left ast.Expr) (node *ArrowFuncExpr, err e
 	return
 }
 
+// arrowParamCount returns the number of parameters of an arrow function.
+func arrowParamCount(params ast.Expr) int {
+	if seq, ok := params.(*SequenceExpr); ok {
+		return len(seq.Values)
+	}
+	return 1
+}
+
 func PrintArrowFunc(pr *printer.Printer, node *ArrowFuncExpr) error {
 	var printParams func(ast.Expr) error
 	printParams = func(n ast.Expr) error {
@@ -2,12 +2,18 @@ package jsextended
 
 import (
 	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
 	"github.com/xjslang/xjs/parser"
 	"github.com/xjslang/xjs/printer"
 	"github.com/xjslang/xjs/token"
 )
 
-var ASYNC = token.RegisterType("async")
+var (
+	ASYNC = token.RegisterType("async")
+	// AsyncScope is entered while parsing the expression following async,
+	// such as an async function.
+	AsyncScope = parser.RegisterScope()
+)
 
 type AsyncExpr struct {
 	ast.BaseExpr
@@ -22,12 +28,24 @@ func ParseAsyncExpr(p *parser.Parser) (node *AsyncExpr, err error) {
 	if node.Layout.Async, err = p.Expect(ASYNC); err != nil {
 		return
 	}
-	if node.Expr, err = p.ParseExpr(); err != nil {
-		return
-	}
+	p.EnterScope(AsyncScope)
+	node.Expr, err = p.ParseExpr()
+	p.ExitScope(AsyncScope)
 	return
 }
 
+// inAsyncFunction reports whether the innermost function the parser is in
+// is an async function.
+func inAsyncFunction(p *parser.Parser) bool {
+	scopes := p.EnclosingScopes()
+	for i := len(scopes) - 1; i >= 0; i-- {
+		if scopes[i] == js.FunctionScope {
+			return i > 0 && scopes[i-1] == AsyncScope
+		}
+	}
+	return false
+}
+
 func PrintAsyncExpr(pr *printer.Printer, node *AsyncExpr) error {
 	pr.Print(node.Layout.Async)
 	pr.Space().Print(node.Expr)
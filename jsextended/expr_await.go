@@ -29,6 +29,35 @@ func ParseAwaitExpr(p *parser.Parser) (node *AwaitExpr, err error) {
 	return
 }
 
+// awaitAsIdent reads the current await keyword as an identifier where it
+// isn't reserved: in functions that aren't async, including their parameters,
+// as in `function f(await) { return await; }`. At the top level, where await
+// is an operator as in modules, only bindings read it as an identifier. It
+// reports whether the keyword was turned into an identifier.
+func awaitAsIdent(p *parser.Parser, binding bool) bool {
+	if p.CurrentToken.Type != AWAIT {
+		return false
+	}
+	scopes := p.EnclosingScopes()
+	for i := len(scopes) - 1; i >= 0; i-- {
+		switch scopes[i] {
+		case js.FunctionScope:
+			if i > 0 && scopes[i-1] == AsyncScope {
+				return false
+			}
+			p.CurrentToken.Type = token.IDENT
+			return true
+		case AsyncScope:
+			// the parameters of an async function
+			return false
+		}
+	}
+	if binding {
+		p.CurrentToken.Type = token.IDENT
+	}
+	return binding
+}
+
 func PrintAwaitExpr(pr *printer.Printer, node *AwaitExpr) error {
 	pr.Print(node.Layout.Await)
 	pr.Space().Print(node.Value)
@@ -0,0 +1,39 @@
+package jsextended
+
+import (
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/parser"
+	"github.com/xjslang/xjs/token"
+)
+
+var EXPONENT = token.RegisterType("**")
+
+// ParseExponentExpr parses `left ** right`. Unlike the other binary
+// operators, ** is right-associative, so `a ** b ** c` is `a ** (b ** c)`.
+//
+// JavaScript doesn't allow a prefix operator on the left of **, as in
+// `-a ** b`, since it isn't clear whether it applies to a or to the power,
+// so the operand must be parenthesized.
+func ParseExponentExpr(p *parser.Parser, left ast.Expr) (node *js.BinaryExpr, err error) {
+	if isPrefixExpr(left) {
+		err = p.Error("unary operator before ** must be parenthesized")
+		return
+	}
+	node = &js.BinaryExpr{Left: left, Op: p.CurrentToken}
+	p.AdvanceToken()
+	if node.Right, err = js.ParseRightExpr(p, p.Precedence(EXPONENT)-1); err != nil {
+		return
+	}
+	return
+}
+
+// isPrefixExpr reports whether expr is an unparenthesized prefix operation,
+// such as `-a`, `typeof a` or `await a`.
+func isPrefixExpr(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *js.UnaryExpr, *js.DeleteExpr, *TypeofExpr, *AwaitExpr:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package jsextended
+
+import (
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/parser"
+	"github.com/xjslang/xjs/token"
+)
+
+var IN = token.RegisterType("in")
+
+// bracketScope is entered while parsing brackets in the init clause of for
+// loops, where `in` operators are allowed again.
+var bracketScope = parser.RegisterScope()
+
+// ParseInExpr parses `key in obj`. In the init clause of for loops, the
+// operator must be enclosed in brackets, so that `for (let x in obj)` is
+// always read as a for-in loop.
+func ParseInExpr(p *parser.Parser, left ast.Expr) (node *js.BinaryExpr, err error) {
+	if noIn(p) {
+		err = p.Error("in must be parenthesized in for loop initializers")
+		return
+	}
+	return js.ParseBinaryExpr(p, left)
+}
+
+// noIn reports whether the parser is in the init clause of a for loop,
+// outside of brackets and function bodies.
+func noIn(p *parser.Parser) bool {
+	scopes := p.EnclosingScopes()
+	for i := len(scopes) - 1; i >= 0; i-- {
+		switch scopes[i] {
+		case js.ForInitScope:
+			return true
+		case bracketScope, js.FunctionScope:
+			return false
+		}
+	}
+	return false
+}
+
+// allowIn calls parse in a bracketScope if the parser is in the init clause
+// of a for loop.
+func allowIn(p *parser.Parser, parse func() (ast.Expr, error)) (ast.Expr, error) {
+	if !p.InScope(js.ForInitScope) {
+		return parse()
+	}
+	p.EnterScope(bracketScope)
+	defer p.ExitScope(bracketScope)
+	return parse()
+}
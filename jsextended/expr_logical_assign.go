@@ -0,0 +1,53 @@
+package jsextended
+
+import (
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/parser"
+	"github.com/xjslang/xjs/token"
+)
+
+var (
+	NULLISH_ASSIGN = token.RegisterType("??=")
+	OR_ASSIGN      = token.RegisterType("||=")
+	AND_ASSIGN     = token.RegisterType("&&=")
+)
+
+// ParseLogicalAssignExpr parses `left ??= right`, `left ||= right` and
+// `left &&= right`. The operator is kept in the Assign token of the
+// js.AssignExpr, so it's printed as written.
+func ParseLogicalAssignExpr(p *parser.Parser, left ast.Expr) (node *js.AssignExpr, err error) {
+	node = &js.AssignExpr{Left: left}
+	node.Layout.Assign = p.CurrentToken
+	switch left.(type) {
+	case *js.Variable, *js.MemberExpr, *js.IndexExpr:
+	default:
+		// destructuring patterns aren't allowed either
+		err = p.Error("invalid assignment target")
+		return
+	}
+	p.AdvanceToken()
+	if node.Right, err = p.ParseExpr(); err != nil {
+		return
+	}
+	err = checkAssignTarget(p, node)
+	return
+}
+
+// checkAssignTarget rejects optional chains as assignment targets, as in
+// `a?.b = c`, which JavaScript doesn't allow.
+func checkAssignTarget(p *parser.Parser, node *js.AssignExpr) error {
+	for expr := node.Left; expr != nil; {
+		switch v := expr.(type) {
+		case *OptionalChainingExpr:
+			return p.ErrorAt(node.Layout.Assign, "invalid assignment target")
+		case *js.MemberExpr:
+			expr = v.Left
+		case *js.IndexExpr:
+			expr = v.Value
+		default:
+			return nil
+		}
+	}
+	return nil
+}
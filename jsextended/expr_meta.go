@@ -0,0 +1,59 @@
+package jsextended
+
+import (
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/parser"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+// MetaPropertyExpr represents new.target and import.meta.
+type MetaPropertyExpr struct {
+	ast.BaseExpr
+	Layout struct {
+		Meta token.Token
+		Dot  token.Token
+	}
+	Property *js.Ident
+}
+
+func ParseMetaPropertyExpr(p *parser.Parser) (node *MetaPropertyExpr, err error) {
+	node = &MetaPropertyExpr{}
+	node.Layout.Meta = p.CurrentToken
+	var property string
+	switch node.Layout.Meta.Type {
+	case NEW:
+		property = "target"
+	case js.IMPORT:
+		property = "meta"
+	default:
+		err = p.Error("syntax error")
+		return
+	}
+	p.AdvanceToken()
+	if node.Layout.Dot, err = p.Expect(token.DOT); err != nil {
+		return
+	}
+	if p.CurrentToken.Literal != property {
+		err = p.Error(property + " expected")
+		return
+	}
+	if node.Property, err = js.ParseObjKey(p); err != nil {
+		return
+	}
+	if node.Layout.Meta.Type == NEW && !p.InScope(js.FunctionScope) {
+		err = p.ErrorAt(node.Layout.Meta, "new.target expression is not allowed here")
+		return
+	}
+	if node.Layout.Meta.Type == js.IMPORT && !p.Module() {
+		err = p.ErrorAt(node.Layout.Meta, "import.meta is only allowed in modules")
+		return
+	}
+	return
+}
+
+func PrintMetaPropertyExpr(pr *printer.Printer, node *MetaPropertyExpr) error {
+	pr.Print(node.Layout.Meta, node.Layout.Dot, node.Property)
+	return nil
+}
@@ -10,6 +10,8 @@ import (
 
 var NEW = token.RegisterType("new")
 
+// NewExpr is a new expression. If the constructor is called with arguments,
+// as in `new C(a)`, Value is a *js.CallExpr holding them.
 type NewExpr struct {
 	ast.BaseExpr
 	Layout struct {
@@ -23,9 +25,26 @@ func ParseNewExpr(p *parser.Parser) (node *NewExpr, err error) {
 	if node.Layout.New, err = p.Expect(NEW); err != nil {
 		return
 	}
-	if node.Value, err = js.ParseRightExpr(p, token.LPAREN.Precedence()-1); err != nil {
+	if node.Value, err = js.ParseValue(p); err != nil {
 		return
 	}
+	// the constructor is a member expression: new a.b[c]
+	for {
+		typ := p.CurrentToken.Type
+		if typ != token.DOT && typ != token.LBRACKET || p.CurrentToken.AfterNewline {
+			break
+		}
+		if node.Value, err = p.ParseBinaryExpr(node.Value); err != nil {
+			return
+		}
+	}
+	// the first arguments belong to new, so new C().x is (new C()).x, and
+	// Value holds them as a call
+	if p.CurrentToken.Type == token.LPAREN && !p.CurrentToken.AfterNewline {
+		if node.Value, err = p.ParseBinaryExpr(node.Value); err != nil {
+			return
+		}
+	}
 	return
 }
 
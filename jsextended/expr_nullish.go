@@ -0,0 +1,38 @@
+package jsextended
+
+import (
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/parser"
+	"github.com/xjslang/xjs/token"
+)
+
+var NULLISH = token.RegisterType("??")
+
+// ParseNullishExpr parses `left ?? right`.
+func ParseNullishExpr(p *parser.Parser, left ast.Expr) (node *js.BinaryExpr, err error) {
+	if node, err = js.ParseBinaryExpr(p, left); err != nil {
+		return
+	}
+	err = checkNullishMixing(p, node)
+	return
+}
+
+// checkNullishMixing rejects ?? expressions whose operands are || or &&
+// expressions, and vice versa, as in `a ?? b || c`. JavaScript requires
+// parentheses in those cases.
+func checkNullishMixing(p *parser.Parser, node *js.BinaryExpr) error {
+	isLogical := func(typ token.Type) bool {
+		return typ == token.OR || typ == token.AND
+	}
+	for _, operand := range []ast.Expr{node.Left, node.Right} {
+		v, ok := operand.(*js.BinaryExpr)
+		if !ok {
+			continue
+		}
+		if node.Op.Type == NULLISH && isLogical(v.Op.Type) || isLogical(node.Op.Type) && v.Op.Type == NULLISH {
+			return p.ErrorAt(node.Op, "cannot mix ?? with || or && without parentheses")
+		}
+	}
+	return nil
+}
@@ -9,6 +9,11 @@ import (
 )
 
 type ObjEntry struct {
+	Layout struct {
+		Colon  token.Token
+		Assign token.Token
+		Comma  token.Token // the comma following the entry, if any
+	}
 	Key     ast.Node
 	Value   ast.Expr
 	Default ast.Expr
@@ -29,14 +34,21 @@ func ParseObjExpr(p *parser.Parser) (node *ObjExpr, err error) {
 		return
 	}
 	for p.CurrentToken.Type != token.RBRACE {
+		if err = p.CheckArgumentCount(len(node.Entries)); err != nil {
+			return
+		}
 		entry := ObjEntry{}
 		switch p.CurrentToken.Type {
 		case token.LBRACKET:
 			if entry.Key, err = js.ParseComputedExpr(p); err != nil {
 				return
 			}
-		case token.STRING, token.NUMBER, SPREAD:
-			if entry.Key, err = js.ParseValue(p); err != nil {
+		case token.STRING, token.NUMBER, token.BIGINT:
+			if entry.Key, err = js.ParseLiteral(p); err != nil {
+				return
+			}
+		case SPREAD:
+			if entry.Key, err = ParseSpreadExpr(p); err != nil {
 				return
 			}
 		default:
@@ -45,21 +57,25 @@ func ParseObjExpr(p *parser.Parser) (node *ObjExpr, err error) {
 			}
 		}
 		if p.CurrentToken.Type == token.COLON {
+			entry.Layout.Colon = p.CurrentToken
 			p.AdvanceToken()
 			if entry.Value, err = js.ParseRightExpr(p, token.ASSIGN.Precedence()); err != nil {
 				return
 			}
 		}
 		if p.CurrentToken.Type == token.ASSIGN {
+			entry.Layout.Assign = p.CurrentToken
 			p.AdvanceToken()
 			if entry.Default, err = p.ParseExpr(); err != nil {
 				return
 			}
 		}
-		node.Entries = append(node.Entries, entry)
 		if p.CurrentToken.Type != token.COMMA {
+			node.Entries = append(node.Entries, entry)
 			break
 		}
+		entry.Layout.Comma = p.CurrentToken
+		node.Entries = append(node.Entries, entry)
 		p.AdvanceToken()
 	}
 	if node.Layout.Rbrace, err = p.Expect(token.RBRACE); err != nil {
@@ -69,12 +85,17 @@ func ParseObjExpr(p *parser.Parser) (node *ObjExpr, err error) {
 }
 
 func PrintObjExpr(pr *printer.Printer, node *ObjExpr) error {
+	fits := pr.Fits(node)
 	pr.Print(node.Layout.Lbrace)
 	if len(node.Entries) > 0 {
 		pr.IncreaseIndent()
 		for i, entry := range node.Entries {
 			if i > 0 {
-				pr.Print(",")
+				js.PrintComma(pr, node.Entries[i-1].Layout.Comma)
+			}
+			if !fits {
+				// one entry per line
+				pr.Line()
 			}
 			switch v := entry.Key.(type) {
 			case *js.ComputedExpr:
@@ -83,15 +104,21 @@ func PrintObjExpr(pr *printer.Printer, node *ObjExpr) error {
 				pr.Space().Print(v)
 			}
 			if entry.Value != nil {
-				pr.Print(":")
+				js.PrintToken(pr, entry.Layout.Colon, ":")
 				pr.Space().Print(entry.Value)
 			}
 			if entry.Default != nil {
-				pr.Space().Print("=")
+				pr.Space()
+				js.PrintToken(pr, entry.Layout.Assign, "=")
 				pr.Space().Print(entry.Default)
 			}
 		}
+		// the trailing comma is dropped, but not its comments
+		pr.PrintTrivia(node.Entries[len(node.Entries)-1].Layout.Comma.LeadingTrivia)
 		pr.DecreaseIndent()
+		if !fits {
+			pr.Line()
+		}
 		pr.Space()
 	}
 	pr.Print(node.Layout.Rbrace)
@@ -10,6 +10,12 @@ import (
 
 var OPTIONAL_CHAINING = token.RegisterType("?.")
 
+// OptionalChainingExpr represents one optional link of a chain. Right is one
+// of the following:
+//
+//	*PropertyExpr    a?.b
+//	*js.ComputedExpr a?.[b]
+//	*js.CallExpr     a?.(b) (with a nil Callee)
 type OptionalChainingExpr struct {
 	ast.BaseExpr
 	Layout struct {
@@ -19,19 +25,57 @@ type OptionalChainingExpr struct {
 	Right ast.Expr
 }
 
+// PropertyExpr is the property name an optional link reads, the b of a?.b.
+type PropertyExpr struct {
+	ast.BaseExpr
+	Name *js.Ident
+}
+
 func ParseOptionalChainingExpr(p *parser.Parser, left ast.Expr) (node *OptionalChainingExpr, err error) {
 	node = &OptionalChainingExpr{Left: left}
+	if v, ok := left.(*NewExpr); ok {
+		// `new a?.b()` would be read as `(new a)?.b()`, which JavaScript
+		// doesn't allow without the arguments of new
+		if _, ok := v.Value.(*js.CallExpr); !ok {
+			err = p.Error("invalid optional chain from new expression")
+			return
+		}
+	}
 	if node.Layout.OptionalChaining, err = p.Expect(OPTIONAL_CHAINING); err != nil {
 		return
 	}
-	// TODO: ParseOptionalChainingExpr delegates to js.ParseRightExpr. When the token after `?.` is `(`, ParseRightExpr parses it as a GroupExpr (see js.ParseGroupExpr), so `fn?.(a, b)` will fail at the comma (GroupExpr only parses a single expression and then expects `)`), and `?.[` will be treated as starting an array literal instead of optional element access. Optional chaining needs to parse the specific postfix forms (`?.ident`, `?.(args...)`, `?.[expr]`) similarly to how the core parser handles `.`, `(`, and `[` as binary postfix operators.
-	if node.Right, err = js.ParseRightExpr(p, node.Layout.OptionalChaining.Type.Precedence()); err != nil {
-		return
+	switch p.CurrentToken.Type {
+	case token.LPAREN:
+		node.Right, err = js.ParseCallExpr(p, nil)
+	case token.LBRACKET:
+		node.Right, err = js.ParseComputedExpr(p)
+	default:
+		right := &PropertyExpr{}
+		node.Right = right
+		right.Name, err = js.ParseObjKey(p)
 	}
 	return
 }
 
 func PrintOptionalChainingExpr(pr *printer.Printer, node *OptionalChainingExpr) error {
-	pr.Print(node.Left, node.Layout.OptionalChaining, node.Right)
+	pr.Print(node.Left, node.Layout.OptionalChaining)
+	switch v := node.Right.(type) {
+	case *PropertyExpr:
+		pr.Print(v.Name)
+	case *js.ComputedExpr:
+		pr.Print(v.Layout.Lbracket, v.Expr, v.Layout.Rbracket)
+	case *js.CallExpr:
+		pr.Print(v.Layout.Lparen)
+		for i, arg := range v.Args {
+			if i > 0 {
+				pr.Print(",")
+				pr.Space()
+			}
+			pr.Print(arg)
+		}
+		pr.Print(v.Layout.Rparen)
+	default:
+		pr.Print(v)
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package jsextended
 
 import (
 	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
 	"github.com/xjslang/xjs/parser"
 	"github.com/xjslang/xjs/printer"
 	"github.com/xjslang/xjs/token"
@@ -41,6 +42,23 @@ func ParseSequenceExpr(p *parser.Parser) (node *SequenceExpr, err error) {
 	return
 }
 
+// parseGroupOrSequenceExpr parses a parenthesized expression in a single pass,
+// since trying a group first and then a sequence takes exponential time on
+// nested parentheses.
+func parseGroupOrSequenceExpr(p *parser.Parser) (ast.Expr, error) {
+	seq, err := ParseSequenceExpr(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(seq.Values) != 1 {
+		return seq, nil
+	}
+	node := &js.GroupExpr{Value: seq.Values[0]}
+	node.Layout.Lparen = seq.Layout.Lparen
+	node.Layout.Rparen = seq.Layout.Rparen
+	return node, nil
+}
+
 func PrintSequenceExpr(pr *printer.Printer, node *SequenceExpr) error {
 	pr.Print(node.Layout.Lparen)
 	pr.IncreaseIndent()
@@ -23,7 +23,7 @@ func ParseSpreadExpr(p *parser.Parser) (node *SpreadExpr, err error) {
 	if node.Layout.Spread, err = p.Expect(SPREAD); err != nil {
 		return
 	}
-	if node.Value, err = js.ParseValue(p); err != nil {
+	if node.Value, err = js.ParseRightExpr(p, token.ASSIGN.Precedence()); err != nil {
 		return
 	}
 	return
@@ -2,12 +2,15 @@ package jsextended
 
 import (
 	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
 	"github.com/xjslang/xjs/parser"
 	"github.com/xjslang/xjs/printer"
 	"github.com/xjslang/xjs/token"
 )
 
-var QUESTION_MARK = token.RegisterType("?")
+// QUESTION_MARK is the ? emitted by the scanner, which this package makes a
+// binary operator for conditional expressions.
+var QUESTION_MARK = token.QUESTION
 
 type TernaryExpr struct {
 	ast.BaseExpr
@@ -18,21 +21,32 @@ type TernaryExpr struct {
 	Cond, Then, Else ast.Expr
 }
 
+// ParseTernaryExpr parses a conditional expression. A chain of them, as in
+// `a ? b : c ? d : e`, is parsed in a loop rather than recursively, so that
+// long chains don't count towards the nesting depth limit.
 func ParseTernaryExpr(p *parser.Parser, left ast.Expr) (node *TernaryExpr, err error) {
 	node = &TernaryExpr{Cond: left}
-	if node.Layout.QuestionMark, err = p.Expect(QUESTION_MARK); err != nil {
-		return
+	for expr := node; ; {
+		if expr.Layout.QuestionMark, err = p.Expect(QUESTION_MARK); err != nil {
+			return
+		}
+		if expr.Then, err = p.ParseExpr(); err != nil {
+			return
+		}
+		if expr.Layout.Colon, err = p.Expect(token.COLON); err != nil {
+			return
+		}
+		// the else branch up to the next ?, if any
+		if expr.Else, err = js.ParseRightExpr(p, p.Precedence(QUESTION_MARK)); err != nil {
+			return
+		}
+		if p.CurrentToken.Type != QUESTION_MARK || p.CurrentToken.AfterNewline {
+			return
+		}
+		next := &TernaryExpr{Cond: expr.Else}
+		expr.Else = next
+		expr = next
 	}
-	if node.Then, err = p.ParseExpr(); err != nil {
-		return
-	}
-	if node.Layout.Colon, err = p.Expect(token.COLON); err != nil {
-		return
-	}
-	if node.Else, err = p.ParseExpr(); err != nil {
-		return
-	}
-	return
 }
 
 func PrintTernaryExpr(pr *printer.Printer, node *TernaryExpr) error {
@@ -0,0 +1,115 @@
+package jsextended
+
+import (
+	"strings"
+
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+// FoldStrings prints the concatenation of adjacent string literals as a
+// single literal:
+//
+//	"Hello, " + "World" + "!"  ->  "Hello, World!"
+//
+// Only literals sharing the same quote are folded. Template literals and
+// operands of other types, such as numbers, are left untouched.
+func FoldStrings(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	if v, ok := node.(*js.BinaryExpr); ok {
+		if tok, ok := foldString(v); ok {
+			return next(&js.Literal{Value: tok})
+		}
+	}
+	return next(node)
+}
+
+func foldString(expr ast.Expr) (tok token.Token, ok bool) {
+	switch v := expr.(type) {
+	case *js.Literal:
+		quote := v.Value.Literal[0]
+		return v.Value, v.Value.Type == token.STRING && (quote == '"' || quote == '\'')
+	case *js.BinaryExpr:
+		if v.Op.Type != token.PLUS {
+			return
+		}
+		var right token.Token
+		if tok, ok = foldString(v.Left); !ok {
+			return
+		}
+		if right, ok = foldString(v.Right); !ok {
+			return
+		}
+		if tok.Literal[0] != right.Literal[0] {
+			return tok, false
+		}
+		left := tok.Literal[1 : len(tok.Literal)-1]
+		rest := right.Literal[1:]
+		if '0' <= rest[0] && rest[0] <= '7' && endsWithOctalEscape(left) {
+			// "\1" + "2" is not "\12"
+			return tok, false
+		}
+		tok.Literal = tok.Literal[:1] + left + rest
+		return tok, true
+	}
+	return
+}
+
+// endsWithOctalEscape reports whether s ends with an escape sequence such as
+// \0 or \12, which would absorb the digits following it.
+func endsWithOctalEscape(s string) bool {
+	digits := strings.TrimRight(s, "01234567")
+	if len(digits) == len(s) {
+		return false
+	}
+	slashes := len(digits) - len(strings.TrimRight(digits, `\`))
+	return slashes%2 == 1
+}
+
+// FoldNullish prints ?? expressions whose left operand is a literal as the
+// operand they evaluate to:
+//
+//	null ?? a  ->  a
+//	0 ?? a     ->  0
+//
+// Only null, undefined and literals that are never nullish, such as numbers,
+// strings and booleans, are folded. Any other operand, optional chains
+// included, may or may not be nullish at runtime, so the expression is left
+// untouched.
+func FoldNullish(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	if v, ok := node.(*js.BinaryExpr); ok && v.Op.Type == NULLISH && !hasComment(v.Op) {
+		if expr := foldNullish(v); expr != nil {
+			return next(expr)
+		}
+	}
+	return next(node)
+}
+
+// foldNullish returns the operand node evaluates to, or nil if it can't be
+// known before running it.
+func foldNullish(node *js.BinaryExpr) ast.Expr {
+	left := node.Left
+	for {
+		group, ok := left.(*js.GroupExpr)
+		if !ok {
+			break
+		}
+		left = group.Value
+	}
+	switch v := left.(type) {
+	case *js.Literal:
+		return node.Left
+	case *js.Variable:
+		switch v.Literal {
+		case "null", "undefined":
+			if hasComment(v.Token) {
+				return nil
+			}
+			return node.Right
+		case "true", "false":
+			return node.Left
+		}
+	}
+	return nil
+}
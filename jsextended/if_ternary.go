@@ -0,0 +1,117 @@
+package jsextended
+
+import (
+	"slices"
+
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+// IfToTernary prints if statements whose branches only assign the same
+// variable as a single assignment of a conditional expression:
+//
+//	if (c) a = 1; else a = 2;  ->  a = c ? 1 : 2;
+//
+// The rewrite is conservative: both branches must be a plain assignment
+// (optionally inside a block) to the same variable, and statements carrying
+// comments that would be lost are printed as they are.
+func IfToTernary(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	if v, ok := node.(*js.IfStmt); ok {
+		if stmt := ifToTernary(v); stmt != nil {
+			return next(stmt)
+		}
+	}
+	return next(node)
+}
+
+func ifToTernary(node *js.IfStmt) *js.ExprStmt {
+	then, thenTokens := branchAssign(node.Then)
+	var els *js.ExprStmt
+	var elseTokens []token.Token
+	if v, ok := node.Else.(*js.IfStmt); ok {
+		// else-if chains become nested conditional expressions
+		if els = ifToTernary(v); els != nil {
+			elseTokens = []token.Token{els.Expr.(*js.AssignExpr).Left.(*js.Variable).Token}
+		}
+	} else {
+		els, elseTokens = branchAssign(node.Else)
+	}
+	if then == nil || els == nil {
+		return nil
+	}
+	left, ok := then.Expr.(*js.AssignExpr).Left.(*js.Variable)
+	if !ok {
+		return nil
+	}
+	right, ok := els.Expr.(*js.AssignExpr).Left.(*js.Variable)
+	if !ok || left.Literal != right.Literal {
+		return nil
+	}
+	dropped := append(thenTokens, elseTokens...)
+	dropped = append(dropped, node.Layout.Lparen, node.Layout.Rparen, node.Layout.Else, left.Token, right.Token)
+	if slices.ContainsFunc(dropped, hasComment) {
+		return nil
+	}
+
+	target := *left
+	target.LeadingTrivia = node.Layout.If.LeadingTrivia
+	assign := *then.Expr.(*js.AssignExpr)
+	assign.Left = &target
+	ternary := &TernaryExpr{
+		Cond: groupLoose(node.Cond),
+		Then: groupLoose(assign.Right),
+	}
+	if v, ok := els.Expr.(*js.AssignExpr).Right.(*TernaryExpr); ok {
+		ternary.Else = v
+	} else {
+		ternary.Else = groupLoose(els.Expr.(*js.AssignExpr).Right)
+	}
+	ternary.Layout.QuestionMark = token.Token{Type: QUESTION_MARK, Literal: "?"}
+	ternary.Layout.Colon = token.Token{Type: token.COLON, Literal: ":"}
+	assign.Right = ternary
+	stmt := &js.ExprStmt{Expr: &assign}
+	stmt.Layout.Semi = els.Layout.Semi
+	return stmt
+}
+
+// branchAssign returns the plain assignment statement a branch consists of,
+// along with the tokens of the block around it, if any.
+func branchAssign(stmt ast.Stmt) (*js.ExprStmt, []token.Token) {
+	var tokens []token.Token
+	if block, ok := stmt.(*js.BlockStmt); ok {
+		if len(block.Stmts) != 1 {
+			return nil, nil
+		}
+		tokens = []token.Token{block.Layout.Lbrace, block.Layout.Rbrace}
+		stmt = block.Stmts[0]
+	}
+	v, ok := stmt.(*js.ExprStmt)
+	if !ok {
+		return nil, nil
+	}
+	assign, ok := v.Expr.(*js.AssignExpr)
+	if !ok || assign.Layout.Assign.Type != token.ASSIGN {
+		return nil, nil
+	}
+	return v, append(tokens, v.Layout.Semi, assign.Layout.Assign)
+}
+
+// groupLoose wraps the expressions that bind as loosely as an assignment in
+// parentheses, so they can be used as operands of a conditional expression.
+func groupLoose(expr ast.Expr) ast.Expr {
+	if v, ok := expr.(ast.Precedencer); !ok || v.Precedence() > token.ASSIGN.Precedence() {
+		return expr
+	}
+	group := &js.GroupExpr{Value: expr}
+	group.Layout.Lparen = token.Token{Type: token.LPAREN, Literal: "("}
+	group.Layout.Rparen = token.Token{Type: token.RPAREN, Literal: ")"}
+	return group
+}
+
+func hasComment(tok token.Token) bool {
+	return slices.ContainsFunc(tok.LeadingTrivia, func(tok token.Token) bool {
+		return tok.Type == token.LINE_COMMENT || tok.Type == token.BLOCK_COMMENT
+	})
+}
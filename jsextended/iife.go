@@ -0,0 +1,25 @@
+package jsextended
+
+import (
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+)
+
+// ArrowIIFE wraps the printed program in an immediately invoked arrow
+// function, so its declarations don't leak into the global scope:
+//
+//	let a = 1;  ->  (() => {
+//	                  let a = 1;
+//	                })();
+func ArrowIIFE(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	if _, ok := node.(*js.Program); !ok {
+		return next(node)
+	}
+	pr.Print("(() => {")
+	pr.IncreaseIndent()
+	err := next(node)
+	pr.DecreaseIndent()
+	pr.Line().Print("})();")
+	return err
+}
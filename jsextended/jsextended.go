@@ -21,57 +21,59 @@ func Plugin(b *plugin.Builder) {
 	token.RegisterUnaryType(TYPEOF)
 	token.RegisterUnaryType(ASYNC)
 	token.RegisterUnaryType(AWAIT)
+	token.RegisterUnaryType(js.IMPORT)
 	token.RegisterBinaryType(STRICT_EQ, token.EQ.Precedence())
 	token.RegisterBinaryType(STRICT_NOT_EQ, token.EQ.Precedence())
 	token.RegisterBinaryType(OPTIONAL_CHAINING, token.DOT.Precedence())
 	token.RegisterBinaryType(ARROW, token.ASSIGN.Precedence()+1)
 	token.RegisterBinaryType(QUESTION_MARK, -1)
+	token.RegisterBinaryType(NULLISH, token.OR.Precedence())
+	token.RegisterBinaryType(NULLISH_ASSIGN, token.ASSIGN.Precedence())
+	token.RegisterBinaryType(OR_ASSIGN, token.ASSIGN.Precedence())
+	token.RegisterBinaryType(AND_ASSIGN, token.ASSIGN.Precedence())
+	token.RegisterAssignmentType(NULLISH_ASSIGN)
+	token.RegisterAssignmentType(OR_ASSIGN)
+	token.RegisterAssignmentType(AND_ASSIGN)
+	token.RegisterBinaryType(IN, token.LT.Precedence())
+	token.RegisterBinaryType(EXPONENT, js.UnaryPrecedence())
 
+	b.UseKeywords(map[string]token.Type{
+		"const":    CONST,
+		"var":      VAR,
+		"try":      TRY,
+		"catch":    CATCH,
+		"finally":  FINALLY,
+		"switch":   SWITCH,
+		"case":     CASE,
+		"default":  DEFAULT,
+		"throw":    THROW,
+		"new":      NEW,
+		"do":       DO,
+		"typeof":   TYPEOF,
+		"async":    ASYNC,
+		"await":    AWAIT,
+		"debugger": DEBUGGER,
+		"in":       IN,
+	})
 	b.UseScanner(func(sc *scanner.Scanner, next func() (token.Token, error)) (tok token.Token, err error) {
 		if tok, err = next(); err != nil {
 			return
 		}
 		switch tok.Type {
-		case token.IDENT:
-			switch tok.Literal {
-			case "const":
-				tok.Type = CONST
-			case "var":
-				tok.Type = VAR
-			case "try":
-				tok.Type = TRY
-			case "catch":
-				tok.Type = CATCH
-			case "finally":
-				tok.Type = FINALLY
-			case "switch":
-				tok.Type = SWITCH
-			case "case":
-				tok.Type = CASE
-			case "default":
-				tok.Type = DEFAULT
-			case "throw":
-				tok.Type = THROW
-			case "new":
-				tok.Type = NEW
-			case "do":
-				tok.Type = DO
-			case "typeof":
-				tok.Type = TYPEOF
-			case "async":
-				tok.Type = ASYNC
-			case "await":
-				tok.Type = AWAIT
-			}
-		case token.UNKNOWN:
-			switch tok.Literal {
-			case "?":
-				if sc.CurrentChar() == '.' {
+		case token.QUESTION:
+			// "a?.5:1" is a conditional expression
+			if sc.CurrentChar() == '.' && !scanner.IsDigit(sc.PeekChar()) {
+				sc.AdvanceChar()
+				tok.Type = OPTIONAL_CHAINING
+				tok.Literal = "?."
+			} else if sc.CurrentChar() == '?' {
+				sc.AdvanceChar()
+				tok.Type = NULLISH
+				tok.Literal = "??"
+				if sc.CurrentChar() == '=' {
 					sc.AdvanceChar()
-					tok.Type = OPTIONAL_CHAINING
-					tok.Literal = "?."
-				} else {
-					tok.Type = QUESTION_MARK
+					tok.Type = NULLISH_ASSIGN
+					tok.Literal = "??="
 				}
 			}
 		case token.EQ:
@@ -86,6 +88,12 @@ func Plugin(b *plugin.Builder) {
 				tok.Type = STRICT_NOT_EQ
 				tok.Literal = "!=="
 			}
+		case token.MULTIPLY:
+			if sc.CurrentChar() == '*' {
+				sc.AdvanceChar()
+				tok.Type = EXPONENT
+				tok.Literal = "**"
+			}
 		case token.DOT:
 			if sc.CurrentChar() == '.' && sc.PeekChar() == '.' {
 				sc.AdvanceChar()
@@ -93,11 +101,17 @@ func Plugin(b *plugin.Builder) {
 				tok.Type = SPREAD
 				tok.Literal = "..."
 			}
-		case token.ASSIGN:
-			if sc.CurrentChar() == '>' {
+		case token.OR:
+			if sc.CurrentChar() == '=' {
+				sc.AdvanceChar()
+				tok.Type = OR_ASSIGN
+				tok.Literal = "||="
+			}
+		case token.AND:
+			if sc.CurrentChar() == '=' {
 				sc.AdvanceChar()
-				tok.Type = ARROW
-				tok.Literal = "=>"
+				tok.Type = AND_ASSIGN
+				tok.Literal = "&&="
 			}
 		}
 		return
@@ -105,17 +119,24 @@ func Plugin(b *plugin.Builder) {
 	b.UseUnaryParser(func(p *parser.Parser, next func() (ast.Expr, error)) (ast.Expr, error) {
 		switch p.CurrentToken.Type {
 		case token.LBRACE:
-			return ParseObjExpr(p)
+			return allowIn(p, func() (ast.Expr, error) {
+				return ParseObjExpr(p)
+			})
 		case token.LBRACKET:
-			return ParseArrayExpr(p)
+			return allowIn(p, func() (ast.Expr, error) {
+				return ParseArrayExpr(p)
+			})
 		case token.LPAREN:
-			return parser.Switch(p, func(p *parser.Parser) (ast.Expr, error) {
-				return js.ParseGroupExpr(p)
-			}, func(p *parser.Parser) (ast.Expr, error) {
-				return ParseSequenceExpr(p)
+			return allowIn(p, func() (ast.Expr, error) {
+				return parseGroupOrSequenceExpr(p)
 			})
 		case NEW:
+			if p.PeekToken.Type == token.DOT {
+				return ParseMetaPropertyExpr(p)
+			}
 			return ParseNewExpr(p)
+		case js.IMPORT:
+			return ParseMetaPropertyExpr(p)
 		case SPREAD:
 			return ParseSpreadExpr(p)
 		case TYPEOF:
@@ -123,6 +144,9 @@ func Plugin(b *plugin.Builder) {
 		case ASYNC:
 			return ParseAsyncExpr(p)
 		case AWAIT:
+			if awaitAsIdent(p, false) {
+				return js.ParseValue(p)
+			}
 			return ParseAwaitExpr(p)
 		}
 		return next()
@@ -137,14 +161,54 @@ func Plugin(b *plugin.Builder) {
 			return ParseTernaryExpr(p, left)
 		case OPTIONAL_CHAINING:
 			return ParseOptionalChainingExpr(p, left)
+		case NULLISH:
+			return ParseNullishExpr(p, left)
+		case NULLISH_ASSIGN, OR_ASSIGN, AND_ASSIGN:
+			return ParseLogicalAssignExpr(p, left)
+		case IN:
+			return ParseInExpr(p, left)
+		case EXPONENT:
+			return ParseExponentExpr(p, left)
+		case token.LPAREN, token.LBRACKET:
+			// arguments and indexes
+			return allowIn(p, func() (ast.Expr, error) {
+				return next(left)
+			})
+		case token.ASSIGN:
+			node, err := next(left)
+			if v, ok := node.(*js.AssignExpr); ok && err == nil {
+				err = checkAssignTarget(p, v)
+			}
+			return node, err
+		case token.OR, token.AND:
+			node, err := next(left)
+			if v, ok := node.(*js.BinaryExpr); ok && err == nil {
+				err = checkNullishMixing(p, v)
+			}
+			return node, err
 		}
 		return next(left)
 	})
+	b.UsePatternParser(func(p *parser.Parser, next func() (ast.Node, error)) (ast.Node, error) {
+		awaitAsIdent(p, true)
+		switch p.CurrentToken.Type {
+		case token.LBRACE, token.LBRACKET:
+			return ParsePattern(p)
+		}
+		return next()
+	})
 	b.UseStmtParser(func(p *parser.Parser, next func() (ast.Stmt, error)) (ast.Stmt, error) {
 		switch p.CurrentToken.Type {
 		case js.LET, CONST, VAR:
 			return ParseVarStmt(p)
+		case js.IMPORT:
+			if p.PeekToken.Type == token.DOT {
+				return js.ParseStmt(p)
+			}
 		case js.FOR:
+			if p.PeekToken.Type == AWAIT {
+				return ParseForofStmt(p)
+			}
 			return parser.Switch(p, func(p *parser.Parser) (ast.Stmt, error) {
 				return ParseForofStmt(p)
 			}, func(p *parser.Parser) (ast.Stmt, error) {
@@ -158,6 +222,8 @@ func Plugin(b *plugin.Builder) {
 			return ParseThrowStmt(p)
 		case DO:
 			return ParseDoWhileStmt(p)
+		case DEBUGGER:
+			return ParseDebuggerStmt(p)
 		}
 		return next()
 	})
@@ -181,6 +247,8 @@ func Printer(pr *printer.Printer, node ast.Node, next func(node ast.Node) error)
 		return PrintNewExpr(pr, v)
 	case *DoWhileStmt:
 		return PrintDoWhileStmt(pr, v)
+	case *DebuggerStmt:
+		return PrintDebuggerStmt(pr, v)
 	case *ArrowFuncExpr:
 		return PrintArrowFunc(pr, v)
 	case *SpreadExpr:
@@ -199,6 +267,8 @@ func Printer(pr *printer.Printer, node ast.Node, next func(node ast.Node) error)
 		return PrintAsyncExpr(pr, v)
 	case *AwaitExpr:
 		return PrintAwaitExpr(pr, v)
+	case *MetaPropertyExpr:
+		return PrintMetaPropertyExpr(pr, v)
 	}
 	return next(node)
 }
@@ -0,0 +1,128 @@
+package jsextended
+
+import (
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/token"
+)
+
+// InfiniteLoops returns the keyword of each loop whose condition is always
+// true and whose body has no break, return or throw to leave it, such as
+// `while (true) {}` or `for (;;) {}`, including the loops of nested
+// functions and arrow functions.
+func InfiniteLoops(program *js.Program) (loops []token.Token) {
+	ast.Walk(program, func(node ast.Node) bool {
+		switch v := node.(type) {
+		case *js.WhileStmt:
+			if isAlwaysTrue(v.Cond) && !leavesLoop(v.Then, false) {
+				loops = append(loops, v.Layout.While)
+			}
+		case *js.ForStmt:
+			if (v.Cond == nil || isAlwaysTrue(v.Cond)) && !leavesLoop(v.Then, false) {
+				loops = append(loops, v.Layout.For)
+			}
+		case *DoWhileStmt:
+			if isAlwaysTrue(v.Cond) && !leavesLoop(v.Stmt, false) {
+				loops = append(loops, v.Layout.Do)
+			}
+		}
+		return true
+	})
+	return
+}
+
+// IsGuardClause reports whether stmt is an early exit, such as
+// `if (!valid) return;`: an if statement without else whose body is a
+// return, throw, break or continue, written alone or as the only statement
+// of a block.
+func IsGuardClause(stmt *js.IfStmt) bool {
+	if stmt.Else != nil {
+		return false
+	}
+	then := stmt.Then
+	if block, ok := then.(*js.BlockStmt); ok {
+		if len(block.Stmts) != 1 {
+			return false
+		}
+		then = block.Stmts[0]
+	}
+	switch then.(type) {
+	case *js.ReturnStmt, *ThrowStmt, *js.BreakStmt, *js.ContinueStmt:
+		return true
+	}
+	return false
+}
+
+func isAlwaysTrue(cond ast.Expr) bool {
+	switch v := cond.(type) {
+	case *js.Variable:
+		return v.Token.Literal == "true"
+	case *js.GroupExpr:
+		return isAlwaysTrue(v.Value)
+	}
+	return false
+}
+
+// leavesLoop reports whether stmt contains a statement that leaves the
+// enclosing loop. Unlabeled breaks of nested loops and switches don't count.
+func leavesLoop(stmt ast.Stmt, nested bool) bool {
+	switch v := stmt.(type) {
+	case *js.BreakStmt:
+		return v.Label != nil || !nested
+	case *js.ReturnStmt, *ThrowStmt:
+		return true
+	case *js.FunctionDecl:
+		return false
+	case *js.WhileStmt, *js.ForStmt, *DoWhileStmt, *ForofStmt, *SwitchStmt:
+		nested = true
+	}
+	for _, child := range childStmts(stmt) {
+		if leavesLoop(child, nested) {
+			return true
+		}
+	}
+	return false
+}
+
+func childStmts(stmt ast.Stmt) []ast.Stmt {
+	switch v := stmt.(type) {
+	case *js.BlockStmt:
+		return v.Stmts
+	case *js.IfStmt:
+		if v.Else != nil {
+			return []ast.Stmt{v.Then, v.Else}
+		}
+		return []ast.Stmt{v.Then}
+	case *js.LabelStmt:
+		return []ast.Stmt{v.Stmt}
+	case *js.WhileStmt:
+		return []ast.Stmt{v.Then}
+	case *js.ForStmt:
+		return []ast.Stmt{v.Then}
+	case *DoWhileStmt:
+		return []ast.Stmt{v.Stmt}
+	case *ForofStmt:
+		return []ast.Stmt{v.Then}
+	case *js.FunctionDecl:
+		return []ast.Stmt{v.Body}
+	case *js.ExportStmt:
+		if v.Decl != nil {
+			return []ast.Stmt{v.Decl}
+		}
+	case *SwitchStmt:
+		return v.Clauses
+	case *SwitchCaseStmt:
+		return v.Stmts
+	case *SwitchDefaultStmt:
+		return v.Stmts
+	case *TryStmt:
+		var stmts []ast.Stmt
+		for _, block := range []*js.BlockStmt{v.Try, v.Catch, v.Finally} {
+			if block != nil {
+				stmts = append(stmts, block)
+			}
+		}
+		return stmts
+	}
+	return nil
+}
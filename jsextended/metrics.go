@@ -0,0 +1,9 @@
+package jsextended
+
+func (*ForofStmt) Branches() int      { return 1 }
+func (*DoWhileStmt) Branches() int    { return 1 }
+func (*SwitchCaseStmt) Branches() int { return 1 }
+func (*TernaryExpr) Branches() int    { return 1 }
+
+// FuncName returns "", as arrow functions have no name.
+func (*ArrowFuncExpr) FuncName() string { return "" }
@@ -0,0 +1,200 @@
+package jsextended
+
+import (
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+// DropRedundantParens prints the parentheses written in the source only
+// where they change the meaning of the expression, as minifiers do:
+//
+//	x = (a * b) + (c);  ->  x = a * b + c;
+//
+// Without it, every pair written in the source is printed. Parentheses with
+// comments around them are kept, and so are those after return or throw,
+// which keep the value on the same line. So are those that would otherwise
+// leave a function or an object literal at the start of a statement, where
+// it would be read as a declaration or a block, as in `(function () {}) + 1`.
+func DropRedundantParens(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	switch v := node.(type) {
+	case *js.ExprStmt:
+		if expr, _ := keepLeadingParens(v.Expr); expr != v.Expr {
+			stmt := *v
+			stmt.Expr = expr
+			return next(&stmt)
+		}
+	case *keptGroup:
+		return next(v.Group)
+	case *js.BinaryExpr:
+		expr := *v
+		prec := pr.Precedence(v.Op.Type)
+		if v.Op.Type == EXPONENT {
+			// ** is right-associative, and its left operand can't be a
+			// prefix operation, which binds like it
+			expr.Left = dropParens(pr, v.Left, prec+1, v.Op.Type)
+			expr.Right = dropParens(pr, v.Right, prec, v.Op.Type)
+			return next(&expr)
+		}
+		// the operators are left-associative
+		expr.Left = dropParens(pr, v.Left, prec, v.Op.Type)
+		expr.Right = dropParens(pr, v.Right, prec+1, v.Op.Type)
+		return next(&expr)
+	case *js.AssignExpr:
+		expr := *v
+		expr.Right = dropParens(pr, v.Right, pr.Precedence(token.ASSIGN), v.Layout.Assign.Type)
+		return next(&expr)
+	}
+	return next(node)
+}
+
+// dropParens removes the parentheses around expr as long as the expression
+// inside binds at least as tightly as prec, and so doesn't need them as an
+// operand of op. The precedences of binary operators are those of the
+// printer, see printer.WithPrecedence.
+func dropParens(pr *printer.Printer, expr ast.Expr, prec int, op token.Type) ast.Expr {
+	for {
+		group, ok := expr.(*js.GroupExpr)
+		if !ok || hasComment(group.Layout.Lparen) || hasComment(group.Layout.Rparen) {
+			return expr
+		}
+		inner, ok := group.Value.(ast.Precedencer)
+		if !ok || mixesNullish(group.Value, op) {
+			return expr
+		}
+		innerPrec := inner.Precedence()
+		if binary, ok := group.Value.(*js.BinaryExpr); ok {
+			innerPrec = pr.Precedence(binary.Op.Type)
+		}
+		if innerPrec < prec {
+			return expr
+		}
+		expr = group.Value
+	}
+}
+
+// mixesNullish reports whether expr can't be an operand of op without
+// parentheses because one of them is ?? and the other is || or &&.
+func mixesNullish(expr ast.Expr, op token.Type) bool {
+	v, ok := expr.(*js.BinaryExpr)
+	if !ok {
+		return false
+	}
+	isLogical := func(typ token.Type) bool {
+		return typ == token.OR || typ == token.AND
+	}
+	return op == NULLISH && isLogical(v.Op.Type) || isLogical(op) && v.Op.Type == NULLISH
+}
+
+// keptGroup is a parenthesized expression whose parentheses are kept.
+type keptGroup struct {
+	ast.BaseExpr
+	Group *js.GroupExpr
+}
+
+func (node *keptGroup) Precedence() int { return node.Group.Precedence() }
+
+// keepLeadingParens returns expr, which starts an expression statement, with
+// the innermost parentheses around its leftmost operand kept if that
+// operand can't start a statement. It also reports whether expr starts with
+// such an operand that isn't parenthesized.
+func keepLeadingParens(expr ast.Expr) (ast.Expr, bool) {
+	switch v := expr.(type) {
+	case *js.GroupExpr:
+		inner, ambiguous := keepLeadingParens(v.Value)
+		if ambiguous {
+			return &keptGroup{Group: v}, false
+		}
+		if inner != v.Value {
+			group := *v
+			group.Value = inner
+			return &group, false
+		}
+	case *js.FunctionExpr, *js.ObjExpr, *ObjExpr:
+		return expr, true
+	case *AsyncExpr:
+		_, ok := v.Expr.(*js.FunctionExpr)
+		return expr, ok
+	case *js.BinaryExpr:
+		left, ambiguous := keepLeadingParens(v.Left)
+		if left == v.Left {
+			return expr, ambiguous
+		}
+		node := *v
+		node.Left = left
+		return &node, ambiguous
+	case *js.AssignExpr:
+		left, ambiguous := keepLeadingParens(v.Left)
+		if left == v.Left {
+			return expr, ambiguous
+		}
+		node := *v
+		node.Left = left
+		return &node, ambiguous
+	case *TernaryExpr:
+		cond, ambiguous := keepLeadingParens(v.Cond)
+		if cond == v.Cond {
+			return expr, ambiguous
+		}
+		node := *v
+		node.Cond = cond
+		return &node, ambiguous
+	case *js.CallExpr:
+		if v.Callee == nil {
+			break
+		}
+		callee, ambiguous := keepLeadingParens(v.Callee)
+		if callee == v.Callee {
+			return expr, ambiguous
+		}
+		node := *v
+		node.Callee = callee
+		return &node, ambiguous
+	case *js.MemberExpr:
+		left, ambiguous := keepLeadingParens(v.Left)
+		if left == v.Left {
+			return expr, ambiguous
+		}
+		node := *v
+		node.Left = left
+		return &node, ambiguous
+	case *js.IndexExpr:
+		if variable, ok := v.Value.(*js.Variable); ok && variable.Literal == "let" {
+			// `let [` starts a declaration
+			return expr, true
+		}
+		value, ambiguous := keepLeadingParens(v.Value)
+		if value == v.Value {
+			return expr, ambiguous
+		}
+		node := *v
+		node.Value = value
+		return &node, ambiguous
+	case *OptionalChainingExpr:
+		left, ambiguous := keepLeadingParens(v.Left)
+		if left == v.Left {
+			return expr, ambiguous
+		}
+		node := *v
+		node.Left = left
+		return &node, ambiguous
+	case *js.IncExpr:
+		left, ambiguous := keepLeadingParens(v.Left)
+		if left == v.Left {
+			return expr, ambiguous
+		}
+		node := *v
+		node.Left = left
+		return &node, ambiguous
+	case *js.DecExpr:
+		left, ambiguous := keepLeadingParens(v.Left)
+		if left == v.Left {
+			return expr, ambiguous
+		}
+		node := *v
+		node.Left = left
+		return &node, ambiguous
+	}
+	return expr, false
+}
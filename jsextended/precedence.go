@@ -0,0 +1,16 @@
+package jsextended
+
+import (
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/token"
+)
+
+func (*ObjExpr) Precedence() int              { return js.PrimaryPrecedence() }
+func (*NewExpr) Precedence() int              { return js.UnaryPrecedence() }
+func (*TypeofExpr) Precedence() int           { return js.UnaryPrecedence() }
+func (*AwaitExpr) Precedence() int            { return js.UnaryPrecedence() }
+func (*OptionalChainingExpr) Precedence() int { return OPTIONAL_CHAINING.Precedence() }
+func (*TernaryExpr) Precedence() int          { return token.ASSIGN.Precedence() }
+func (*ArrowFuncExpr) Precedence() int        { return token.ASSIGN.Precedence() }
+func (*MetaPropertyExpr) Precedence() int     { return js.PrimaryPrecedence() }
+func (*SequenceExpr) Precedence() int         { return js.PrimaryPrecedence() }
@@ -0,0 +1,54 @@
+package jsextended
+
+import (
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/scanner"
+	"github.com/xjslang/xjs/token"
+)
+
+// QuotePropsAsNeeded prints the string keys of object literals unquoted when
+// they are spelled like identifiers, as formatters do. The other keys keep
+// their quotes:
+//
+//	{"name": a, "data-id": b}  ->  {name: a, "data-id": b}
+//
+// Keywords are unquoted too, since they are valid property names.
+func QuotePropsAsNeeded(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	switch v := node.(type) {
+	case *js.ObjExpr:
+		expr := *v
+		expr.Entries = make([]js.ObjEntry, len(v.Entries))
+		for i, entry := range v.Entries {
+			entry.Key = unquotedKey(entry.Key)
+			expr.Entries[i] = entry
+		}
+		return next(&expr)
+	case *ObjExpr:
+		expr := *v
+		expr.Entries = make([]ObjEntry, len(v.Entries))
+		for i, entry := range v.Entries {
+			entry.Key = unquotedKey(entry.Key)
+			expr.Entries[i] = entry
+		}
+		return next(&expr)
+	}
+	return next(node)
+}
+
+// unquotedKey returns key as an identifier if it is a string that can be
+// written without quotes.
+func unquotedKey(key ast.Node) ast.Node {
+	lit, ok := key.(*js.Literal)
+	if !ok || lit.Value.Type != token.STRING || lit.Value.Literal[0] == '`' {
+		return key
+	}
+	value, err := lit.StringValue()
+	if err != nil || !scanner.IsIdentifier(value) {
+		return key
+	}
+	tok := lit.Value
+	tok.Type, tok.Literal = token.IDENT, value
+	return &js.Ident{Token: tok}
+}
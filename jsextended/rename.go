@@ -0,0 +1,138 @@
+package jsextended
+
+import (
+	"slices"
+
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+// RenameIdentifiers returns a printer that prints the variables, and the
+// names that declare them, such as functions, parameters or imports, as
+// returned by rename:
+//
+//	let a = b;  ->  let ns_a = ns_b;
+//
+// The rename is naive: it doesn't know about scopes, so rename should only
+// depend on the name. Property names and labels are kept, and shorthand
+// properties, imports and exports are expanded or aliased so that the
+// exported and imported names don't change. The names declared by exported
+// declarations, as in `export let a = 1`, are renamed though.
+func RenameIdentifiers(rename func(name string) string) func(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	renameIdent := func(ident *js.Ident) *js.Ident {
+		if ident == nil {
+			return nil
+		}
+		v := *ident
+		v.Literal = rename(v.Literal)
+		return &v
+	}
+	renamePattern := func(pattern ast.Node) ast.Node {
+		// destructuring patterns are renamed as the expressions they are
+		if ident, ok := pattern.(*js.Ident); ok {
+			return renameIdent(ident)
+		}
+		return pattern
+	}
+	return func(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+		switch v := node.(type) {
+		case *js.Variable:
+			expr := *v
+			expr.Literal = rename(v.Literal)
+			return next(&expr)
+		case *js.LetStmt:
+			stmt := *v
+			stmt.Name = renameIdent(v.Name)
+			stmt.More = slices.Clone(v.More)
+			for i, decl := range stmt.More {
+				d := *decl
+				d.Name = renameIdent(decl.Name)
+				stmt.More[i] = &d
+			}
+			return next(&stmt)
+		case *VarStmt:
+			stmt := *v
+			stmt.Pattern = renamePattern(v.Pattern)
+			stmt.More = slices.Clone(v.More)
+			for i, decl := range stmt.More {
+				d := *decl
+				d.Pattern = renamePattern(decl.Pattern)
+				stmt.More[i] = &d
+			}
+			return next(&stmt)
+		case *js.FunctionDecl:
+			decl := *v
+			decl.Name = renameIdent(v.Name)
+			decl.Params = slices.Clone(v.Params)
+			for i, param := range decl.Params {
+				decl.Params[i] = renamePattern(param)
+			}
+			return next(&decl)
+		case *js.FunctionExpr:
+			expr := *v
+			expr.Name = renameIdent(v.Name)
+			expr.Params = slices.Clone(v.Params)
+			for i, param := range expr.Params {
+				expr.Params[i] = renamePattern(param)
+			}
+			return next(&expr)
+		case *ForofStmt:
+			stmt := *v
+			stmt.Pattern = renamePattern(v.Pattern)
+			return next(&stmt)
+		case *TryStmt:
+			stmt := *v
+			stmt.CatchParam = renamePattern(v.CatchParam)
+			return next(&stmt)
+		case *ObjExpr:
+			expr := *v
+			expr.Entries = slices.Clone(v.Entries)
+			for i, entry := range expr.Entries {
+				// {a} -> {a: ns_a}
+				if key, ok := entry.Key.(*js.Ident); ok && entry.Value == nil && rename(key.Literal) != key.Literal {
+					value := &js.Variable{Token: key.Token}
+					value.LeadingTrivia = nil
+					expr.Entries[i].Value = value
+				}
+			}
+			return next(&expr)
+		case *js.ImportStmt:
+			stmt := *v
+			stmt.Namespace = renameIdent(v.Namespace)
+			stmt.Default = renameIdent(v.Default)
+			stmt.Imports = slices.Clone(v.Imports)
+			for i, imp := range stmt.Imports {
+				n := *imp
+				// the alias, or the name itself, is the local name
+				if n.Alias != nil {
+					n.Alias = renameIdent(imp.Alias)
+				} else if name := rename(imp.Name.Literal); name != imp.Name.Literal {
+					n.Layout.As = token.Token{Type: token.IDENT, Literal: "as"}
+					n.Alias = &js.Ident{Token: token.Token{Type: token.IDENT, Literal: name}}
+				}
+				stmt.Imports[i] = &n
+			}
+			return next(&stmt)
+		case *js.ExportStmt:
+			if v.Path.Type != token.EOF {
+				// re-exports don't refer to local names
+				return next(node)
+			}
+			stmt := *v
+			stmt.Exports = slices.Clone(v.Exports)
+			for i, exp := range stmt.Exports {
+				n := *exp
+				n.Name = renameIdent(exp.Name)
+				if n.Alias == nil && n.Name.Literal != exp.Name.Literal {
+					n.Layout.As = token.Token{Type: token.IDENT, Literal: "as"}
+					n.Alias = &js.Ident{Token: token.Token{Type: token.IDENT, Literal: exp.Name.Literal}}
+				}
+				stmt.Exports[i] = &n
+			}
+			return next(&stmt)
+		}
+		return next(node)
+	}
+}
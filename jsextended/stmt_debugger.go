@@ -0,0 +1,129 @@
+package jsextended
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/parser"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+var DEBUGGER = token.RegisterType("debugger")
+
+type DebuggerStmt struct {
+	ast.BaseStmt
+	Layout struct {
+		Debugger token.Token
+		Semi     token.Token
+	}
+}
+
+func ParseDebuggerStmt(p *parser.Parser) (node *DebuggerStmt, err error) {
+	node = &DebuggerStmt{}
+	if node.Layout.Debugger, err = p.Expect(DEBUGGER); err != nil {
+		return
+	}
+	if node.Layout.Semi, err = js.ExpectSemi(p); err != nil {
+		return
+	}
+	return
+}
+
+func PrintDebuggerStmt(pr *printer.Printer, node *DebuggerStmt) error {
+	pr.Line().Print(node.Layout.Debugger)
+	pr.PrintSemi(node.Layout.Semi)
+	return nil
+}
+
+// DropDebugger is a printer that omits the debugger statements, for
+// production builds. Their comments are kept:
+//
+//	f(); // pause
+//	debugger;  ->  f(); // pause
+//
+// A debugger statement that is the body of another statement, as in
+// `if (a) debugger;`, is printed as an empty block.
+func DropDebugger(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	switch v := node.(type) {
+	case *js.Program:
+		program := *v
+		program.Stmts = dropDebuggerStmts(v.Stmts)
+		return next(&program)
+	case *js.BlockStmt:
+		block := *v
+		block.Stmts = dropDebuggerStmts(v.Stmts)
+		return next(&block)
+	case *SwitchStmt:
+		stmt := *v
+		stmt.Clauses = make([]ast.Stmt, len(v.Clauses))
+		for i, clause := range v.Clauses {
+			switch c := clause.(type) {
+			case *SwitchCaseStmt:
+				clause := *c
+				clause.Stmts = dropDebuggerStmts(c.Stmts)
+				stmt.Clauses[i] = &clause
+			case *SwitchDefaultStmt:
+				clause := *c
+				clause.Stmts = dropDebuggerStmts(c.Stmts)
+				stmt.Clauses[i] = &clause
+			default:
+				stmt.Clauses[i] = clause
+			}
+		}
+		return next(&stmt)
+	case *DebuggerStmt:
+		block := &js.BlockStmt{}
+		block.Layout.Lbrace = v.Layout.Debugger
+		block.Layout.Lbrace.Type, block.Layout.Lbrace.Literal = token.LBRACE, "{"
+		block.Layout.Rbrace = token.Token{Type: token.RBRACE, Literal: "}"}
+		return next(block)
+	case *droppedStmt:
+		// an empty token, as at the end of a program
+		pr.Line().Print(token.Token{Type: token.EOF, LeadingTrivia: v.Trivia})
+		return nil
+	}
+	return next(node)
+}
+
+// droppedStmt stands for a statement that isn't printed, but whose comments
+// are.
+type droppedStmt struct {
+	ast.BaseStmt
+	Trivia []token.Token
+}
+
+func dropDebuggerStmts(stmts []ast.Stmt) []ast.Stmt {
+	if !slices.ContainsFunc(stmts, isDebuggerStmt) {
+		return stmts
+	}
+	result := make([]ast.Stmt, 0, len(stmts))
+	for _, stmt := range stmts {
+		v, ok := stmt.(*DebuggerStmt)
+		if !ok {
+			result = append(result, stmt)
+			continue
+		}
+		trivia := slices.Concat(v.Layout.Debugger.LeadingTrivia, v.Layout.Semi.LeadingTrivia)
+		if !slices.ContainsFunc(trivia, isComment) {
+			continue
+		}
+		if last := &trivia[len(trivia)-1]; last.Type == token.LINE_COMMENT {
+			// the line break of the statement follows
+			last.Literal = strings.TrimRight(last.Literal, "\r\n")
+		}
+		result = append(result, &droppedStmt{Trivia: trivia})
+	}
+	return result
+}
+
+func isDebuggerStmt(stmt ast.Stmt) bool {
+	_, ok := stmt.(*DebuggerStmt)
+	return ok
+}
+
+func isComment(tok token.Token) bool {
+	return tok.Type == token.LINE_COMMENT || tok.Type == token.BLOCK_COMMENT
+}
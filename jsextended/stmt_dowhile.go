@@ -28,7 +28,10 @@ func ParseDoWhileStmt(p *parser.Parser) (node *DoWhileStmt, err error) {
 	if node.Layout.Do, err = p.Expect(DO); err != nil {
 		return
 	}
-	if node.Stmt, err = p.ParseStmt(); err != nil {
+	p.EnterScope(js.LoopScope)
+	node.Stmt, err = p.ParseStmt()
+	p.ExitScope(js.LoopScope)
+	if err != nil {
 		return
 	}
 	if node.Layout.While, err = p.Expect(js.WHILE); err != nil {
@@ -54,6 +57,6 @@ func PrintDoWhileStmt(pr *printer.Printer, node *DoWhileStmt) error {
 	pr.Space().Print(node.Stmt)
 	pr.Space().Print(node.Layout.While)
 	pr.Space().Print(node.Layout.Lparen, node.Cond, node.Layout.Rparen)
-	pr.Print(node.Layout.Semi)
+	pr.PrintSemi(node.Layout.Semi)
 	return nil
 }
@@ -8,13 +8,17 @@ import (
 	"github.com/xjslang/xjs/token"
 )
 
+// ForofStmt is a for-of loop, or a for-in loop if Layout.Of is the `in`
+// keyword. Without a declaration keyword, as in `for (a.b in obj)`, the
+// pattern is a left-hand side expression.
 type ForofStmt struct {
 	ast.BaseStmt
 	Layout struct {
 		For    token.Token
+		Await  token.Token // for await loops only
 		Lparen token.Token
-		Var    token.Token
-		Of     token.Token
+		Var    token.Token // let, const or var, if any
+		Of     token.Token // `of` or `in`
 		Rparen token.Token
 	}
 	Pattern ast.Node
@@ -22,32 +26,47 @@ type ForofStmt struct {
 	Then    ast.Stmt
 }
 
+// IsAwait reports whether the loop is a for await loop, which iterates over
+// an async iterable.
+func (node *ForofStmt) IsAwait() bool {
+	return node.Layout.Await.Type == AWAIT
+}
+
+// IsIn reports whether the loop is a for-in loop, which iterates over the
+// keys of an object.
+func (node *ForofStmt) IsIn() bool {
+	return node.Layout.Of.Type == IN
+}
+
 func ParseForofStmt(p *parser.Parser) (node *ForofStmt, err error) {
 	node = &ForofStmt{}
 	if node.Layout.For, err = p.Expect(js.FOR); err != nil {
 		return
 	}
-	if node.Layout.Lparen, err = p.Expect(token.LPAREN); err != nil {
-		return
+	if p.CurrentToken.Type == AWAIT {
+		if !inAsyncFunction(p) {
+			err = p.Error("for await is only allowed in async functions")
+			return
+		}
+		node.Layout.Await = p.CurrentToken
+		p.AdvanceToken()
 	}
-	if typ := p.CurrentToken.Type; typ != js.LET && typ != CONST && typ != VAR {
-		err = p.Error("syntax error")
+	if node.Layout.Lparen, err = p.Expect(token.LPAREN); err != nil {
 		return
 	}
-	node.Layout.Var = p.CurrentToken
-	p.AdvanceToken()
-	switch p.CurrentToken.Type {
-	case token.LBRACE:
-		node.Pattern, err = ParseObjExpr(p)
-	case token.LBRACKET:
-		node.Pattern, err = ParseArrayExpr(p)
-	default:
-		node.Pattern, err = js.ParseIdent(p)
-	}
-	if err != nil {
+	if typ := p.CurrentToken.Type; typ == js.LET || typ == CONST || typ == VAR {
+		node.Layout.Var = p.CurrentToken
+		p.AdvanceToken()
+		if node.Pattern, err = ParsePattern(p); err != nil {
+			return
+		}
+	} else if node.Pattern, err = parseForofTarget(p); err != nil {
 		return
 	}
-	if node.Layout.Of, err = p.ExpectString("of"); err != nil {
+	if p.CurrentToken.Type == IN && !node.IsAwait() {
+		node.Layout.Of = p.CurrentToken
+		p.AdvanceToken()
+	} else if node.Layout.Of, err = p.ExpectString("of"); err != nil {
 		return
 	}
 	if node.Value, err = p.ParseExpr(); err != nil {
@@ -56,18 +75,42 @@ func ParseForofStmt(p *parser.Parser) (node *ForofStmt, err error) {
 	if node.Layout.Rparen, err = p.Expect(token.RPAREN); err != nil {
 		return
 	}
-	if node.Then, err = p.ParseStmt(); err != nil {
+	p.EnterScope(js.LoopScope)
+	node.Then, err = p.ParseStmt()
+	p.ExitScope(js.LoopScope)
+	if err != nil {
 		return
 	}
 	return
 }
 
+// parseForofTarget parses the expression assigned by a for-in or for-of loop
+// without a declaration, up to the `in` or `of` keyword.
+func parseForofTarget(p *parser.Parser) (ast.Expr, error) {
+	tok := p.CurrentToken
+	expr, err := js.ParseRightExpr(p, p.Precedence(IN))
+	if err != nil {
+		return nil, err
+	}
+	switch expr.(type) {
+	case *js.Variable, *js.MemberExpr, *js.IndexExpr, *js.ArrayExpr, *ObjExpr:
+		return expr, nil
+	}
+	return nil, p.ErrorAt(tok, "invalid assignment target")
+}
+
 func PrintForofStmt(pr *printer.Printer, node *ForofStmt) error {
 	pr.Line().Print(node.Layout.For)
+	if node.IsAwait() {
+		pr.Space().Print(node.Layout.Await)
+	}
 	pr.Space().Print(node.Layout.Lparen)
 	pr.IncreaseIndent()
-	pr.Print(node.Layout.Var)
-	pr.Space().Print(node.Pattern)
+	if node.Layout.Var.Type != 0 {
+		pr.Print(node.Layout.Var)
+		pr.Space()
+	}
+	pr.Print(node.Pattern)
 	pr.Space().Print(node.Layout.Of)
 	pr.Space().Print(node.Value)
 	pr.DecreaseIndent()
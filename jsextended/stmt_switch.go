@@ -2,6 +2,7 @@ package jsextended
 
 import (
 	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
 	"github.com/xjslang/xjs/parser"
 	"github.com/xjslang/xjs/printer"
 	"github.com/xjslang/xjs/token"
@@ -26,6 +27,12 @@ type SwitchStmt struct {
 	Clauses []ast.Stmt
 }
 
+// SwitchCaseStmt is a case clause. Its statements may be empty, so that
+// several labels share the body of the next clause:
+//
+//	case 1:
+//	case 2:
+//		doThing();
 type SwitchCaseStmt struct {
 	ast.BaseStmt
 	Layout struct {
@@ -45,6 +52,18 @@ type SwitchDefaultStmt struct {
 	Stmts []ast.Stmt
 }
 
+// HasBody reports whether the clause has statements of its own, rather than
+// falling through to the next clause.
+func (s *SwitchCaseStmt) HasBody() bool {
+	return len(s.Stmts) > 0
+}
+
+// HasBody reports whether the clause has statements of its own, rather than
+// falling through to the next clause.
+func (s *SwitchDefaultStmt) HasBody() bool {
+	return len(s.Stmts) > 0
+}
+
 func ParseSwitchStmt(p *parser.Parser) (node *SwitchStmt, err error) {
 	node = &SwitchStmt{}
 	if node.Layout.Switch, err = p.Expect(SWITCH); err != nil {
@@ -62,6 +81,8 @@ func ParseSwitchStmt(p *parser.Parser) (node *SwitchStmt, err error) {
 	if node.Layout.Lbrace, err = p.Expect(token.LBRACE); err != nil {
 		return
 	}
+	p.EnterScope(js.SwitchScope)
+	defer p.ExitScope(js.SwitchScope)
 	defClauses := 0
 clausesLoop:
 	for {
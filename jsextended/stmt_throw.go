@@ -35,6 +35,7 @@ func ParseThrowStmt(p *parser.Parser) (node *ThrowStmt, err error) {
 
 func PrintThrowStmt(pr *printer.Printer, node *ThrowStmt) error {
 	pr.Line().Print(node.Layout.Throw)
-	pr.Space().Print(node.Expr, node.Layout.Semi)
+	pr.Space().Print(node.Expr)
+	pr.PrintSemi(node.Layout.Semi)
 	return nil
 }
@@ -25,7 +25,7 @@ type TryStmt struct {
 	}
 	Try        *js.BlockStmt
 	Catch      *js.BlockStmt
-	CatchParam *js.Ident
+	CatchParam ast.Node // identifier or destructuring pattern
 	Finally    *js.BlockStmt
 }
 
@@ -43,7 +43,10 @@ func ParseTryStmt(p *parser.Parser) (node *TryStmt, err error) {
 		if p.CurrentToken.Type == token.LPAREN {
 			node.Layout.Lparen = p.CurrentToken
 			p.AdvanceToken()
-			if node.CatchParam, err = js.ParseIdent(p); err != nil {
+			if node.CatchParam, err = ParsePattern(p); err != nil {
+				return
+			}
+			if err = p.SkipTypeAnnotation(); err != nil {
 				return
 			}
 			if node.Layout.Rparen, err = p.Expect(token.RPAREN); err != nil {
@@ -22,6 +22,30 @@ type VarStmt struct {
 	}
 	Pattern ast.Node
 	Value   ast.Expr
+	More    []*VarDecl // declarations after the first one, as in `var a, b = 1;`
+}
+
+// Kind returns the declaration keyword: let, const or var.
+func (node *VarStmt) Kind() token.Type {
+	return node.Layout.Var.Type
+}
+
+// SetKind changes the declaration keyword, which must be let, const or var,
+// keeping the comments around it.
+func (node *VarStmt) SetKind(kind token.Type) {
+	node.Layout.Var.Type = kind
+	node.Layout.Var.Literal = kind.Spelling()
+}
+
+// VarDecl is a declaration following a comma in a var, let or const
+// statement.
+type VarDecl struct {
+	Layout struct {
+		Comma  token.Token
+		Assign token.Token
+	}
+	Pattern ast.Node
+	Value   ast.Expr
 }
 
 func ParseVarStmt(p *parser.Parser) (node *VarStmt, err error) {
@@ -32,26 +56,17 @@ func ParseVarStmt(p *parser.Parser) (node *VarStmt, err error) {
 	}
 	node.Layout.Var = p.CurrentToken
 	p.AdvanceToken()
-	switch p.CurrentToken.Type {
-	case token.LBRACE:
-		if node.Pattern, err = ParseObjExpr(p); err != nil {
-			return
-		}
-	case token.LBRACKET:
-		if node.Pattern, err = ParseArrayExpr(p); err != nil {
-			return
-		}
-	default:
-		if node.Pattern, err = js.ParseIdent(p); err != nil {
-			return
-		}
+	if node.Pattern, node.Layout.Assign, node.Value, err = parseVarDecl(p); err != nil {
+		return
 	}
-	if p.CurrentToken.Type == token.ASSIGN {
-		node.Layout.Assign = p.CurrentToken
+	for p.CurrentToken.Type == token.COMMA {
+		decl := &VarDecl{}
+		decl.Layout.Comma = p.CurrentToken
 		p.AdvanceToken()
-		if node.Value, err = p.ParseExpr(); err != nil {
+		if decl.Pattern, decl.Layout.Assign, decl.Value, err = parseVarDecl(p); err != nil {
 			return
 		}
+		node.More = append(node.More, decl)
 	}
 	if node.Layout.Semi, err = js.ExpectSemi(p); err != nil {
 		return
@@ -59,13 +74,55 @@ func ParseVarStmt(p *parser.Parser) (node *VarStmt, err error) {
 	return
 }
 
+// ParsePattern parses a binding target: either an identifier or an object or
+// array destructuring pattern.
+func ParsePattern(p *parser.Parser) (ast.Node, error) {
+	awaitAsIdent(p, true)
+	switch p.CurrentToken.Type {
+	case token.LBRACE:
+		return ParseObjExpr(p)
+	case token.LBRACKET:
+		return ParseArrayExpr(p)
+	}
+	return js.ParseIdent(p)
+}
+
+// parseVarDecl parses a pattern and its initial value, which is optional
+// unless the parser requires initializers.
+func parseVarDecl(p *parser.Parser) (pattern ast.Node, assign token.Token, value ast.Expr, err error) {
+	if pattern, err = ParsePattern(p); err != nil {
+		return
+	}
+	if err = p.SkipTypeAnnotation(); err != nil {
+		return
+	}
+	if err = js.CheckDeclAssign(p); err != nil {
+		return
+	}
+	if p.CurrentToken.Type == token.ASSIGN || p.RequireInitializers() {
+		if assign, err = p.Expect(token.ASSIGN); err != nil {
+			return
+		}
+		value, err = p.ParseExpr()
+	}
+	return
+}
+
 func PrintVarStmt(pr *printer.Printer, node *VarStmt) error {
 	pr.Line().Print(node.Layout.Var)
-	pr.Space().Print(node.Pattern)
-	if node.Value != nil {
-		pr.Space().Print(node.Layout.Assign)
-		pr.Space().Print(node.Value)
+	printVarDecl(pr, node.Pattern, node.Layout.Assign, node.Value)
+	for _, decl := range node.More {
+		pr.Print(decl.Layout.Comma)
+		printVarDecl(pr, decl.Pattern, decl.Layout.Assign, decl.Value)
 	}
-	pr.Print(node.Layout.Semi)
+	pr.PrintSemi(node.Layout.Semi)
 	return nil
 }
+
+func printVarDecl(pr *printer.Printer, pattern ast.Node, assign token.Token, value ast.Expr) {
+	pr.Space().Print(pattern)
+	if value != nil {
+		pr.Space().Print(assign)
+		pr.Space().Print(value)
+	}
+}
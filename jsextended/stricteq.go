@@ -0,0 +1,34 @@
+package jsextended
+
+import (
+	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
+)
+
+// StrictEquality returns a printer that prints loose comparisons (== and !=)
+// as strict ones (=== and !==). The warn function, if not nil, receives the
+// operator of each rewritten comparison, so the source can be fixed later.
+func StrictEquality(warn func(op token.Token)) func(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+	return func(pr *printer.Printer, node ast.Node, next func(node ast.Node) error) error {
+		v, ok := node.(*js.BinaryExpr)
+		if !ok {
+			return next(node)
+		}
+		expr := *v
+		switch expr.Op.Type {
+		case token.EQ:
+			expr.Op.Type = STRICT_EQ
+		case token.NOT_EQ:
+			expr.Op.Type = STRICT_NOT_EQ
+		default:
+			return next(node)
+		}
+		if warn != nil {
+			warn(v.Op)
+		}
+		expr.Op.Literal = expr.Op.Type.String()
+		return next(&expr)
+	}
+}
@@ -1,21 +1,46 @@
 package parser
 
 import (
+	"fmt"
+	"maps"
+
 	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/internal/fingerprint"
 	"github.com/xjslang/xjs/token"
 )
 
 type Builder struct {
-	stmtParsers   []func(*Parser, func() (ast.Stmt, error)) (ast.Stmt, error)
-	exprParsers   []func(*Parser, func() (ast.Expr, error)) (ast.Expr, error)
-	unaryParsers  []func(*Parser, func() (ast.Expr, error)) (ast.Expr, error)
-	binaryParsers []func(*Parser, ast.Expr, func(ast.Expr) (ast.Expr, error)) (ast.Expr, error)
+	stmtParsers    []func(*Parser, func() (ast.Stmt, error)) (ast.Stmt, error)
+	exprParsers    []func(*Parser, func() (ast.Expr, error)) (ast.Expr, error)
+	unaryParsers   []func(*Parser, func() (ast.Expr, error)) (ast.Expr, error)
+	binaryParsers  []func(*Parser, ast.Expr, func(ast.Expr) (ast.Expr, error)) (ast.Expr, error)
+	patternParsers []func(*Parser, func() (ast.Node, error)) (ast.Node, error)
+	maxDepth       int
+	maxArgs        int
+	recoverPanics  bool
+	errorHandler   func(err Error)
+	reserved       map[string]bool
+	precedences    map[token.Type]int
+	ignoreTypes    bool
+	strictReturn   bool
+	smartSemis     bool
+	requireInits   bool
+	strictEquality bool
+	module         bool
+	comments       bool
+	trackComments  bool
+	prelude        []ast.Stmt
 }
 
 func NewBuilder() *Builder {
 	return &Builder{}
 }
 
+// New creates a parser with no middlewares that reads the tokens from sc.
+func New(sc token.Scanner) *Parser {
+	return NewBuilder().Build(sc)
+}
+
 func (b *Builder) UseStmtParser(parser func(p *Parser, next func() (ast.Stmt, error)) (ast.Stmt, error)) *Builder {
 	b.stmtParsers = append(b.stmtParsers, parser)
 	return b
@@ -36,8 +61,238 @@ func (b *Builder) UseBinaryParser(parser func(p *Parser, left ast.Expr, next fun
 	return b
 }
 
+// UsePatternParser adds a parser for binding patterns, the targets of
+// declarations such as function parameters. See Parser.ParsePattern.
+func (b *Builder) UsePatternParser(parser func(p *Parser, next func() (ast.Node, error)) (ast.Node, error)) *Builder {
+	b.patternParsers = append(b.patternParsers, parser)
+	return b
+}
+
+// WithMaxDepth limits how deeply statements and expressions can be nested.
+// A non-positive value means DefaultMaxDepth.
+func (b *Builder) WithMaxDepth(n int) *Builder {
+	b.maxDepth = n
+	return b
+}
+
+// WithMaxArgumentCount limits the number of elements of lists such as the
+// arguments of a call, the parameters of a function, or the elements of an
+// array or object literal, so that untrusted input can't make them grow
+// without bounds. A non-positive value means no limit.
+func (b *Builder) WithMaxArgumentCount(n int) *Builder {
+	b.maxArgs = n
+	return b
+}
+
+// WithPanicRecovery makes the parser recover from panics, such as those of
+// a buggy middleware, so that services parsing untrusted input don't crash.
+// Parsing stops at the panic, which is returned as a *PanicError holding the
+// recovered value and its stack trace.
+func (b *Builder) WithPanicRecovery() *Builder {
+	b.recoverPanics = true
+	return b
+}
+
+// WithErrorHandler sets a function that is called with each error as soon as
+// the statement containing it has been parsed, so that tools such as editors
+// can report errors while a large input is still being parsed. The errors are
+// returned at the end as usual.
+func (b *Builder) WithErrorHandler(handler func(err Error)) *Builder {
+	b.errorHandler = handler
+	return b
+}
+
+// WithReservedWords reserves words, such as "enum" or "yield", that can't be
+// used as identifiers even if no syntax uses them yet. See
+// Parser.IsReserved.
+func (b *Builder) WithReservedWords(words ...string) *Builder {
+	if b.reserved == nil {
+		b.reserved = make(map[string]bool)
+	}
+	for _, word := range words {
+		b.reserved[word] = true
+	}
+	return b
+}
+
+// WithPrecedence overrides the precedence of the binary operator typ for the
+// parsers built, so that dialects can change how operators bind without
+// affecting the others, which use the precedence registered globally with
+// token.RegisterBinaryType. For example, to make && bind looser than ||:
+//
+//	b.WithPrecedence(token.AND, token.OR.Precedence()-1)
+//
+// Binary operators are parsed according to Parser.Precedence. Printers
+// print groups as written, so they don't add the parentheses that other
+// dialects would need; those that drop parentheses should be told about the
+// override with printer.WithPrecedence.
+func (b *Builder) WithPrecedence(typ token.Type, precedence int) *Builder {
+	if b.precedences == nil {
+		b.precedences = make(map[token.Type]int)
+	}
+	b.precedences[typ] = precedence
+	return b
+}
+
+// WithIgnoreTypeAnnotations makes the parser skip TypeScript-style type
+// annotations, such as `let a: number = 1` or `x as string`, so they are
+// stripped from the output. See Parser.SkipTypeAnnotation and
+// Parser.SkipTypeAssertion.
+func (b *Builder) WithIgnoreTypeAnnotations() *Builder {
+	b.ignoreTypes = true
+	return b
+}
+
+// WithStrictReturn makes return statements outside of functions an error, as
+// in JavaScript scripts and modules. By default they are accepted, as in the
+// CommonJS modules run by Node, whose code is wrapped in a function. See
+// Parser.StrictReturn.
+func (b *Builder) WithStrictReturn() *Builder {
+	b.strictReturn = true
+	return b
+}
+
+// WithSmartSemicolons makes the parser insert the semicolons missing between
+// two statements on the same line, as in `let a = 1 let b = 2`, instead of
+// reporting an error. See Parser.SmartSemicolons.
+func (b *Builder) WithSmartSemicolons() *Builder {
+	b.smartSemis = true
+	return b
+}
+
+// WithRequireInitializers makes variable declarations without an initial
+// value an error, as in `let a = 1, b;`. The variables of for-in and for-of
+// loops are not concerned. See Parser.RequireInitializers.
+func (b *Builder) WithRequireInitializers() *Builder {
+	b.requireInits = true
+	return b
+}
+
+// WithStrictEquality makes the loose comparison operators, == and !=, an
+// error, for dialects that have the strict ones. See Parser.StrictEquality.
+func (b *Builder) WithStrictEquality() *Builder {
+	b.strictEquality = true
+	return b
+}
+
+// WithModule parses the source as an ES module rather than a script, which
+// allows the constructs only modules have, such as import.meta. See
+// Parser.Module.
+func (b *Builder) WithModule() *Builder {
+	b.module = true
+	return b
+}
+
+// Dialect is a preset of parser options, see Builder.WithDialect.
+type Dialect int
+
+const (
+	// DialectStandard sets no options: the parser is configured as by
+	// NewBuilder.
+	DialectStandard Dialect = iota
+	// DialectPermissive tolerates what it can: it inserts the missing
+	// semicolons and turns the panics of the parsers into errors. See
+	// WithSmartSemicolons and WithPanicRecovery.
+	DialectPermissive
+	// DialectStrict requires initial values in declarations and rejects the
+	// loose comparison operators. See WithRequireInitializers and
+	// WithStrictEquality.
+	DialectStrict
+)
+
+// WithDialect applies the options of a preset, in addition to those set
+// before. Options can still be added afterwards.
+func (b *Builder) WithDialect(dialect Dialect) *Builder {
+	switch dialect {
+	case DialectPermissive:
+		b.WithSmartSemicolons().WithPanicRecovery()
+	case DialectStrict:
+		b.WithRequireInitializers().WithStrictEquality()
+	}
+	return b
+}
+
+// WithComments makes the parser accept comment and newline tokens from
+// scanners that emit them as regular tokens, attaching them as leading trivia
+// to the next token, just as the built-in scanner does. The printer then
+// keeps them along with the node they precede.
+func (b *Builder) WithComments() *Builder {
+	b.comments = true
+	return b
+}
+
+// Fingerprint returns a hash of the configuration of the builder: the
+// middlewares it uses, its options and the registered token types, with
+// their precedences. It changes when the dialect does, so tools can use it
+// to invalidate cached results. Middlewares are identified by the name of
+// their functions, so changes to their code are not detected.
+func (b *Builder) Fingerprint() string {
+	h := fingerprint.New()
+	fingerprint.AddFuncs(h, "stmt", b.stmtParsers...)
+	fingerprint.AddFuncs(h, "expr", b.exprParsers...)
+	fingerprint.AddFuncs(h, "unary", b.unaryParsers...)
+	fingerprint.AddFuncs(h, "binary", b.binaryParsers...)
+	fingerprint.AddFuncs(h, "pattern", b.patternParsers...)
+	h.Add("maxDepth", b.maxDepth)
+	h.Add("maxArgs", b.maxArgs)
+	h.Add("recoverPanics", b.recoverPanics)
+	h.Add("reserved", b.reserved)
+	h.Add("precedences", b.precedences)
+	h.Add("ignoreTypes", b.ignoreTypes)
+	h.Add("strictReturn", b.strictReturn)
+	h.Add("smartSemis", b.smartSemis)
+	h.Add("requireInits", b.requireInits)
+	h.Add("strictEquality", b.strictEquality)
+	h.Add("module", b.module)
+	h.Add("comments", b.comments)
+	h.Add("trackComments", b.trackComments)
+	for _, stmt := range b.prelude {
+		if data, err := ast.ToJSON(stmt); err == nil {
+			h.Add("prelude", string(data))
+		} else {
+			// the dump includes addresses, so the fingerprint may change
+			// between runs, but it never matches a different prelude
+			h.Add("prelude", fmt.Sprintf("%#v", stmt))
+		}
+	}
+	h.AddTokenTypes()
+	return h.Sum()
+}
+
+// WithTrackComments makes the parser collect the comments it reads into a
+// list, see Parser.Comments, for tools that need the comments but not the
+// nodes they belong to. The comments are still attached to the tokens.
+func (b *Builder) WithTrackComments() *Builder {
+	b.trackComments = true
+	return b
+}
+
+// WithPrelude adds stmts to the top of the programs the parser reads, once,
+// so plugins can ship the runtime helpers their syntax relies on. The
+// statements are shared by all the programs and shouldn't be modified.
+func (b *Builder) WithPrelude(stmts ...ast.Stmt) *Builder {
+	b.prelude = append(b.prelude, stmts...)
+	return b
+}
+
 func (b *Builder) Build(sc token.Scanner) *Parser {
-	p := &Parser{}
+	p := &Parser{
+		maxDepth:       b.maxDepth,
+		maxArgs:        b.maxArgs,
+		recoverPanics:  b.recoverPanics,
+		errorHandler:   b.errorHandler,
+		reserved:       maps.Clone(b.reserved),
+		precedences:    maps.Clone(b.precedences),
+		ignoreTypes:    b.ignoreTypes,
+		strictReturn:   b.strictReturn,
+		smartSemis:     b.smartSemis,
+		requireInits:   b.requireInits,
+		strictEquality: b.strictEquality,
+		module:         b.module,
+		comments:       b.comments,
+		trackComments:  b.trackComments,
+		prelude:        b.prelude,
+	}
 	for _, stmt := range b.stmtParsers {
 		p.useStmtParser(stmt)
 	}
@@ -50,6 +305,46 @@ func (b *Builder) Build(sc token.Scanner) *Parser {
 	for _, binaryExpr := range b.binaryParsers {
 		p.useBinaryParser(binaryExpr)
 	}
+	for _, pattern := range b.patternParsers {
+		p.usePatternParser(pattern)
+	}
 	p.init(sc)
 	return p
 }
+
+// BuildFromTokens creates a parser that reads the given tokens instead of
+// scanning source code. An EOF token is implied after the last one.
+func (b *Builder) BuildFromTokens(tokens []token.Token) *Parser {
+	return b.Build(&tokenScanner{tokens: tokens})
+}
+
+type tokenScanner struct {
+	tokens []token.Token
+	offset int
+}
+
+func (sc *tokenScanner) NextToken() token.Token {
+	if sc.offset >= len(sc.tokens) {
+		tok := token.Token{Type: token.EOF}
+		if n := len(sc.tokens); n > 0 {
+			tok.Position = sc.tokens[n-1].Position
+		}
+		return tok
+	}
+	tok := sc.tokens[sc.offset]
+	sc.offset++
+	return tok
+}
+
+func (sc *tokenScanner) Fork() token.Scanner {
+	return &tokenScanner{tokens: sc.tokens, offset: sc.offset}
+}
+
+func (sc *tokenScanner) Apply(s token.Scanner) {
+	switch v := s.(type) {
+	case *tokenScanner:
+		sc.offset = v.offset
+	default:
+		panic("*tokenScanner expected")
+	}
+}
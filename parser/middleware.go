@@ -52,6 +52,18 @@ func (p *Parser) useExprParser(parser func(p *Parser, next func() (ast.Expr, err
 	}
 }
 
+func (p *Parser) usePatternParser(parser func(p *Parser, next func() (ast.Node, error)) (ast.Node, error)) {
+	next := p.patternParser
+	if next == nil {
+		next = defaultPatternParser
+	}
+	p.patternParser = func(p *Parser) (ast.Node, error) {
+		return parser(p, func() (ast.Node, error) {
+			return next(p)
+		})
+	}
+}
+
 func defaultUnaryParser(p *Parser) (ast.Expr, error) {
 	return nil, p.Error("unknown unary operator")
 }
@@ -67,3 +79,7 @@ func defaultStmtParser(p *Parser) (ast.Stmt, error) {
 func defaultExprParser(p *Parser) (ast.Expr, error) {
 	return nil, p.Error("unknown expression")
 }
+
+func defaultPatternParser(p *Parser) (ast.Node, error) {
+	return nil, p.Error("identifier expected")
+}
@@ -1,7 +1,11 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
 	"maps"
+	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode/utf8"
@@ -18,6 +22,18 @@ type Range struct {
 type Error struct {
 	Range   Range  `json:"range"`
 	Message string `json:"message"`
+	// Code identifies the kind of error, so tools can handle it without
+	// parsing the message. It's empty for most errors.
+	Code string `json:"code,omitempty"`
+	// Fix, if not nil, is an edit that fixes the error.
+	Fix *Fix `json:"fix,omitempty"`
+}
+
+// Fix is a suggested edit that replaces the text in Range with Text. An
+// empty range means an insertion.
+type Fix struct {
+	Range Range  `json:"range"`
+	Text  string `json:"text"`
 }
 
 func (err Error) Error() string {
@@ -27,6 +43,41 @@ func (err Error) Error() string {
 		"] " + err.Message
 }
 
+// PanicCode is the code of the errors reported in place of recovered panics,
+// see PanicError.
+const PanicCode = "panic"
+
+// PanicError is the error returned in place of a panic recovered while
+// parsing, see Builder.WithPanicRecovery.
+type PanicError struct {
+	// Position is the position of the token being parsed.
+	Position token.Position
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+func (err *PanicError) Error() string {
+	return err.parserError().Error()
+}
+
+// parserError returns the Error reported to error handlers.
+func (err *PanicError) parserError() Error {
+	return Error{
+		Range:   Range{Start: err.Position, End: err.Position},
+		Message: fmt.Sprintf("internal error: %v", err.Value),
+		Code:    PanicCode,
+	}
+}
+
+// Comment is a comment collected by a parser built with
+// Builder.WithTrackComments. Text includes the comment delimiters.
+type Comment struct {
+	Text  string `json:"text"`
+	Range Range  `json:"range"`
+}
+
 type ErrorList []error
 
 func (list ErrorList) Error() string {
@@ -46,16 +97,45 @@ func (list ErrorList) Error() string {
 type Parser struct {
 	CurrentToken     token.Token
 	PeekToken        token.Token
+	lookahead        []token.Token // tokens after PeekToken, see PeekTokenN
+	prevEnd          token.Position
 	scanner          token.Scanner
 	scopes           ScopeTracker
+	scopeStack       []scopeEntry
+	lastScopeID      int
+	depth, maxDepth  int
+	maxArgs          int
+	recoverPanics    bool
+	aborted          bool // after a recovered panic
+	errorHandler     func(err Error)
+	reserved         map[string]bool
+	precedences      map[token.Type]int
+	ignoreTypes      bool
+	strictReturn     bool
+	smartSemis       bool
+	requireInits     bool
+	strictEquality   bool
+	module           bool
+	comments         bool
+	trackComments    bool
+	trackedComments  []Comment
+	prelude          []ast.Stmt
 	stmtParser       func(p *Parser) (ast.Stmt, error)
 	exprParser       func(p *Parser) (ast.Expr, error)
 	binaryExprParser func(p *Parser, left ast.Expr) (ast.Expr, error)
 	unaryExprParser  func(p *Parser) (ast.Expr, error)
+	patternParser    func(p *Parser) (ast.Node, error)
 }
 
 func (p *Parser) init(sc token.Scanner) {
 	p.scopes = make(ScopeTracker)
+	p.scopeStack = nil
+	p.lastScopeID = 0
+	p.depth = 0
+	p.aborted = false
+	if p.maxDepth <= 0 {
+		p.maxDepth = DefaultMaxDepth
+	}
 	p.scanner = sc
 	if p.stmtParser == nil {
 		p.stmtParser = defaultStmtParser
@@ -69,24 +149,84 @@ func (p *Parser) init(sc token.Scanner) {
 	if p.unaryExprParser == nil {
 		p.unaryExprParser = defaultUnaryParser
 	}
+	if p.patternParser == nil {
+		p.patternParser = defaultPatternParser
+	}
 	p.CurrentToken = token.Token{}
 	p.PeekToken = token.Token{}
+	p.lookahead = nil
+	p.trackedComments = nil
+	p.prevEnd = token.Position{}
 	// call twice to update CurrentToken and PeekToken
 	p.AdvanceToken()
 	p.AdvanceToken()
 }
 
+// ErrNoReset is returned by Reset when the scanner of the parser can't be
+// reset.
+var ErrNoReset = errors.New("the scanner of the parser can't be reset")
+
+// inputResetter is implemented by the scanners that can start over with a
+// new input, such as *scanner.Scanner.
+type inputResetter interface {
+	ResetInput(input []byte)
+}
+
+// CanReset reports whether Reset can be used, which requires the scanner to
+// provide a ResetInput method, as *scanner.Scanner does. Parsers reading a
+// list of tokens, see Builder.BuildFromTokens, can't be reset.
+func (p *Parser) CanReset() bool {
+	_, ok := p.scanner.(inputResetter)
+	return ok
+}
+
+// Reset starts parsing a new input, keeping the installed parsers, so a
+// single parser can be reused across many inputs. It returns ErrNoReset,
+// leaving the parser as it was, if the scanner can't be reset, see
+// CanReset.
+func (p *Parser) Reset(input []byte) error {
+	sc, ok := p.scanner.(inputResetter)
+	if !ok {
+		return ErrNoReset
+	}
+	sc.ResetInput(input)
+	p.init(p.scanner)
+	return nil
+}
+
 func (p *Parser) Fork() *Parser {
 	sc := p.scanner.(token.ForkableScanner)
 	return &Parser{
 		CurrentToken:     p.CurrentToken,
 		PeekToken:        p.PeekToken,
+		lookahead:        slices.Clone(p.lookahead),
+		prevEnd:          p.prevEnd,
 		scanner:          sc.Fork(),
 		scopes:           maps.Clone(p.scopes),
+		scopeStack:       slices.Clone(p.scopeStack),
+		lastScopeID:      p.lastScopeID,
+		depth:            p.depth,
+		maxDepth:         p.maxDepth,
+		maxArgs:          p.maxArgs,
+		recoverPanics:    p.recoverPanics,
+		errorHandler:     p.errorHandler,
+		reserved:         p.reserved,
+		precedences:      p.precedences,
+		ignoreTypes:      p.ignoreTypes,
+		strictReturn:     p.strictReturn,
+		smartSemis:       p.smartSemis,
+		requireInits:     p.requireInits,
+		strictEquality:   p.strictEquality,
+		module:           p.module,
+		comments:         p.comments,
+		trackComments:    p.trackComments,
+		trackedComments:  slices.Clone(p.trackedComments),
+		prelude:          p.prelude,
 		stmtParser:       p.stmtParser,
 		exprParser:       p.exprParser,
 		binaryExprParser: p.binaryExprParser,
 		unaryExprParser:  p.unaryExprParser,
+		patternParser:    p.patternParser,
 	}
 }
 
@@ -95,28 +235,200 @@ func (p *Parser) Apply(p1 *Parser) {
 	sc.Apply(p1.scanner)
 	p.CurrentToken = p1.CurrentToken
 	p.PeekToken = p1.PeekToken
+	p.lookahead = slices.Clone(p1.lookahead)
+	p.trackedComments = slices.Clone(p1.trackedComments)
+	p.prevEnd = p1.prevEnd
 	p.scopes = maps.Clone(p1.scopes)
+	p.scopeStack = slices.Clone(p1.scopeStack)
+	p.lastScopeID = p1.lastScopeID
 }
 
-func (p *Parser) ParseStmt() (ast.Stmt, error) {
+func (p *Parser) ParseStmt() (stmt ast.Stmt, err error) {
+	if p.recoverPanics && p.depth == 0 {
+		defer p.recoverPanic(&err)
+	}
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.exit()
 	return p.stmtParser(p)
 }
 
-func (p *Parser) ParseExpr() (ast.Expr, error) {
+func (p *Parser) ParseExpr() (expr ast.Expr, err error) {
+	if p.recoverPanics && p.depth == 0 {
+		defer p.recoverPanic(&err)
+	}
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.exit()
 	return p.exprParser(p)
 }
 
+// recoverPanic turns a panic into a PanicError stored in err. The state of
+// the parser is unknown after a panic, so the rest of the input is skipped,
+// without calling the scanner again.
+func (p *Parser) recoverPanic(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	*err = &PanicError{Position: p.CurrentToken.Position, Value: r, Stack: debug.Stack()}
+	p.aborted = true
+	p.CurrentToken = token.Token{Type: token.EOF, Position: p.CurrentToken.Position}
+	p.PeekToken = p.CurrentToken
+	p.lookahead = nil
+}
+
 func (p *Parser) ParseBinaryExpr(left ast.Expr) (ast.Expr, error) {
 	return p.binaryExprParser(p, left)
 }
 
 func (p *Parser) ParseUnaryExpr() (ast.Expr, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.exit()
 	return p.unaryExprParser(p)
 }
 
+// ParsePattern parses a binding pattern, such as a function parameter: an
+// identifier or, in dialects with destructuring, an object or array pattern.
+func (p *Parser) ParsePattern() (ast.Node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.exit()
+	return p.patternParser(p)
+}
+
+// Codes of the errors reported when the input exceeds the limits set with
+// Builder.WithMaxDepth and Builder.WithMaxArgumentCount.
+const (
+	MaxDepthCode         = "max-depth"
+	MaxArgumentCountCode = "max-argument-count"
+)
+
+// DefaultMaxDepth is the default limit of nested statements and expressions,
+// which prevents deeply nested input from overflowing the stack.
+const DefaultMaxDepth = 5000
+
+func (p *Parser) enter() error {
+	if p.depth >= p.maxDepth {
+		err := p.NewError(p.CurrentToken, "maximum nesting depth exceeded")
+		err.Code = MaxDepthCode
+		// skip the rest of the input, as recovering from here would nest again
+		for p.CurrentToken.Type != token.EOF {
+			p.AdvanceToken()
+		}
+		return err
+	}
+	p.depth++
+	return nil
+}
+
+// CheckArgumentCount reports an error if a list that has n elements, such
+// as the arguments of a call or the elements of an array literal, can't have
+// another one, as set with Builder.WithMaxArgumentCount. List parsers call it
+// before each element.
+func (p *Parser) CheckArgumentCount(n int) error {
+	if p.maxArgs > 0 && n >= p.maxArgs {
+		err := p.NewError(p.CurrentToken, "maximum argument count exceeded")
+		err.Code = MaxArgumentCountCode
+		return err
+	}
+	return nil
+}
+
+func (p *Parser) exit() {
+	p.depth--
+}
+
 func (p *Parser) AdvanceToken() {
+	p.prevEnd = p.CurrentToken.End()
 	p.CurrentToken = p.PeekToken
-	p.PeekToken = p.scanner.NextToken()
+	if len(p.lookahead) > 0 {
+		p.PeekToken = p.lookahead[0]
+		p.lookahead = p.lookahead[1:]
+	} else {
+		p.PeekToken = p.nextToken()
+	}
+}
+
+// PeekTokenN returns the token n positions ahead of the current one, without
+// consuming anything: PeekTokenN(0) is CurrentToken and PeekTokenN(1) is
+// PeekToken. The tokens are read once and kept until they are consumed.
+func (p *Parser) PeekTokenN(n int) token.Token {
+	switch {
+	case n <= 0:
+		return p.CurrentToken
+	case n == 1:
+		return p.PeekToken
+	}
+	for len(p.lookahead) < n-1 {
+		p.lookahead = append(p.lookahead, p.nextToken())
+	}
+	return p.lookahead[n-2]
+}
+
+// nextToken returns the next token from the scanner. If comments are enabled
+// (see Builder.WithComments), the comment and newline tokens before it are
+// moved into its leading trivia.
+func (p *Parser) nextToken() token.Token {
+	tok := p.readToken()
+	if p.trackComments {
+		for _, t := range tok.LeadingTrivia {
+			if t.Type == token.LINE_COMMENT || t.Type == token.BLOCK_COMMENT {
+				// line comments end before the line break
+				t.Literal = strings.TrimRight(t.Literal, "\r\n")
+				p.trackedComments = append(p.trackedComments, Comment{
+					Text:  t.Literal,
+					Range: Range{Start: t.Position, End: t.End()},
+				})
+			}
+		}
+	}
+	return tok
+}
+
+// readToken reads the next token from the scanner, see nextToken.
+func (p *Parser) readToken() token.Token {
+	if p.aborted {
+		return token.Token{Type: token.EOF, Position: p.CurrentToken.Position}
+	}
+	tok := p.scanner.NextToken()
+	if !p.comments {
+		return tok
+	}
+	var trivia []token.Token
+	afterNewline := false
+	for {
+		switch tok.Type {
+		case token.NEWLINE:
+			afterNewline = true
+		case token.LINE_COMMENT, token.BLOCK_COMMENT:
+			afterNewline = afterNewline || strings.ContainsAny(tok.Literal, "\n\r")
+		default:
+			tok.LeadingTrivia = append(trivia, tok.LeadingTrivia...)
+			tok.AfterNewline = tok.AfterNewline || afterNewline
+			return tok
+		}
+		trivia = append(trivia, tok)
+		tok = p.scanner.NextToken()
+	}
+}
+
+// Comments returns the comments read so far, in source order, if the parser
+// was built with Builder.WithTrackComments. It includes the comments of the
+// tokens that have been peeked but not consumed yet.
+func (p *Parser) Comments() []Comment {
+	return p.trackedComments
+}
+
+// PrevEnd returns the position right after the last consumed token, which is
+// where a missing token, such as a semicolon, should be inserted.
+func (p *Parser) PrevEnd() token.Position {
+	return p.prevEnd
 }
 
 func (p *Parser) Expect(typ token.Type) (token.Token, error) {
@@ -137,11 +449,150 @@ func (p *Parser) ExpectString(s string) (token.Token, error) {
 	return tok, nil
 }
 
+// IsReserved reports whether name was reserved with Builder.WithReservedWords,
+// so it can't be used as an identifier.
+func (p *Parser) IsReserved(name string) bool {
+	return p.reserved[name]
+}
+
+// Precedence returns the precedence of the binary operator typ, as
+// overridden with Builder.WithPrecedence or else registered globally.
+func (p *Parser) Precedence(typ token.Type) int {
+	if precedence, ok := p.precedences[typ]; ok {
+		return precedence
+	}
+	return typ.Precedence()
+}
+
+// Prelude returns the statements added with Builder.WithPrelude, which
+// ParseProgram puts before the ones it reads.
+func (p *Parser) Prelude() []ast.Stmt {
+	return p.prelude
+}
+
+// StrictReturn reports whether return statements must be inside functions,
+// see Builder.WithStrictReturn.
+func (p *Parser) StrictReturn() bool {
+	return p.strictReturn
+}
+
+// SmartSemicolons reports whether missing semicolons are inserted, see
+// Builder.WithSmartSemicolons.
+func (p *Parser) SmartSemicolons() bool {
+	return p.smartSemis
+}
+
+// RequireInitializers reports whether declarations must have initial
+// values, see Builder.WithRequireInitializers.
+func (p *Parser) RequireInitializers() bool {
+	return p.requireInits
+}
+
+// StrictEquality reports whether == and != are rejected, see
+// Builder.WithStrictEquality.
+func (p *Parser) StrictEquality() bool {
+	return p.strictEquality
+}
+
+// Module reports whether the source is parsed as an ES module, see
+// Builder.WithModule.
+func (p *Parser) Module() bool {
+	return p.module
+}
+
+// SkipTypeAnnotation skips a type annotation, as in `a: number`, following a
+// declared name, if type annotations are ignored (see
+// Builder.WithIgnoreTypeAnnotations).
+func (p *Parser) SkipTypeAnnotation() error {
+	if !p.ignoreTypes || p.CurrentToken.Type != token.COLON {
+		return nil
+	}
+	p.AdvanceToken()
+	return p.skipTypeName()
+}
+
+// SkipTypeAssertion skips a type assertion, as in `x as string`, following an
+// expression, if type annotations are ignored (see
+// Builder.WithIgnoreTypeAnnotations).
+func (p *Parser) SkipTypeAssertion() error {
+	for p.ignoreTypes && p.CurrentToken.Type == token.IDENT && p.CurrentToken.Literal == "as" && !p.CurrentToken.AfterNewline {
+		p.AdvanceToken()
+		if err := p.skipTypeName(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipTypeName skips a possibly qualified type name, such as `string`,
+// `ns.Type` or `number[]`.
+func (p *Parser) skipTypeName() error {
+	for {
+		if p.CurrentToken.Type != token.IDENT && !p.CurrentToken.Type.IsKeyword() {
+			return p.Error("type name expected")
+		}
+		p.AdvanceToken()
+		if p.CurrentToken.Type != token.DOT {
+			break
+		}
+		p.AdvanceToken()
+	}
+	for p.CurrentToken.Type == token.LBRACKET && p.PeekToken.Type == token.RBRACKET {
+		p.AdvanceToken()
+		p.AdvanceToken()
+	}
+	return nil
+}
+
+// ReportError passes the errors in err to the handler set with
+// Builder.WithErrorHandler, if any. It's called when the parser recovers from
+// an error, rather than when the error is created, since the errors of the
+// alternatives discarded by Switch aren't errors in the input.
+func (p *Parser) ReportError(err error) {
+	if p.errorHandler == nil {
+		return
+	}
+	switch v := err.(type) {
+	case nil:
+	case ErrorList:
+		for _, err := range v {
+			p.ReportError(err)
+		}
+	case Error:
+		p.errorHandler(v)
+	case *PanicError:
+		p.errorHandler(v.parserError())
+	default:
+		p.errorHandler(Error{
+			Range:   Range{Start: p.CurrentToken.Position, End: p.CurrentToken.Position},
+			Message: v.Error(),
+		})
+	}
+}
+
 func (p *Parser) Error(msg string) error {
 	return p.ErrorAt(p.CurrentToken, msg)
 }
 
 func (p *Parser) ErrorAt(tok token.Token, msg string) error {
+	return p.NewError(tok, msg)
+}
+
+// NewError is like ErrorAt, but returns the Error itself, so that a Code or a
+// Fix can be added to it.
+func (p *Parser) NewError(tok token.Token, msg string) Error {
+	var code string
+	// the scanner knows better why an illegal token is wrong
+	if sc, ok := p.scanner.(token.ErrorScanner); ok && tok.Type == token.ILLEGAL {
+		if err := sc.TokenError(tok.Position); err != nil {
+			msg = err.Error()
+			// scanner errors may come with a code, as with ErrorCode() string
+			var coded interface{ ErrorCode() string }
+			if errors.As(err, &coded) {
+				code = coded.ErrorCode()
+			}
+		}
+	}
 	line := tok.Line
 	column := tok.Column
 	if tok.Type == token.EOF {
@@ -159,15 +610,77 @@ func (p *Parser) ErrorAt(tok token.Token, msg string) error {
 			},
 		},
 		Message: msg,
+		Code:    code,
 	}
 }
 
 func (p *Parser) EnterScope(sc Scope) {
+	p.EnterNamedScope(sc, "")
+}
+
+// EnterNamedScope enters a scope that is identified by a name, such as the
+// body of a named function.
+func (p *Parser) EnterNamedScope(sc Scope, name string) {
 	p.scopes.Enter(sc)
+	p.lastScopeID++
+	p.scopeStack = append(p.scopeStack, scopeEntry{scope: sc, name: name, id: p.lastScopeID})
 }
 
 func (p *Parser) ExitScope(sc Scope) {
 	p.scopes.Exit(sc)
+	for i := len(p.scopeStack) - 1; i >= 0; i-- {
+		if p.scopeStack[i].scope == sc {
+			p.scopeStack = slices.Delete(p.scopeStack, i, i+1)
+			break
+		}
+	}
+}
+
+// ScopeDepth returns the number of scopes the parser is currently in.
+func (p *Parser) ScopeDepth() int {
+	return len(p.scopeStack)
+}
+
+// EnclosingScopes returns the scopes the parser is currently in, ordered
+// from the outermost to the innermost one.
+func (p *Parser) EnclosingScopes() []Scope {
+	var scopes []Scope
+	for _, entry := range p.scopeStack {
+		scopes = append(scopes, entry.scope)
+	}
+	return scopes
+}
+
+// ScopeNames returns the names of the enclosing instances of the given scope,
+// ordered from the outermost to the innermost one. Unnamed instances are
+// reported as empty strings.
+func (p *Parser) ScopeNames(sc Scope) []string {
+	var names []string
+	for _, entry := range p.scopeStack {
+		if entry.scope == sc {
+			names = append(names, entry.name)
+		}
+	}
+	return names
+}
+
+// ScopeLevel returns the number of nested instances of the given scope the
+// parser is currently in, such as the block depth for js.BlockScope.
+func (p *Parser) ScopeLevel(sc Scope) int {
+	return p.scopes[sc]
+}
+
+// ScopeID returns the id of the innermost enclosing instance of the given
+// scope, or 0 if the parser is not in it. Each scope entered while parsing
+// a program gets a different id, starting at 1, so plugins can use it to
+// generate names that are unique per block or function.
+func (p *Parser) ScopeID(sc Scope) int {
+	for i := len(p.scopeStack) - 1; i >= 0; i-- {
+		if p.scopeStack[i].scope == sc {
+			return p.scopeStack[i].id
+		}
+	}
+	return 0
 }
 
 func (p *Parser) InScope(sc Scope) bool {
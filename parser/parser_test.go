@@ -13,7 +13,9 @@ import (
 	"github.com/xjslang/xjs/ast"
 	"github.com/xjslang/xjs/internal/testutil"
 	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/jsextended"
 	"github.com/xjslang/xjs/parser"
+	"github.com/xjslang/xjs/plugin"
 	"github.com/xjslang/xjs/scanner"
 	"github.com/xjslang/xjs/token"
 )
@@ -59,6 +61,43 @@ func ExampleBuilder_Build() {
 	// Output: 'Hello, World!'
 }
 
+type CondExpr struct {
+	ast.BaseExpr
+	Cond, Then, Else ast.Expr
+}
+
+// The scanner emits ? and : as tokens of their own, so plugins can build
+// syntax on them, such as a conditional operator. It is parsed as a binary
+// operator, whose left operand is the condition.
+func Example_conditional() {
+	token.RegisterBinaryType(token.QUESTION, -1) // the lowest precedence
+	b := xjs.PluginBuilder()
+	b.UseBinaryParser(func(p *parser.Parser, left ast.Expr, next func(left ast.Expr) (ast.Expr, error)) (_ ast.Expr, err error) {
+		if p.CurrentToken.Type != token.QUESTION {
+			return next(left)
+		}
+		p.AdvanceToken()
+		node := &CondExpr{Cond: left}
+		if node.Then, err = p.ParseExpr(); err != nil {
+			return
+		}
+		if _, err = p.Expect(token.COLON); err != nil {
+			return
+		}
+		if node.Else, err = p.ParseExpr(); err != nil {
+			return
+		}
+		return node, nil
+	})
+	result, err := js.ParseProgram(b.Build([]byte("a == 1 ? b : c")))
+	if err != nil {
+		panic(err)
+	}
+	expr := result.Stmts[0].(*js.ExprStmt).Expr.(*CondExpr)
+	fmt.Printf("%T %T %T\n", expr.Cond, expr.Then, expr.Else)
+	// Output: *js.BinaryExpr *js.Variable *js.Variable
+}
+
 func TestMain(m *testing.M) {
 	flag.BoolVar(&updateGoldenFiles, "update", false, "update golden files")
 	flag.Parse()
@@ -113,6 +152,55 @@ func TestLookahead(t *testing.T) {
 	})
 }
 
+func TestBuildFromTokens(t *testing.T) {
+	p := parser.NewBuilder().BuildFromTokens([]token.Token{
+		{Type: token.IDENT, Literal: "a", Position: token.Position{Column: 0}},
+		{Type: token.IDENT, Literal: "b", Position: token.Position{Column: 2}},
+	})
+	result, err := parser.Switch(p, func(p *parser.Parser) (*js.Variable, error) {
+		node := &js.Variable{}
+		_, err := p.ExpectString("a")
+		if err != nil {
+			return nil, err
+		}
+		node.Token, err = p.ExpectString("c")
+		return node, err
+	}, func(p *parser.Parser) (*js.Variable, error) {
+		node := &js.Variable{}
+		_, err := p.ExpectString("a")
+		if err != nil {
+			return nil, err
+		}
+		node.Token, err = p.ExpectString("b")
+		return node, err
+	})
+	require.NoError(t, err)
+	require.Equal(t, "b", result.Token.Literal)
+	require.Equal(t, token.EOF, p.CurrentToken.Type)
+	require.Equal(t, 2, p.CurrentToken.Column)
+}
+
+func TestPeekTokenN(t *testing.T) {
+	p := xjs.Extended.PluginBuilder().Build([]byte("a b c d"))
+	require.Equal(t, "a", p.PeekTokenN(0).Literal)
+	require.Equal(t, "b", p.PeekTokenN(1).Literal)
+	require.Equal(t, "d", p.PeekTokenN(3).Literal)
+	require.Equal(t, token.EOF, p.PeekTokenN(5).Type)
+	require.Equal(t, "c", p.PeekTokenN(2).Literal)
+
+	// peeked tokens are consumed in order, and kept across forks
+	p.AdvanceToken()
+	require.Equal(t, "b", p.CurrentToken.Literal)
+	require.Equal(t, "c", p.PeekToken.Literal)
+	fork := p.Fork()
+	fork.AdvanceToken()
+	require.Equal(t, "d", fork.PeekToken.Literal)
+	require.Equal(t, "d", p.PeekTokenN(2).Literal)
+	p.Apply(fork)
+	require.Equal(t, "c", p.CurrentToken.Literal)
+	require.Equal(t, token.EOF, p.PeekTokenN(2).Type)
+}
+
 func TestExprs(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -376,3 +464,388 @@ func TestInvalidTokenAfterNewline(t *testing.T) {
 		}
 	}
 }
+
+func TestEnclosingScopes(t *testing.T) {
+	input := `mark
+	function foo() {
+		mark
+		{ mark }
+		while (true) mark
+	}`
+	var scopes [][]parser.Scope
+	b := xjs.PluginBuilder()
+	b.UseStmtParser(func(p *parser.Parser, next func() (ast.Stmt, error)) (ast.Stmt, error) {
+		if p.CurrentToken.Literal == "mark" {
+			require.Len(t, p.EnclosingScopes(), p.ScopeDepth())
+			scopes = append(scopes, p.EnclosingScopes())
+		}
+		return next()
+	})
+	p := b.Build([]byte(input))
+	_, err := js.ParseProgram(p)
+	require.NoError(t, err)
+	require.Equal(t, [][]parser.Scope{
+		nil,
+		{js.FunctionScope, js.BlockScope},
+		{js.FunctionScope, js.BlockScope, js.BlockScope},
+		{js.FunctionScope, js.BlockScope, js.LoopScope},
+	}, scopes)
+	require.Zero(t, p.ScopeDepth())
+}
+
+func TestScopeNames(t *testing.T) {
+	input := `mark
+	function main() {
+		mark
+		function main() { mark }
+		let f = function () { mark }
+	}`
+	var names [][]string
+	b := xjs.PluginBuilder()
+	b.UseStmtParser(func(p *parser.Parser, next func() (ast.Stmt, error)) (ast.Stmt, error) {
+		if p.CurrentToken.Literal == "mark" {
+			names = append(names, p.ScopeNames(js.FunctionScope))
+		}
+		return next()
+	})
+	_, err := js.ParseProgram(b.Build([]byte(input)))
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		nil,
+		{"main"},
+		{"main", "main"},
+		{"main", ""},
+	}, names)
+}
+
+func TestScopeIDs(t *testing.T) {
+	input := `mark
+	{ mark; { mark } }
+	{ mark }`
+	type block struct{ level, id int }
+	var blocks []block
+	b := xjs.PluginBuilder()
+	b.UseStmtParser(func(p *parser.Parser, next func() (ast.Stmt, error)) (ast.Stmt, error) {
+		if p.CurrentToken.Literal == "mark" {
+			blocks = append(blocks, block{p.ScopeLevel(js.BlockScope), p.ScopeID(js.BlockScope)})
+		}
+		return next()
+	})
+	_, err := js.ParseProgram(b.Build([]byte(input)))
+	require.NoError(t, err)
+	require.Equal(t, []block{{0, 0}, {1, 1}, {2, 2}, {1, 3}}, blocks)
+}
+
+func FuzzParse(f *testing.F) {
+	entries, err := os.ReadDir("../testdata")
+	require.NoError(f, err)
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".js") {
+			input, err := os.ReadFile("../testdata/" + entry.Name())
+			require.NoError(f, err)
+			f.Add(input)
+		}
+	}
+	f.Fuzz(func(t *testing.T, input []byte) {
+		// partial results must be printable too
+		result, _ := testutil.ParseExtended(input)
+		_, _ = testutil.PrintExtended(result)
+	})
+}
+
+func TestMaxDepth(t *testing.T) {
+	b := xjs.PluginBuilder()
+	b.WithMaxDepth(10)
+	_, err := js.ParseProgram(b.Build([]byte("let a = [[[[[[[[[[1]]]]]]]]]];\nlet b = 2;")))
+	require.EqualError(t, err, "[line:0, col:12] maximum nesting depth exceeded")
+
+	t.Run("default", func(t *testing.T) {
+		input := strings.Repeat("!", parser.DefaultMaxDepth) + "a"
+		_, err := testutil.ParseExtended([]byte(input))
+		require.ErrorContains(t, err, "maximum nesting depth exceeded")
+	})
+
+	t.Run("ternary chains", func(t *testing.T) {
+		// chains are parsed in a loop, so they aren't limited by the depth
+		n := parser.DefaultMaxDepth * 2
+		var sb strings.Builder
+		sb.WriteString("x = ")
+		for i := range n {
+			fmt.Fprintf(&sb, "a%d ? b%d : ", i, i)
+		}
+		sb.WriteString("c;")
+		result, err := testutil.ParseExtended([]byte(sb.String()))
+		require.NoError(t, err)
+		expr := result.Stmts[0].(*js.ExprStmt).Expr.(*js.AssignExpr).Right
+		for range n {
+			expr = expr.(*jsextended.TernaryExpr).Else
+		}
+		require.Equal(t, "c", expr.(*js.Variable).Literal)
+		out, err := testutil.PrintExtended(result)
+		require.NoError(t, err)
+		require.Equal(t, sb.String(), out)
+	})
+
+	t.Run("unclosed parentheses", func(t *testing.T) {
+		// used to take exponential time
+		_, err := testutil.ParseExtended([]byte(strings.Repeat("(", 100) + "a"))
+		require.ErrorContains(t, err, ") expected")
+	})
+}
+
+func TestMaxArgumentCount(t *testing.T) {
+	b := xjs.Extended.PluginBuilder()
+	b.WithMaxArgumentCount(3)
+	for _, input := range []string{
+		"f(1, 2, 3);",
+		"let a = [1, 2, 3];",
+		"let a = [, , ,];",
+		"let o = { a, b, c };",
+		"function f(a, b, c) {}",
+		"let f = (a, b, c) => 1;",
+		"let f = a => 1;",
+	} {
+		_, err := js.ParseProgram(b.Build([]byte(input)))
+		require.NoError(t, err, input)
+	}
+
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"f(1, 2, 3, 4);", "[line:0, col:11] maximum argument count exceeded"},
+		{"let a = [1, 2, 3, 4];", "[line:0, col:18] maximum argument count exceeded"},
+		{"let a = [, , , ,];", "[line:0, col:15] maximum argument count exceeded"},
+		{"let o = { a, b, c, d };", "[line:0, col:19] maximum argument count exceeded"},
+		{"function f(a, b, c, d) {}", "[line:0, col:20] maximum argument count exceeded"},
+		{"let f = function (a, b, c, d) {};", "[line:0, col:27] maximum argument count exceeded"},
+		{"let f = (a, b, c, d) => 1;", "[line:0, col:21] maximum argument count exceeded"},
+	}
+	for _, test := range tests {
+		_, err := js.ParseProgram(b.Build([]byte(test.input)))
+		var errs parser.ErrorList
+		require.ErrorAs(t, err, &errs, test.input)
+		require.EqualError(t, errs[0], test.err, test.input)
+		require.Equal(t, parser.MaxArgumentCountCode, errs[0].(parser.Error).Code, test.input)
+	}
+}
+
+func TestLimitCodes(t *testing.T) {
+	code := func(b *plugin.Builder, input string) string {
+		_, err := js.ParseProgram(b.Build([]byte(input)))
+		var errs parser.ErrorList
+		require.ErrorAs(t, err, &errs, input)
+		return errs[0].(parser.Error).Code
+	}
+	b := xjs.PluginBuilder()
+	b.WithMaxDepth(3)
+	require.Equal(t, parser.MaxDepthCode, code(b, "a = [[[[1]]]];"))
+	b = xjs.PluginBuilder()
+	b.WithMaxTokens(3)
+	require.Equal(t, scanner.MaxTokensCode, code(b, "a = 1 + 2;"))
+	b = xjs.PluginBuilder()
+	b.WithMaxArgumentCount(1)
+	require.Equal(t, parser.MaxArgumentCountCode, code(b, "f(1, 2);"))
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	var reported []string
+	b := xjs.Extended.PluginBuilder()
+	b.WithErrorHandler(func(err parser.Error) {
+		reported = append(reported, err.Error())
+	})
+	// the for statement is first tried as a for-of loop, whose errors must
+	// not be reported
+	input := "let a = ;\nfor (let i = 0; i < 1; i = i + 1) {}\nfunction f() { let b = ; let c = ; }"
+	_, err := js.ParseProgram(b.Build([]byte(input)))
+	require.Error(t, err)
+	require.Equal(t, strings.Split(err.Error(), "\n"), reported)
+	require.Len(t, reported, 3)
+}
+
+func TestWithReservedWords(t *testing.T) {
+	b := xjs.Extended.PluginBuilder()
+	b.WithReservedWords("enum", "yield")
+	tests := []struct {
+		input, err string
+	}{
+		{"let enum = 1;", "[line:0, col:4] reserved word used as identifier"},
+		{"function f(yield) {}", "[line:0, col:11] reserved word used as identifier"},
+		{"function yield() {}", "[line:0, col:9] reserved word used as identifier"},
+		{"x = enum + 1;", "[line:0, col:4] reserved word used as identifier"},
+		{"const { a: yield } = b;", "[line:0, col:11] reserved word used as identifier"},
+		{"x = a.enum;", ""},
+		{"x = { enum: 1 };", ""},
+		{"let enumerate = 1;", ""},
+	}
+	for _, test := range tests {
+		_, err := js.ParseProgram(b.Build([]byte(test.input)))
+		if test.err == "" {
+			require.NoError(t, err, test.input)
+		} else {
+			// the statement may produce further errors after recovering
+			require.ErrorContains(t, err, test.err, test.input)
+		}
+	}
+
+	// words aren't reserved by default
+	_, err := testutil.ParseExtended([]byte("let enum = 1;"))
+	require.NoError(t, err)
+
+	// words reserved later don't affect the parsers already built
+	p := b.Build([]byte("let implements = 1;"))
+	b.WithReservedWords("implements")
+	_, err = js.ParseProgram(p)
+	require.NoError(t, err)
+}
+
+func TestWithIgnoreTypeAnnotations(t *testing.T) {
+	b := xjs.Extended.PluginBuilder()
+	b.WithIgnoreTypeAnnotations()
+	tests := []struct {
+		input, expected string
+	}{
+		{"let a: number = 1, b: string = 'x';", "let a = 1, b = 'x';"},
+		{"const { a, b }: Point = p;", "const { a, b } = p;"},
+		{"var xs: ns.Item[] = [];", "var xs = [];"},
+		{"function f(a: number, b): void { return a as any; }", "function f(a, b) {\n  return a;\n}"},
+		{"g = function (s: string): string[] { return [s]; };", "g = function (s) {\n  return [s];\n};"},
+		{"x = (y as Foo).bar + f(z as Baz) as number;", "x = (y).bar + f(z);"},
+		{"try { f(); } catch (e: unknown) {}", "try {\n  f();\n} catch (e) {}"},
+		{"x = c ? a : b;", "x = c ? a : b;"},
+	}
+	for _, test := range tests {
+		program, err := js.ParseProgram(b.Build([]byte(test.input)))
+		require.NoError(t, err, test.input)
+		out, err := xjs.Extended.Print(program)
+		require.NoError(t, err, test.input)
+		require.Equal(t, test.expected, out, test.input)
+	}
+
+	_, err := js.ParseProgram(b.Build([]byte("let a: = 1;")))
+	require.ErrorContains(t, err, "[line:0, col:7] type name expected")
+
+	// annotations are syntax errors by default
+	_, err = testutil.ParseExtended([]byte("let a: number = 1;"))
+	require.Error(t, err)
+}
+
+// sliceScanner feeds the parser from a list of tokens, which may include
+// comments and newlines.
+type sliceScanner struct {
+	tokens []token.Token
+}
+
+func (sc *sliceScanner) NextToken() token.Token {
+	if len(sc.tokens) == 0 {
+		return token.Token{Type: token.EOF}
+	}
+	tok := sc.tokens[0]
+	sc.tokens = sc.tokens[1:]
+	return tok
+}
+
+func (sc *sliceScanner) Fork() token.Scanner {
+	return &sliceScanner{tokens: sc.tokens}
+}
+
+func (sc *sliceScanner) Apply(s token.Scanner) {
+	sc.tokens = s.(*sliceScanner).tokens
+}
+
+func TestWithComments(t *testing.T) {
+	tokens := func() *sliceScanner {
+		return &sliceScanner{tokens: []token.Token{
+			{Type: token.LINE_COMMENT, Literal: "// note"},
+			{Type: token.NEWLINE, Literal: "\n"},
+			{Type: token.IDENT, Literal: "x"},
+			{Type: token.ASSIGN, Literal: "="},
+			{Type: token.NUMBER, Literal: "1"},
+			{Type: token.BLOCK_COMMENT, Literal: "/* one */"},
+			{Type: token.SEMICOLON, Literal: ";"},
+			{Type: token.NEWLINE, Literal: "\n"},
+			{Type: token.IDENT, Literal: "y"},
+			{Type: token.ASSIGN, Literal: "="},
+			{Type: token.IDENT, Literal: "x"},
+			{Type: token.BLOCK_COMMENT, Literal: "/* end */"},
+		}}
+	}
+	b := xjs.Extended.PluginBuilder()
+	b.WithComments()
+	program, err := js.ParseProgram(b.BuildWithScanner(tokens()))
+	require.NoError(t, err)
+	out, err := xjs.Extended.Print(program)
+	require.NoError(t, err)
+	require.Equal(t, "// note\nx = 1 /* one */;\ny = x; /* end */", out)
+
+	// without it, comment tokens are unexpected
+	_, err = js.ParseProgram(xjs.Extended.PluginBuilder().BuildWithScanner(tokens()))
+	require.Error(t, err)
+}
+
+func TestWithTrackComments(t *testing.T) {
+	input := "// head\nfor (let i = 0; i < n; i++) { /* body */ f(i) }\n/* tail */"
+	b := xjs.Extended.PluginBuilder()
+	b.WithTrackComments()
+	p := b.Build([]byte(input))
+	program, err := js.ParseProgram(p)
+	require.NoError(t, err)
+	require.Equal(t, []parser.Comment{
+		{Text: "// head", Range: parser.Range{
+			Start: token.Position{Line: 0, Column: 0},
+			End:   token.Position{Line: 0, Column: 7},
+		}},
+		{Text: "/* body */", Range: parser.Range{
+			Start: token.Position{Line: 1, Column: 30},
+			End:   token.Position{Line: 1, Column: 40},
+		}},
+		{Text: "/* tail */", Range: parser.Range{
+			Start: token.Position{Line: 2, Column: 0},
+			End:   token.Position{Line: 2, Column: 10},
+		}},
+	}, p.Comments())
+
+	// the comments are still printed
+	out, err := xjs.Extended.Print(program)
+	require.NoError(t, err)
+	require.Contains(t, out, "/* body */")
+
+	// without it, no comments are collected
+	p = xjs.Extended.PluginBuilder().Build([]byte(input))
+	_, err = js.ParseProgram(p)
+	require.NoError(t, err)
+	require.Empty(t, p.Comments())
+}
+
+func TestWithPanicRecovery(t *testing.T) {
+	b := xjs.Extended.PluginBuilder()
+	b.UseStmtParser(func(p *parser.Parser, next func() (ast.Stmt, error)) (ast.Stmt, error) {
+		if p.CurrentToken.Literal == "boom" {
+			panic("boom")
+		}
+		return next()
+	})
+	input := "let a = 1;\nif (a) { boom(); }\nlet b = 2;"
+	require.Panics(t, func() {
+		_, _ = js.ParseProgram(b.Build([]byte(input)))
+	})
+
+	var reported []parser.Error
+	b.WithPanicRecovery()
+	b.WithErrorHandler(func(err parser.Error) {
+		reported = append(reported, err)
+	})
+	result, err := js.ParseProgram(b.Build([]byte(input)))
+	var errs parser.ErrorList
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 1)
+	var panicErr *parser.PanicError
+	require.ErrorAs(t, errs[0], &panicErr)
+	require.Equal(t, "boom", panicErr.Value)
+	require.Contains(t, string(panicErr.Stack), "TestWithPanicRecovery")
+	require.EqualError(t, panicErr, "[line:1, col:9] internal error: boom")
+	// parsing stops at the panic
+	require.Len(t, result.Stmts, 1)
+	require.Len(t, reported, 1)
+	require.Equal(t, parser.PanicCode, reported[0].Code)
+}
@@ -0,0 +1,50 @@
+package parser
+
+import "sync"
+
+// Pool recycles parsers of the same dialect, for services that parse many
+// inputs concurrently. Parsers are created by the given function and reused
+// through Parser.Reset, so their scanner must support it:
+//
+//	pool, err := parser.NewPool(func() *parser.Parser {
+//		return xjs.Extended.PluginBuilder().Build(nil)
+//	})
+//	...
+//	p, err := pool.Get(input)
+//	...
+//	defer pool.Put(p)
+//
+// A parser must not be used after it is put back, and neither should the
+// tokens it holds. The nodes it returned remain valid.
+type Pool struct {
+	pool sync.Pool
+}
+
+// NewPool returns a pool of the parsers created by newParser. It returns
+// ErrNoReset if they can't be reset, see Parser.CanReset.
+func NewPool(newParser func() *Parser) (*Pool, error) {
+	p := newParser()
+	if !p.CanReset() {
+		return nil, ErrNoReset
+	}
+	pool := &Pool{pool: sync.Pool{New: func() any { return newParser() }}}
+	pool.pool.Put(p)
+	return pool, nil
+}
+
+// Get returns a parser ready to parse input. It only fails if newParser
+// created a parser that can't be reset after the pool was built.
+func (pool *Pool) Get(input []byte) (*Parser, error) {
+	p := pool.pool.Get().(*Parser)
+	if err := p.Reset(input); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Put returns p to the pool. The input it was parsing is released.
+func (pool *Pool) Put(p *Parser) {
+	if p.Reset(nil) == nil {
+		pool.pool.Put(p)
+	}
+}
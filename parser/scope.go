@@ -6,6 +6,12 @@ type Scope int
 
 type ScopeTracker map[Scope]int
 
+type scopeEntry struct {
+	scope Scope
+	name  string
+	id    int
+}
+
 var (
 	nextScope Scope
 	regMut    sync.Mutex
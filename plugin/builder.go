@@ -2,6 +2,7 @@ package plugin
 
 import (
 	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/internal/fingerprint"
 	"github.com/xjslang/xjs/parser"
 	"github.com/xjslang/xjs/scanner"
 	"github.com/xjslang/xjs/token"
@@ -23,6 +24,98 @@ func (b *Builder) UseScanner(scanner func(sc *scanner.Scanner, next func() (toke
 	b.scanner.UseScanner(scanner)
 }
 
+func (b *Builder) UseKeywords(keywords map[string]token.Type) {
+	b.scanner.UseKeywords(keywords)
+}
+
+func (b *Builder) RemoveKeywords(keywords ...string) {
+	b.scanner.RemoveKeywords(keywords...)
+}
+
+func (b *Builder) UseOperators(operators map[string]token.Type) {
+	b.scanner.UseOperators(operators)
+}
+
+func (b *Builder) UseMacros(macros map[string][]token.Token) {
+	b.scanner.UseMacros(macros)
+}
+
+func (b *Builder) WithIdentifierRules(isStart, isPart func(r rune) bool) {
+	b.scanner.WithIdentifierRules(isStart, isPart)
+}
+
+func (b *Builder) WithMaxTokens(n int) {
+	b.scanner.WithMaxTokens(n)
+}
+
+func (b *Builder) WithSignificantIndentation() {
+	b.scanner.WithSignificantIndentation()
+}
+
+func (b *Builder) WithMaxDepth(n int) {
+	b.parser.WithMaxDepth(n)
+}
+
+func (b *Builder) WithMaxArgumentCount(n int) {
+	b.parser.WithMaxArgumentCount(n)
+}
+
+func (b *Builder) WithPanicRecovery() {
+	b.parser.WithPanicRecovery()
+}
+
+func (b *Builder) WithErrorHandler(handler func(err parser.Error)) {
+	b.parser.WithErrorHandler(handler)
+}
+
+func (b *Builder) WithReservedWords(words ...string) {
+	b.parser.WithReservedWords(words...)
+}
+
+func (b *Builder) WithPrecedence(typ token.Type, precedence int) {
+	b.parser.WithPrecedence(typ, precedence)
+}
+
+func (b *Builder) WithIgnoreTypeAnnotations() {
+	b.parser.WithIgnoreTypeAnnotations()
+}
+
+func (b *Builder) WithStrictReturn() {
+	b.parser.WithStrictReturn()
+}
+
+func (b *Builder) WithSmartSemicolons() {
+	b.parser.WithSmartSemicolons()
+}
+
+func (b *Builder) WithRequireInitializers() {
+	b.parser.WithRequireInitializers()
+}
+
+func (b *Builder) WithStrictEquality() {
+	b.parser.WithStrictEquality()
+}
+
+func (b *Builder) WithModule() {
+	b.parser.WithModule()
+}
+
+func (b *Builder) WithDialect(dialect parser.Dialect) {
+	b.parser.WithDialect(dialect)
+}
+
+func (b *Builder) WithComments() {
+	b.parser.WithComments()
+}
+
+func (b *Builder) WithTrackComments() {
+	b.parser.WithTrackComments()
+}
+
+func (b *Builder) WithPrelude(stmts ...ast.Stmt) {
+	b.parser.WithPrelude(stmts...)
+}
+
 func (b *Builder) UseUnaryParser(parser func(p *parser.Parser, next func() (ast.Expr, error)) (ast.Expr, error)) {
 	b.parser.UseUnaryParser(parser)
 }
@@ -39,12 +132,38 @@ func (b *Builder) UseExprParser(parser func(p *parser.Parser, next func() (ast.E
 	b.parser.UseExprParser(parser)
 }
 
+func (b *Builder) UsePatternParser(parser func(p *parser.Parser, next func() (ast.Node, error)) (ast.Node, error)) {
+	b.parser.UsePatternParser(parser)
+}
+
 func (b *Builder) Install(plugin func(b *Builder)) *Builder {
 	plugin(b)
 	return b
 }
 
+// Fingerprint returns a hash of the scanner and parser configuration
+// installed by the plugins, see parser.Builder.Fingerprint.
+func (b *Builder) Fingerprint() string {
+	h := fingerprint.New()
+	h.Add("scanner", b.scanner.Fingerprint())
+	h.Add("parser", b.parser.Fingerprint())
+	return h.Sum()
+}
+
 func (b *Builder) Build(src []byte) *parser.Parser {
 	s := b.scanner.Build(src)
 	return b.parser.Build(s)
 }
+
+// BuildScanner builds the scanner configured by the plugins, to read the
+// tokens of src without parsing them.
+func (b *Builder) BuildScanner(src []byte) *scanner.Scanner {
+	return b.scanner.Build(src)
+}
+
+// BuildWithScanner builds a parser that reads the tokens from sc instead of
+// the scanner configured by the plugins. The parser forks sc for lookahead,
+// so it should also implement token.ForkableScanner.
+func (b *Builder) BuildWithScanner(sc token.Scanner) *parser.Parser {
+	return b.parser.Build(sc)
+}
@@ -0,0 +1,170 @@
+package printer
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xjslang/xjs/ast"
+)
+
+// flatText is the rendering of a node printed without a maximum width, as
+// measured by Fits. The renderings of the nodes within the measured one are
+// kept too, so that each node is printed by the printers once to be
+// measured, and isn't printed by them again where its rendering can be
+// reused, see printFlat.
+type flatText struct {
+	text       string
+	start      int  // offset of text in the measuring output, or -1
+	tainted    bool // something other than text was printed or requested first
+	flexible   bool // Fits was called, so the layout depends on the width
+	statements int
+	errors     ErrorList
+	// the state the node leaves behind
+	ensure      bool
+	ensureChar  rune
+	pendingSemi bool
+}
+
+// flatKey identifies a rendering. The same node may be printed differently
+// in another context, see PushContext.
+type flatKey struct {
+	node    ast.Node
+	context string
+}
+
+func (pr *Printer) flatKey(node ast.Node) (flatKey, bool) {
+	if reflect.TypeOf(node).Kind() != reflect.Pointer {
+		return flatKey{}, false
+	}
+	ctx := pr.Context()
+	if len(ctx) == 0 {
+		return flatKey{node: node}, true
+	}
+	var b strings.Builder
+	for _, k := range slices.Sorted(maps.Keys(ctx)) {
+		b.WriteString(k + "=" + ctx[k] + ";")
+	}
+	return flatKey{node, b.String()}, true
+}
+
+// measure returns the rendering of node, printing it if it wasn't measured
+// before.
+func (pr *Printer) measure(node ast.Node) *flatText {
+	if pr.flat == nil {
+		pr.flat = make(map[flatKey]*flatText)
+	}
+	if key, ok := pr.flatKey(node); ok {
+		if f := pr.flat[key]; f != nil {
+			return f
+		}
+	}
+	tmp := *pr
+	tmp.doc = strings.Builder{}
+	tmp.out = &tmp.doc
+	tmp.writeErr = nil
+	tmp.errors = nil
+	tmp.maxWidth = 0
+	tmp.measuring = true
+	tmp.open = nil
+	tmp.Print(node)
+	return tmp.last
+}
+
+// printFlat prints the rendering of node instead of printing node, if it was
+// measured and printing node would give the same text: the rendering is on a
+// single line and either fits or doesn't depend on the width. While
+// measuring, any rendering is reused, since only the first line counts.
+func (pr *Printer) printFlat(node ast.Node) bool {
+	if pr.flat == nil {
+		return false
+	}
+	key, ok := pr.flatKey(node)
+	if !ok {
+		return false
+	}
+	f := pr.flat[key]
+	if f == nil || f.start < 0 || f.tainted {
+		return false
+	}
+	if !pr.measuring {
+		if strings.Contains(f.text, "\n") {
+			return false
+		}
+		if f.flexible && pr.flatColumn(f.text)+utf8.RuneCountInString(f.text) > pr.maxWidth {
+			return false
+		}
+	}
+	pr.printPendingSemi(f.text)
+	pr.printSeparatorIfNeeded()
+	pr.startFlat()
+	pr.writeString(f.text)
+	if f.flexible {
+		for _, open := range pr.open {
+			open.flexible = true
+		}
+	}
+	pr.ensure, pr.ensureChar = f.ensure, f.ensureChar
+	if f.pendingSemi {
+		pr.pendingSemi = true
+		pr.semiLine = pr.line
+	}
+	pr.statements += f.statements
+	pr.errors = append(pr.errors, f.errors...)
+	return true
+}
+
+// flatColumn returns the column s would start at, after the pending
+// semicolon and separator.
+func (pr *Printer) flatColumn(s string) int {
+	tmp := *pr
+	tmp.doc = strings.Builder{}
+	tmp.out = &tmp.doc
+	tmp.open = nil
+	if s != "" {
+		tmp.printPendingSemi(s)
+	}
+	tmp.printSeparatorIfNeeded()
+	return tmp.column
+}
+
+// beginFlat starts recording the rendering of a node, while measuring.
+func (pr *Printer) beginFlat() *flatText {
+	f := &flatText{start: -1, statements: pr.statements, errors: pr.errors}
+	pr.open = append(pr.open, f)
+	return f
+}
+
+// endFlat finishes recording f, the rendering of node.
+func (pr *Printer) endFlat(key flatKey, keyed bool, f *flatText) {
+	pr.open = pr.open[:len(pr.open)-1]
+	if f.start >= 0 {
+		f.text = pr.doc.String()[f.start:]
+	}
+	f.statements = pr.statements - f.statements
+	f.errors = slices.Clone(pr.errors[len(f.errors):])
+	f.ensure, f.ensureChar, f.pendingSemi = pr.ensure, pr.ensureChar, pr.pendingSemi
+	if _, ok := pr.flat[key]; keyed && !ok {
+		pr.flat[key] = f
+	}
+	pr.last = f
+}
+
+// startFlat records that the text of the nodes being measured starts, after
+// their separator.
+func (pr *Printer) startFlat() {
+	for i := len(pr.open) - 1; i >= 0 && pr.open[i].start < 0; i-- {
+		pr.open[i].start = pr.doc.Len()
+	}
+}
+
+// taintFlat records that the nodes being measured print or request
+// something before their text, which depends on what precedes them, so
+// their renderings can't be reused.
+func (pr *Printer) taintFlat() {
+	for i := len(pr.open) - 1; i >= 0 && pr.open[i].start < 0; i-- {
+		pr.open[i].tainted = true
+	}
+}
@@ -2,8 +2,10 @@ package printer
 
 import (
 	"errors"
+	"io"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/xjslang/xjs/ast"
@@ -30,17 +32,32 @@ func (list ErrorList) Error() string {
 }
 
 type config struct {
-	indent       string
-	withComments bool
-	withNewLines bool
-	withLogs     bool
+	indent            string
+	lineEnding        string
+	withLineComments  bool
+	withBlockComments bool
+	withNewLines      bool
+	statementLines    bool
+	withLogs          bool
+	emptyBlockSpace   bool
+	minimalSemis      bool
+	normalizeNumbers  bool
+	chainBreaking     int
+	maxWidth          int
+	banner            string
+	sourceURL         string
+	precedences       map[token.Type]int
+	output            io.Writer
+	stats             *Stats
+	parse             func([]byte) (ast.Node, error)
 }
 
 type Option func(*config)
 
 func Compact() Option {
 	return func(cfg *config) {
-		cfg.withComments = false
+		cfg.withLineComments = false
+		cfg.withBlockComments = false
 		cfg.withNewLines = false
 	}
 }
@@ -51,9 +68,31 @@ func WithIndent(value string) Option {
 	}
 }
 
+// WithLineEnding sets the line terminator used for the printed new lines,
+// such as "\n" or "\r\n".
+func WithLineEnding(value string) Option {
+	return func(cfg *config) {
+		cfg.lineEnding = value
+	}
+}
+
+// WithComments shows or hides both line and block comments.
 func WithComments(value bool) Option {
 	return func(cfg *config) {
-		cfg.withComments = value
+		cfg.withLineComments = value
+		cfg.withBlockComments = value
+	}
+}
+
+func WithLineComments(value bool) Option {
+	return func(cfg *config) {
+		cfg.withLineComments = value
+	}
+}
+
+func WithBlockComments(value bool) Option {
+	return func(cfg *config) {
+		cfg.withBlockComments = value
 	}
 }
 
@@ -63,49 +102,339 @@ func WithNewLines(value bool) Option {
 	}
 }
 
+// WithStatementLines prints each statement of the printed program on its own
+// line, even with new lines disabled, so that compact output is still easy to
+// diff:
+//
+//	let a = 1;
+//	function f(x) {if (x) {return a;}}
+//	f(a);
+//
+// It has no effect when new lines are enabled.
+func WithStatementLines(value bool) Option {
+	return func(cfg *config) {
+		cfg.statementLines = value
+	}
+}
+
 func WithLogs(value bool) Option {
 	return func(cfg *config) {
 		cfg.withLogs = value
 	}
 }
 
+// WithEmptyBlockSpace prints empty blocks as `{ }` instead of `{}`.
+func WithEmptyBlockSpace(value bool) Option {
+	return func(cfg *config) {
+		cfg.emptyBlockSpace = value
+	}
+}
+
+// WithMinimalSemicolons omits the semicolons printed with PrintSemi, unless
+// the next statement is on the same line or starts with a character that
+// would continue the previous one, such as ( or [:
+//
+//	let a = 1
+//	;[a] = b
+func WithMinimalSemicolons(value bool) Option {
+	return func(cfg *config) {
+		cfg.minimalSemis = value
+	}
+}
+
+// SemicolonStyle tells which of the semicolons ending statements are printed.
+// See WithSemicolons.
+type SemicolonStyle int
+
+const (
+	// SemicolonsAlways ends every statement with a semicolon, as Prettier
+	// does. This is the default.
+	SemicolonsAlways SemicolonStyle = iota
+	// SemicolonsAsNeeded prints only the semicolons that automatic semicolon
+	// insertion can't restore, as Standard does. See WithMinimalSemicolons.
+	SemicolonsAsNeeded
+)
+
+// WithSemicolons sets which of the semicolons ending statements are printed.
+// There is no style without semicolons at all, since some of them are
+// required, as in `a = b;(c || d).e()`.
+func WithSemicolons(style SemicolonStyle) Option {
+	return func(cfg *config) {
+		cfg.minimalSemis = style == SemicolonsAsNeeded
+	}
+}
+
+// WithNormalizeNumbers prints decimal numbers in their shortest form, as
+// minifiers do: 100000000000000000000 as 1e20, or 0.50 as .5. By default,
+// numbers keep their source spelling.
+func WithNormalizeNumbers(value bool) Option {
+	return func(cfg *config) {
+		cfg.normalizeNumbers = value
+	}
+}
+
+// WithChainBreaking prints method chains with more than n calls one call per
+// line:
+//
+//	promise
+//	  .then(f)
+//	  .then(g)
+//	  .catch(h)
+//
+// A non-positive n, the default, keeps the chains on a single line.
+func WithChainBreaking(n int) Option {
+	return func(cfg *config) {
+		cfg.chainBreaking = n
+	}
+}
+
+// WithMaxWidth sets the width that lines should not exceed. Call arguments,
+// and array and object elements, are printed one per line when their
+// expression doesn't fit. See Fits.
+//
+// Other constructs aren't broken, so the width can still be exceeded.
+func WithMaxWidth(n int) Option {
+	return func(cfg *config) {
+		cfg.maxWidth = n
+	}
+}
+
+// WithBanner prints text as a header comment, such as
+// "Generated by xjs; do not edit.", before anything else. Lines that aren't
+// comments already are turned into line comments. The banner is kept even
+// when comments are hidden.
+func WithBanner(text string) Option {
+	return func(cfg *config) {
+		cfg.banner = text
+	}
+}
+
+// WithSourceURL ends the output with a `//# sourceURL=` comment naming the
+// script url, so that debuggers can name code run through eval or new
+// Function. Like the banner, it's kept even when comments are hidden.
+func WithSourceURL(url string) Option {
+	return func(cfg *config) {
+		cfg.sourceURL = url
+	}
+}
+
+// WithPrecedence tells the printers that the binary operator typ binds with
+// the given precedence, as set with parser.Builder.WithPrecedence for the
+// parser that read the code. See Printer.Precedence.
+func WithPrecedence(typ token.Type, precedence int) Option {
+	return func(cfg *config) {
+		if cfg.precedences == nil {
+			cfg.precedences = make(map[token.Type]int)
+		}
+		cfg.precedences[typ] = precedence
+	}
+}
+
+// WithOutput streams the printed text to w instead of keeping it in memory.
+// In that case Output only reports the errors.
+func WithOutput(w io.Writer) Option {
+	return func(cfg *config) {
+		cfg.output = w
+	}
+}
+
+// WithParser sets the function Parse reads source code with, which should be
+// the one of the dialect that read the printed tree, so that printers
+// reparsing part of it, such as the substitutions of a template literal,
+// accept the same constructs.
+func WithParser(parse func(src []byte) (ast.Node, error)) Option {
+	return func(cfg *config) {
+		cfg.parse = parse
+	}
+}
+
+// Stats holds measures of the printed code, see WithStats.
+type Stats struct {
+	// Bytes is the size of the output.
+	Bytes int
+	// Lines is the number of lines of the output, the last one included
+	// even if it doesn't end with a line break.
+	Lines int
+	// Statements is the number of statements printed, blocks included. As
+	// in ast.Measure, the nodes passed to Print aren't counted themselves,
+	// so printing a program counts its statements.
+	Statements int
+	// Duration is the time spent from Build to the last call to Output.
+	Duration time.Duration
+}
+
+// WithStats makes Output fill stats with measures of the printed code, for
+// tools monitoring their output over time.
+func WithStats(stats *Stats) Option {
+	return func(cfg *config) {
+		cfg.stats = stats
+	}
+}
+
 type Printer struct {
-	doc          strings.Builder
-	withComments bool
-	withNewLines bool
-	withLogs     bool
-	indent       string
-	indentLevel  int
-	lastChar     rune
-	ensureChar   rune
-	ensure       bool
-	printer      func(*Printer, ast.Node) error
-	context      []map[string]string
-	errors       ErrorList
+	doc               strings.Builder
+	out               io.Writer
+	writeErr          error
+	line, column      int
+	withLineComments  bool
+	withBlockComments bool
+	withNewLines      bool
+	statementLines    bool
+	withLogs          bool
+	emptyBlockSpace   bool
+	minimalSemis      bool
+	normalizeNumbers  bool
+	chainBreaking     int
+	maxWidth          int
+	sourceURL         string // printed by Output, see WithSourceURL
+	precedences       map[token.Type]int
+	pendingSemi       bool
+	semiLine          int
+	indent            string
+	lineEnding        string
+	indentLevel       int
+	lastChar          rune
+	ensureChar        rune
+	ensure            bool
+	stmtLine          bool // whether a statement of the program starts, see WithStatementLines
+	printer           func(*Printer, ast.Node) error
+	context           []map[string]string
+	flat              map[flatKey]*flatText // renderings measured by Fits
+	measuring         bool
+	open              []*flatText // renderings being recorded, while measuring
+	last              *flatText   // the rendering recorded last, while measuring
+	errors            ErrorList
+	stats             *Stats
+	parse             func([]byte) (ast.Node, error) // see WithParser
+	start             time.Time
+	bytes             int
+	statements        int
+	nesting           int
 }
 
 func (pr *Printer) init(opts ...Option) {
 	cfg := &config{
-		withComments: true,
-		withNewLines: true,
-		indent:       "  ",
+		withLineComments:  true,
+		withBlockComments: true,
+		withNewLines:      true,
+		indent:            "  ",
+		lineEnding:        "\n",
 	}
 	for _, opt := range opts {
 		opt(cfg)
 	}
 	pr.doc.Reset()
-	pr.withComments = cfg.withComments
+	pr.out = &pr.doc
+	if cfg.output != nil {
+		pr.out = cfg.output
+	}
+	pr.writeErr = nil
+	pr.line, pr.column = 0, 0
+	pr.withLineComments = cfg.withLineComments
+	pr.withBlockComments = cfg.withBlockComments
 	pr.withNewLines = cfg.withNewLines
+	pr.statementLines = cfg.statementLines
 	pr.withLogs = cfg.withLogs
+	pr.emptyBlockSpace = cfg.emptyBlockSpace
+	pr.minimalSemis = cfg.minimalSemis
+	pr.normalizeNumbers = cfg.normalizeNumbers
+	pr.chainBreaking = cfg.chainBreaking
+	pr.maxWidth = cfg.maxWidth
+	pr.sourceURL = cfg.sourceURL
+	pr.precedences = cfg.precedences
+	pr.pendingSemi = false
 	pr.indent = cfg.indent
+	pr.lineEnding = cfg.lineEnding
 	pr.indentLevel = 0
 	pr.lastChar = eol
 	pr.ensureChar = eol
 	pr.ensure = false
+	pr.stmtLine = false
 	if pr.printer == nil {
 		pr.printer = defaultPrinter
 	}
 	pr.errors = nil
+	pr.flat, pr.measuring, pr.open, pr.last = nil, false, nil, nil
+	pr.stats = cfg.stats
+	pr.parse = cfg.parse
+	pr.start = time.Now()
+	pr.bytes, pr.statements, pr.nesting = 0, 0, 0
+	if cfg.banner != "" {
+		pr.printBanner(cfg.banner)
+	}
+}
+
+func (pr *Printer) printBanner(text string) {
+	if strings.HasPrefix(text, "/*") && strings.HasSuffix(text, "*/") {
+		pr.writeString(text + pr.lineEnding)
+		return
+	}
+	for line := range strings.Lines(text) {
+		line = strings.TrimRight(line, "\r\n")
+		if !strings.HasPrefix(line, "//") {
+			line = strings.TrimRight("// "+line, " ")
+		}
+		pr.writeString(line + pr.lineEnding)
+	}
+}
+
+// EmptyBlockSpace reports whether empty blocks are printed with a space
+// between the braces. See WithEmptyBlockSpace.
+func (pr *Printer) EmptyBlockSpace() bool {
+	return pr.emptyBlockSpace
+}
+
+// NormalizeNumbers reports whether numbers are printed in their shortest
+// form. See WithNormalizeNumbers.
+func (pr *Printer) NormalizeNumbers() bool {
+	return pr.normalizeNumbers
+}
+
+// ChainBreaking returns the number of calls a method chain can have before
+// it's broken into lines, or 0 if chains aren't broken. See
+// WithChainBreaking.
+func (pr *Printer) ChainBreaking() int {
+	return pr.chainBreaking
+}
+
+// Parse parses src with the parser set with WithParser, or fails if there's
+// none.
+func (pr *Printer) Parse(src []byte) (ast.Node, error) {
+	if pr.parse == nil {
+		return nil, errors.New("no parser, see WithParser")
+	}
+	return pr.parse(src)
+}
+
+// Precedence returns the precedence of the binary operator typ, as set with
+// WithPrecedence or else registered globally.
+func (pr *Printer) Precedence(typ token.Type) int {
+	if precedence, ok := pr.precedences[typ]; ok {
+		return precedence
+	}
+	return typ.Precedence()
+}
+
+// Fits reports whether the first line of node, printed from the current
+// position, stays within the width set with WithMaxWidth. It always reports
+// true if no width is set or new lines are disabled.
+//
+// Node is printed once to find out, without a maximum width. The renderings
+// of the nodes within it are kept, so they aren't printed again to be
+// measured, nor, where the layout allows it, to be printed.
+func (pr *Printer) Fits(node ast.Node) bool {
+	if pr.measuring {
+		for _, f := range pr.open {
+			f.flexible = true
+		}
+		return true
+	}
+	if pr.maxWidth <= 0 || !pr.withNewLines {
+		return true
+	}
+	f := pr.measure(node)
+	first, _, _ := strings.Cut(f.text, "\n")
+	return pr.flatColumn(f.text)+utf8.RuneCountInString(first) <= pr.maxWidth
 }
 
 func (pr *Printer) IncreaseIndent() {
@@ -119,6 +448,11 @@ func (pr *Printer) DecreaseIndent() {
 }
 
 func (pr *Printer) PrintIndent() {
+	pr.taintFlat()
+	pr.printIndent()
+}
+
+func (pr *Printer) printIndent() {
 	for range pr.indentLevel {
 		pr.writeString(pr.indent)
 	}
@@ -129,6 +463,7 @@ func (pr *Printer) PrintIndent() {
 // Only one ensure character can be pending at a time. For example,
 // Ensure(' ').Ensure('\n') will print a space and discard the newline request.
 func (pr *Printer) Ensure(c rune) *Printer {
+	pr.taintFlat()
 	if pr.ensure {
 		return pr
 	}
@@ -177,6 +512,41 @@ func (pr *Printer) Print(args ...any) {
 	}
 }
 
+// PrintSemi prints the semicolon ending a statement. Semicolons that are part
+// of the syntax, as in the header of a for loop, must be printed with Print
+// instead, since PrintSemi may omit them. See WithMinimalSemicolons.
+func (pr *Printer) PrintSemi(tok token.Token) {
+	pr.taintFlat()
+	if !pr.minimalSemis {
+		pr.Print(tok)
+		return
+	}
+	pr.PrintTrivia(tok.LeadingTrivia)
+	pr.pendingSemi = true
+	pr.semiLine = pr.line
+}
+
+// printPendingSemi prints the semicolon omitted by PrintSemi, if any, when
+// the text that follows it, s, requires it.
+func (pr *Printer) printPendingSemi(s string) {
+	if !pr.pendingSemi {
+		return
+	}
+	pr.pendingSemi = false
+	newLine := pr.line > pr.semiLine || pr.ensure && pr.ensureChar == '\n' && pr.printsNewLine()
+	if !newLine {
+		if s[0] != '}' {
+			pr.writeRune(';')
+		}
+		return
+	}
+	if strings.ContainsAny(s[:1], "([`+-/") {
+		// the semicolon starts the next line, as in `;(a || b).c()`
+		pr.printSeparatorIfNeeded()
+		pr.writeRune(';')
+	}
+}
+
 func (pr *Printer) Log(args ...any) {
 	if pr.withLogs {
 		pr.Print(args...)
@@ -184,36 +554,57 @@ func (pr *Printer) Log(args ...any) {
 }
 
 func (pr *Printer) PrintTrivia(trivia []token.Token) {
+	if len(trivia) > 0 {
+		pr.taintFlat()
+	}
 	es, e := pr.ensureChar, pr.ensure
 	for _, tok := range trivia {
-		if tok.Type == token.NEWLINE {
+		lit := tok.Literal
+		switch tok.Type {
+		case token.NEWLINE:
 			if pr.withNewLines {
-				pr.writeRune('\n')
+				pr.writeString(pr.lineEnding)
 			}
 			continue
+		case token.LINE_COMMENT:
+			if !pr.withLineComments {
+				continue
+			}
+			// line comments include their own line terminator
+			if s := strings.TrimRight(lit, "\r\n"); s != lit {
+				lit = s + pr.lineEnding
+			}
+		case token.BLOCK_COMMENT:
+			if !pr.withBlockComments {
+				continue
+			}
 		}
-		if pr.withComments {
-			pr.printSpaceIfNeeded()
-			pr.printIndentIfNeeded()
-			pr.writeString(tok.Literal)
-		}
+		pr.printSpaceIfNeeded()
+		pr.printIndentIfNeeded()
+		pr.writeString(lit)
 	}
 	pr.ensureChar, pr.ensure = es, e
 }
 
-func (pr *Printer) Error(msg string) error {
-	s := pr.doc.String()
-	line, col := 0, 0
-	for _, c := range s {
-		if c == '\n' {
-			line++
-			col = -1
-		}
-		col++
+// PrintLineComment prints text as a line comment on a line of its own,
+// unlike the comments in trivia, which stay beside the code before them. It
+// prints nothing if the printer drops line comments.
+func (pr *Printer) PrintLineComment(text string) {
+	if !pr.withLineComments {
+		return
 	}
+	pr.taintFlat()
+	if pr.column > 0 {
+		pr.writeString(pr.lineEnding)
+	}
+	pr.printIndentIfNeeded()
+	pr.writeString("// " + text + pr.lineEnding)
+}
+
+func (pr *Printer) Error(msg string) error {
 	return ErrorAt(token.Position{
-		Line:   line,
-		Column: col,
+		Line:   pr.line,
+		Column: pr.column,
 	}, msg)
 }
 
@@ -222,7 +613,26 @@ func (pr *Printer) Errors() ErrorList {
 }
 
 func (pr *Printer) Output() (string, error) {
-	return pr.doc.String(), errors.Join(pr.errors...)
+	if pr.sourceURL != "" {
+		if pr.column > 0 {
+			pr.writeString(pr.lineEnding)
+		}
+		pr.writeString("//# sourceURL=" + pr.sourceURL)
+		pr.sourceURL = ""
+	}
+	if pr.stats != nil {
+		lines := pr.line
+		if pr.column > 0 {
+			lines++
+		}
+		*pr.stats = Stats{
+			Bytes:      pr.bytes,
+			Lines:      lines,
+			Statements: pr.statements,
+			Duration:   time.Since(pr.start),
+		}
+	}
+	return pr.doc.String(), errors.Join(append(pr.errors, pr.writeErr)...)
 }
 
 func (pr *Printer) writeString(s string) {
@@ -231,30 +641,70 @@ func (pr *Printer) writeString(s string) {
 	}
 	r, _ := utf8.DecodeLastRuneInString(s)
 	pr.lastChar = r
-	pr.doc.WriteString(s)
+	pr.bytes += len(s)
+	for _, c := range s {
+		pr.advancePosition(c)
+	}
+	if pr.writeErr == nil {
+		_, pr.writeErr = io.WriteString(pr.out, s)
+	}
 }
 
 func (pr *Printer) writeRune(r rune) {
-	pr.lastChar = r
-	pr.doc.WriteRune(r)
+	pr.writeString(string(r))
+}
+
+func (pr *Printer) advancePosition(c rune) {
+	if c == '\n' {
+		pr.line++
+		pr.column = 0
+		return
+	}
+	pr.column++
 }
 
 func (pr *Printer) printNode(node ast.Node) {
-	if err := pr.printer(pr, node); err != nil {
+	if _, ok := node.(ast.Stmt); ok && pr.nesting > 0 {
+		pr.statements++
+		if pr.statementLines && pr.nesting == 1 {
+			// a statement of the printed program
+			pr.stmtLine = true
+		}
+	}
+	if pr.printFlat(node) {
+		return
+	}
+	var f *flatText
+	key, keyed := flatKey{}, false
+	if pr.measuring {
+		key, keyed = pr.flatKey(node)
+		f = pr.beginFlat()
+	}
+	pr.nesting++
+	err := pr.printer(pr, node)
+	pr.nesting--
+	if err != nil {
 		pr.errors = append(pr.errors, err)
 	}
+	if f != nil {
+		pr.endFlat(key, keyed, f)
+	}
 }
 
 func (pr *Printer) printString(s string) {
 	if len(s) == 0 {
 		return
 	}
+	pr.printPendingSemi(s)
 	pr.printSeparatorIfNeeded()
+	pr.startFlat()
 	pr.writeString(s)
 }
 
 func (pr *Printer) printRune(r rune) {
+	pr.printPendingSemi(string(r))
 	pr.printSeparatorIfNeeded()
+	pr.startFlat()
 	pr.writeRune(r)
 }
 
@@ -271,16 +721,21 @@ func (pr *Printer) printSpaceIfNeeded() {
 
 func (pr *Printer) printIndentIfNeeded() {
 	if isNewLine(pr.lastChar) {
-		pr.PrintIndent()
+		pr.printIndent()
 	}
 }
 
+// printsNewLine reports whether the new line requested with Line is printed.
+func (pr *Printer) printsNewLine() bool {
+	return pr.withNewLines || pr.stmtLine
+}
+
 func (pr *Printer) printSeparatorIfNeeded() {
 	if pr.ensure {
 		switch pr.ensureChar {
 		case '\n':
-			if pr.withNewLines && !isNewLine(pr.lastChar) {
-				pr.writeRune(pr.ensureChar)
+			if pr.printsNewLine() && !isNewLine(pr.lastChar) {
+				pr.writeString(pr.lineEnding)
 			}
 		case ' ':
 			if !isWhitespace(pr.lastChar) {
@@ -295,5 +750,6 @@ func (pr *Printer) printSeparatorIfNeeded() {
 		pr.ensureChar = eol
 		pr.ensure = false
 	}
+	pr.stmtLine = false
 	pr.printIndentIfNeeded()
 }
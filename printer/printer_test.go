@@ -1,7 +1,9 @@
 package printer_test
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -391,6 +393,8 @@ func TestWithComments(t *testing.T) {
 		{"show comments by default", xjs.PrinterBuilder().Build()},
 		{"hide comments", xjs.PrinterBuilder().Build(printer.WithComments(false))},
 		{"show comments", xjs.PrinterBuilder().Build(printer.WithComments(true))},
+		{"hide lcomments and show bcomments", xjs.PrinterBuilder().Build(printer.WithLineComments(false))},
+		{"show lcomments and hide bcomments", xjs.PrinterBuilder().Build(printer.WithBlockComments(false))},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -495,3 +499,303 @@ func TestError(t *testing.T) {
 	require.Equal(t, "something went wrong", errPos.Message)
 	require.EqualError(t, err, "[line:1, col:3] something went wrong")
 }
+
+func TestWithLineEnding(t *testing.T) {
+	input := "// c\r\nfunction foo() {\n\tlet a = 1 // c\r\n\n\tlet b = 2\r}"
+	result, err := xjs.Parse([]byte(input))
+	require.NoError(t, err)
+	out, err := xjs.Print(result, printer.WithLineEnding("\r\n"))
+	require.NoError(t, err)
+	require.Equal(t, "// c\r\nfunction foo() {\r\n  let a = 1; // c\r\n\r\n  let b = 2;\r\n}", out)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWithOutput(t *testing.T) {
+	input := "function foo() {\n\tlet a = 1 // c\n}"
+	result, err := xjs.Parse([]byte(input))
+	require.NoError(t, err)
+	expected, err := xjs.Print(result)
+	require.NoError(t, err)
+
+	t.Run("streams the output", func(t *testing.T) {
+		var sb strings.Builder
+		require.NoError(t, xjs.PrintTo(&sb, result))
+		require.Equal(t, expected, sb.String())
+	})
+
+	t.Run("reports write errors", func(t *testing.T) {
+		require.EqualError(t, xjs.PrintTo(failingWriter{}, result), "disk full")
+	})
+}
+
+func TestWithStats(t *testing.T) {
+	input := "function foo() {\n\tlet a = 1\n\tif (a) { a++ }\n}\nfoo()"
+	result, err := xjs.Parse([]byte(input))
+	require.NoError(t, err)
+	var stats printer.Stats
+	out, err := xjs.Print(result, printer.WithStats(&stats))
+	require.NoError(t, err)
+	require.Equal(t, len(out), stats.Bytes)
+	require.Equal(t, strings.Count(out, "\n")+1, stats.Lines)
+	// function, body, let, if, block, a++ and foo()
+	require.Equal(t, 7, stats.Statements)
+	require.Positive(t, stats.Duration)
+
+	var sb strings.Builder
+	require.NoError(t, xjs.PrintTo(&sb, result, printer.WithStats(&stats)))
+	require.Equal(t, sb.Len(), stats.Bytes)
+}
+
+func TestWithParser(t *testing.T) {
+	_, err := printer.NewBuilder().Build().Parse([]byte("a"))
+	require.Error(t, err)
+
+	pr := printer.NewBuilder().Build(printer.WithParser(func(src []byte) (ast.Node, error) {
+		return xjs.Parse(src)
+	}))
+	result, err := pr.Parse([]byte("a + 1"))
+	require.NoError(t, err)
+	require.IsType(t, &js.Program{}, result)
+}
+
+func TestWithBanner(t *testing.T) {
+	result, err := xjs.Parse([]byte("let a = 1 // c"))
+	require.NoError(t, err)
+	tests := []struct {
+		banner   string
+		opts     []printer.Option
+		expected string
+	}{
+		{"Generated by xjs; do not edit.", nil, "// Generated by xjs; do not edit.\nlet a = 1; // c"},
+		{"// one\n\ntwo\n", nil, "// one\n//\n// two\nlet a = 1; // c"},
+		{"/* License: MIT */", nil, "/* License: MIT */\nlet a = 1; // c"},
+		{"generated", []printer.Option{printer.Compact()}, "// generated\nlet a = 1;"},
+	}
+	for _, test := range tests {
+		out, err := xjs.Print(result, append(test.opts, printer.WithBanner(test.banner))...)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, out, test.banner)
+	}
+}
+
+func TestWithSourceURL(t *testing.T) {
+	result, err := xjs.Parse([]byte("let a = 1 // c"))
+	require.NoError(t, err)
+	tests := []struct {
+		opts     []printer.Option
+		expected string
+	}{
+		{nil, "let a = 1; // c\n//# sourceURL=foo.xjs"},
+		{[]printer.Option{printer.Compact()}, "let a = 1;\n//# sourceURL=foo.xjs"},
+		{[]printer.Option{printer.WithLineEnding("\r\n"), printer.WithBanner("banner")}, "// banner\r\nlet a = 1; // c\r\n//# sourceURL=foo.xjs"},
+	}
+	for _, test := range tests {
+		out, err := xjs.Print(result, append(test.opts, printer.WithSourceURL("foo.xjs"))...)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, out)
+	}
+
+	// an empty program is only the comment
+	pr := printer.NewBuilder().Build(printer.WithSourceURL("foo.xjs"))
+	out, err := pr.Output()
+	require.NoError(t, err)
+	require.Equal(t, "//# sourceURL=foo.xjs", out)
+	out, err = pr.Output()
+	require.NoError(t, err)
+	require.Equal(t, "//# sourceURL=foo.xjs", out)
+}
+
+func TestWithMinimalSemicolons(t *testing.T) {
+	input := `let a = 1; // c
+f();
+(a || b).c();
+[a] = b;
+x = y;
+-z;
+for (let i = 0; i < 10; i++) {
+  if (i) continue; else break;
+}
+do f(); while (a);
+function g() {
+  return a;
+}
+;`
+	result, err := xjs.Extended.Parse([]byte(input))
+	require.NoError(t, err)
+	out, err := xjs.Extended.Print(result, printer.WithMinimalSemicolons(true))
+	require.NoError(t, err)
+	require.Equal(t, `let a = 1 // c
+f()
+;(a || b).c()
+;[a] = b
+x = y
+;-z
+for (let i = 0; i < 10; i++) {
+  if (i) continue; else break
+}
+do f(); while (a)
+function g() {
+  return a
+}
+;`, out)
+
+	// the output means the same
+	result2, err := xjs.Extended.Parse([]byte(out))
+	require.NoError(t, err)
+	expected, err := xjs.Extended.Print(result, printer.Compact())
+	require.NoError(t, err)
+	out, err = xjs.Extended.Print(result2, printer.Compact())
+	require.NoError(t, err)
+	require.Equal(t, expected, out)
+
+	out, err = xjs.Extended.Print(result, printer.Compact(), printer.WithMinimalSemicolons(true))
+	require.NoError(t, err)
+	require.Equal(t, "let a = 1;f();(a || b).c();[a] = b;x = y;-z;for (let i = 0; i < 10; i++) {if (i) continue; else break}do f(); while (a);function g() {return a};", out)
+}
+
+func TestWithStatementLines(t *testing.T) {
+	input := `let a = 1; function f(x) {
+  if (x) { return 1 } else { g() }
+
+  return 2 }
+f(a); // c
+switch (a) { case 1: b(); default: c() }
+x = [a]`
+	result, err := xjs.Extended.Parse([]byte(input))
+	require.NoError(t, err)
+	out, err := xjs.Extended.Print(result, printer.Compact(), printer.WithStatementLines(true))
+	require.NoError(t, err)
+	require.Equal(t, `let a = 1;
+function f(x) {if (x) {return 1;} else {g();}return 2;}
+f(a);
+switch (a) {case 1:b();default:c();}
+x = [a];`, out)
+
+	out, err = xjs.Extended.Print(result, printer.Compact(), printer.WithStatementLines(true), printer.WithMinimalSemicolons(true))
+	require.NoError(t, err)
+	require.Equal(t, `let a = 1
+function f(x) {if (x) {return 1} else {g()}return 2}
+f(a)
+switch (a) {case 1:b();default:c()}
+x = [a]`, out)
+
+	// no effect with new lines
+	expected, err := xjs.Extended.Print(result)
+	require.NoError(t, err)
+	out, err = xjs.Extended.Print(result, printer.WithStatementLines(true))
+	require.NoError(t, err)
+	require.Equal(t, expected, out)
+}
+
+func TestWithSemicolons(t *testing.T) {
+	result, err := xjs.Extended.Parse([]byte("let a = 1\n[a] = b"))
+	require.NoError(t, err)
+	out, err := xjs.Extended.Print(result, printer.WithSemicolons(printer.SemicolonsAsNeeded))
+	require.NoError(t, err)
+	require.Equal(t, "let a = 1\n;[a] = b", out)
+
+	out, err = xjs.Extended.Print(result, printer.WithMinimalSemicolons(true), printer.WithSemicolons(printer.SemicolonsAlways))
+	require.NoError(t, err)
+	require.Equal(t, "let a = 1;\n[a] = b;", out)
+}
+
+func TestWithChainBreaking(t *testing.T) {
+	input := `promise.then(f).then(g).catch(h);
+a.b.c(1).d(x => x.y().z().w());
+f(a).b();
+5..toString().trim().at(0);
+promise /* c */ .then(f).then(g).done();`
+	result, err := xjs.Extended.Parse([]byte(input))
+	require.NoError(t, err)
+	out, err := xjs.Extended.Print(result, printer.WithChainBreaking(2))
+	require.NoError(t, err)
+	require.Equal(t, `promise
+  .then(f)
+  .then(g)
+  .catch(h);
+a.b.c(1).d(x => x
+  .y()
+  .z()
+  .w());
+f(a).b();
+5.
+  .toString()
+  .trim()
+  .at(0);
+promise /* c */
+  .then(f)
+  .then(g)
+  .done();`, out)
+
+	out, err = xjs.Extended.Print(result, printer.WithChainBreaking(2), printer.Compact())
+	require.NoError(t, err)
+	require.Equal(t, "promise.then(f).then(g).catch(h);a.b.c(1).d(x => x.y().z().w());f(a).b();5..toString().trim().at(0);promise.then(f).then(g).done();", out)
+}
+
+func TestWithMaxWidth(t *testing.T) {
+	input := `foo(alpha, beta, [1, 2, 3, 4, 5, 6, 7], { a: 1, b: "two" }, f(x));
+short(a, b);
+let x = [aaaaaaaa, bbbbbbbbbbb, , ccccccccccc];
+run(function () {
+  return 1;
+}, 2);
+let o = { key: [1, 2, 3], other: "some string value" };`
+	result, err := xjs.Extended.Parse([]byte(input))
+	require.NoError(t, err)
+	out, err := xjs.Extended.Print(result, printer.WithMaxWidth(30))
+	require.NoError(t, err)
+	require.Equal(t, `foo(
+  alpha,
+  beta,
+  [1, 2, 3, 4, 5, 6, 7],
+  { a: 1, b: "two" },
+  f(x)
+);
+short(a, b);
+let x = [
+  aaaaaaaa,
+  bbbbbbbbbbb,
+  ,
+  ccccccccccc
+];
+run(function () {
+  return 1;
+}, 2);
+let o = {
+  key: [1, 2, 3],
+  other: "some string value"
+};`, out)
+
+	// the same program
+	result2, err := xjs.Extended.Parse([]byte(out))
+	require.NoError(t, err)
+	expected, err := xjs.Extended.Print(result, printer.Compact())
+	require.NoError(t, err)
+	out, err = xjs.Extended.Print(result2, printer.Compact())
+	require.NoError(t, err)
+	require.Equal(t, expected, out)
+
+	// compact output is never broken
+	out, err = xjs.Extended.Print(result, printer.WithMaxWidth(30), printer.Compact())
+	require.NoError(t, err)
+	require.Equal(t, expected, out)
+}
+
+func TestWithNormalizeNumbers(t *testing.T) {
+	input := "x = [100000000000000000000, 1000, 100, 0.50, 0.000001, 1.0, 1e+3, 12.5e-1, 0x10, 0123, 0, 0.0, 1e400, 10n];\ny = 1.0.toFixed() + 5e0.toString();"
+	result, err := xjs.Extended.Parse([]byte(input))
+	require.NoError(t, err)
+	out, err := xjs.Extended.Print(result, printer.WithNormalizeNumbers(true))
+	require.NoError(t, err)
+	require.Equal(t, "x = [1e20, 1e3, 100, .5, 1e-6, 1, 1e3, 1.25, 0x10, 0123, 0, 0, 1e400, 10n];\ny = 1 .toFixed() + 5 .toString();", out)
+
+	// the source spelling is kept by default
+	out, err = xjs.Extended.Print(result)
+	require.NoError(t, err)
+	require.Equal(t, input, out)
+}
@@ -1,9 +1,23 @@
 package scanner
 
-import "github.com/xjslang/xjs/token"
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/xjslang/xjs/internal/fingerprint"
+	"github.com/xjslang/xjs/token"
+)
 
 type Builder struct {
-	scanners []func(*Scanner, func() (token.Token, error)) (token.Token, error)
+	scanners  []func(*Scanner, func() (token.Token, error)) (token.Token, error)
+	keywords  map[string]token.Type
+	operators map[string]token.Type
+	macros    map[string][]token.Token
+	maxTokens int
+	indented  bool
+	// identifier rules
+	isIdentStart, isIdentPart func(r rune) bool
 }
 
 func NewBuilder() *Builder {
@@ -15,8 +29,135 @@ func (b *Builder) UseScanner(scanner func(s *Scanner, next func() (token.Token,
 	return b
 }
 
+// UseKeywords instructs the scanner to report the given identifiers as
+// keywords of the corresponding types, which are registered with
+// token.RegisterKeywordType.
+func (b *Builder) UseKeywords(keywords map[string]token.Type) *Builder {
+	if b.keywords == nil {
+		b.keywords = make(map[string]token.Type, len(keywords))
+	}
+	maps.Copy(b.keywords, keywords)
+	for _, typ := range keywords {
+		token.RegisterKeywordType(typ)
+	}
+	return b
+}
+
+// RemoveKeywords turns the given keywords back into plain identifiers.
+func (b *Builder) RemoveKeywords(keywords ...string) *Builder {
+	for _, lit := range keywords {
+		delete(b.keywords, lit)
+	}
+	return b
+}
+
+// UseOperators instructs the scanner to report the given character sequences,
+// such as "<=>", as operators of the corresponding types. When several
+// operators match, the longest one wins, so "**=" is preferred over "**".
+// Operators take precedence over the built-in tokens, but not over the
+// scanners installed with UseScanner.
+func (b *Builder) UseOperators(operators map[string]token.Type) *Builder {
+	if b.operators == nil {
+		b.operators = make(map[string]token.Type, len(operators))
+	}
+	maps.Copy(b.operators, operators)
+	return b
+}
+
+// UseMacros instructs the scanner to replace the given identifiers with
+// their tokens, as the constants of a preprocessor:
+//
+//	b.UseMacros(map[string][]token.Token{
+//		"DEBUG": {{Type: token.IDENT, Literal: "false"}},
+//	})
+//
+// The replacement tokens are reported at the position of the identifier,
+// with its comments, and may use other macros. A macro that keeps expanding
+// into itself is reported as an illegal token.
+func (b *Builder) UseMacros(macros map[string][]token.Token) *Builder {
+	if b.macros == nil {
+		b.macros = make(map[string][]token.Token, len(macros))
+	}
+	maps.Copy(b.macros, macros)
+	return b
+}
+
+// WithIdentifierRules sets the characters that identifiers (and keywords)
+// may start with and contain. By default, identifiers start with an ASCII
+// letter, _ or $, and may also contain digits. A nil function keeps the
+// default rule. For example, to reject $ in identifiers:
+//
+//	b.WithIdentifierRules(
+//		func(r rune) bool { return r != '$' && scanner.IsLetter(r) },
+//		func(r rune) bool { return r != '$' && (scanner.IsLetter(r) || scanner.IsDigit(r)) },
+//	)
+func (b *Builder) WithIdentifierRules(isStart, isPart func(r rune) bool) *Builder {
+	b.isIdentStart = isStart
+	b.isIdentPart = isPart
+	return b
+}
+
+// WithMaxTokens limits the number of tokens the scanner produces. Once the
+// limit is reached, the scanner reports an illegal token and then EOF. A
+// non-positive value means no limit.
+func (b *Builder) WithMaxTokens(n int) *Builder {
+	b.maxTokens = n
+	return b
+}
+
+// WithSignificantIndentation makes the scanner report the layout of lines,
+// for dialects where blocks are delimited by indentation, as in Python:
+//
+//   - a token.NEWLINE at the end of each line with tokens,
+//   - a token.INDENT before the first token of a line that is indented more
+//     than the previous one,
+//   - a token.DEDENT for each indentation level closed by a line, or by the
+//     end of the input.
+//
+// Lines inside parentheses, brackets and braces continue the line where they
+// were opened, and lines with only comments are skipped. Indentation is
+// measured in characters, so a tab counts as much as a space, and a line
+// that goes back to a level that was never opened is illegal. The NEWLINE
+// tokens are regular tokens here, so parsers must not be built with
+// parser.Builder.WithComments.
+func (b *Builder) WithSignificantIndentation() *Builder {
+	b.indented = true
+	return b
+}
+
+// Fingerprint returns a hash of the configuration of the builder, see
+// parser.Builder.Fingerprint.
+func (b *Builder) Fingerprint() string {
+	h := fingerprint.New()
+	fingerprint.AddFuncs(h, "scanners", b.scanners...)
+	h.Add("keywords", b.keywords)
+	h.Add("operators", b.operators)
+	h.Add("macros", b.macros)
+	h.Add("maxTokens", b.maxTokens)
+	h.Add("indented", b.indented)
+	fingerprint.AddFuncs(h, "identifier rules", b.isIdentStart, b.isIdentPart)
+	h.AddTokenTypes()
+	return h.Sum()
+}
+
 func (b *Builder) Build(input []byte) *Scanner {
-	s := &Scanner{}
+	s := &Scanner{
+		keywords:     maps.Clone(b.keywords),
+		macros:       maps.Clone(b.macros),
+		maxTokens:    b.maxTokens,
+		isIdentStart: b.isIdentStart,
+		isIdentPart:  b.isIdentPart,
+	}
+	if b.indented {
+		s.indentation = &indentation{}
+	}
+	for lit, typ := range b.operators {
+		s.operators = append(s.operators, token.Token{Type: typ, Literal: lit})
+	}
+	// longest first
+	slices.SortFunc(s.operators, func(a, b token.Token) int {
+		return cmp.Compare(len(b.Literal), len(a.Literal))
+	})
 	for _, scanner := range b.scanners {
 		s.useScanner(scanner)
 	}
@@ -1,5 +1,7 @@
 package scanner
 
+import "strings"
+
 func IsLetter(r rune) bool {
 	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || r == '$'
 }
@@ -15,3 +17,35 @@ func IsHexDigit(r rune) bool {
 func IsOctalDigit(r rune) bool {
 	return r >= '0' && r <= '7'
 }
+
+// IsIdentifier reports whether s is spelled like an identifier. Note that
+// keywords are spelled like identifiers too.
+func IsIdentifier(s string) bool {
+	for i, r := range s {
+		if !IsLetter(r) && (i == 0 || !IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// IsBigInt reports whether s is a valid BigInt literal, such as "12n" or
+// "0xFFn". Fractions, exponents and leading zeros are not allowed.
+func IsBigInt(s string) bool {
+	digits, ok := strings.CutSuffix(s, "n")
+	if !ok || digits == "" {
+		return false
+	}
+	if len(digits) > 2 && digits[0] == '0' && strings.ContainsRune("xXoO", rune(digits[1])) {
+		return true
+	}
+	if len(digits) > 1 && digits[0] == '0' {
+		return false
+	}
+	for _, r := range digits {
+		if !IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/xjslang/xjs/token"
+)
+
+// indentation is the state of a scanner that reports the layout of lines, see
+// Builder.WithSignificantIndentation.
+type indentation struct {
+	levels      []int // columns of the open indentation levels
+	brackets    int   // open parentheses, brackets and braces
+	lineStarted bool  // whether the current line has tokens
+	pending     []token.Token
+}
+
+func (in *indentation) clone() *indentation {
+	if in == nil {
+		return nil
+	}
+	c := *in
+	c.levels = slices.Clone(in.levels)
+	c.pending = slices.Clone(in.pending)
+	return &c
+}
+
+// level returns the column of the innermost indentation level.
+func (in *indentation) level() int {
+	if n := len(in.levels); n > 0 {
+		return in.levels[n-1]
+	}
+	return 0
+}
+
+// pop returns the next token queued by tokens, if any.
+func (in *indentation) pop() (token.Token, bool) {
+	if len(in.pending) == 0 {
+		return token.Token{}, false
+	}
+	tok := in.pending[0]
+	in.pending = in.pending[1:]
+	return tok, true
+}
+
+// tokens returns the first of the NEWLINE, INDENT and DEDENT tokens that go
+// before tok, and queues the rest of them, followed by tok itself.
+func (in *indentation) tokens(sc *Scanner, tok token.Token) token.Token {
+	var layout []token.Token
+	add := func(typ token.Type) {
+		layout = append(layout, token.Token{Type: typ, Position: tok.Position, AfterNewline: tok.AfterNewline})
+	}
+	switch {
+	case tok.Type == token.EOF:
+		if in.lineStarted {
+			add(token.NEWLINE)
+		}
+		for range in.levels {
+			add(token.DEDENT)
+		}
+		in.levels = nil
+	case in.brackets == 0 && (tok.AfterNewline || !in.lineStarted):
+		if in.lineStarted {
+			add(token.NEWLINE)
+		}
+		if tok.Column > in.level() {
+			in.levels = append(in.levels, tok.Column)
+			add(token.INDENT)
+			break
+		}
+		for tok.Column < in.level() {
+			in.levels = in.levels[:len(in.levels)-1]
+			add(token.DEDENT)
+		}
+		if tok.Column != in.level() {
+			tok.Type = token.ILLEGAL
+			sc.errors[tok.Position] = errors.New("unindent does not match any outer indentation level")
+		}
+	}
+	switch tok.Type {
+	case token.LPAREN, token.LBRACKET, token.LBRACE:
+		in.brackets++
+	case token.RPAREN, token.RBRACKET, token.RBRACE:
+		in.brackets = max(0, in.brackets-1)
+	}
+	in.lineStarted = tok.Type != token.EOF
+	if len(layout) == 0 {
+		return tok
+	}
+	in.pending = append(layout[1:], tok)
+	return layout[0]
+}
@@ -0,0 +1,65 @@
+package scanner
+
+import (
+	"errors"
+
+	"github.com/xjslang/xjs/token"
+)
+
+// maxMacroDepth limits the expansion of macros used by other macros, so that
+// recursive macros don't expand forever.
+const maxMacroDepth = 16
+
+var errMacroDepth = errors.New("macro expansion too deep")
+
+// expandedToken returns the next token, with the identifiers defined as
+// macros replaced by their tokens. See Builder.UseMacros.
+func (sc *Scanner) expandedToken() token.Token {
+	if len(sc.macros) == 0 {
+		return sc.nextToken()
+	}
+	var trivia []token.Token
+	afterNewline := false
+	for len(sc.expansion) == 0 {
+		tok := sc.nextToken()
+		// an empty expansion leaves its comments to the next token
+		trivia = append(trivia, tok.LeadingTrivia...)
+		afterNewline = afterNewline || tok.AfterNewline
+		toks, err := sc.expand(tok, 0)
+		if err != nil {
+			tok.Type = token.ILLEGAL
+			sc.errors[tok.Position] = err
+			toks = []token.Token{tok}
+		}
+		sc.expansion = toks
+	}
+	tok := sc.expansion[0]
+	sc.expansion = sc.expansion[1:]
+	if len(trivia) > 0 || afterNewline {
+		tok.LeadingTrivia = trivia
+		tok.AfterNewline = afterNewline
+	}
+	return tok
+}
+
+// expand returns the tokens that replace the macro tok. The replacement
+// tokens are placed at the position of tok.
+func (sc *Scanner) expand(tok token.Token, depth int) (toks []token.Token, err error) {
+	replacement, ok := sc.macros[tok.Literal]
+	if !ok || tok.Type != token.IDENT {
+		return []token.Token{tok}, nil
+	}
+	if depth == maxMacroDepth {
+		return nil, errMacroDepth
+	}
+	for _, r := range replacement {
+		r.Position = tok.Position
+		r.LeadingTrivia, r.AfterNewline = nil, false
+		expanded, err := sc.expand(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		toks = append(toks, expanded...)
+	}
+	return toks, nil
+}
@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"errors"
 	"unicode/utf8"
 
 	"github.com/xjslang/xjs/token"
@@ -19,6 +20,11 @@ func (s *Scanner) useScanner(scanner func(s *Scanner, next func() (token.Token,
 }
 
 func defaultScanner(s *Scanner) (tok token.Token, err error) {
+	for _, op := range s.operators {
+		if s.AcceptString(op.Literal) {
+			return op, nil
+		}
+	}
 	switch s.currentChar {
 	// operators
 	case '=':
@@ -28,6 +34,10 @@ func defaultScanner(s *Scanner) (tok token.Token, err error) {
 			c2 := s.currentChar
 			s.AdvanceChar()
 			tok = token.Token{Type: token.EQ, Literal: string([]rune{c1, c2})}
+		} else if s.currentChar == '>' {
+			c2 := s.currentChar
+			s.AdvanceChar()
+			tok = token.Token{Type: token.ARROW, Literal: string([]rune{c1, c2})}
 		} else {
 			tok = token.Token{Type: token.ASSIGN, Literal: string(c1)}
 		}
@@ -182,6 +192,10 @@ func defaultScanner(s *Scanner) (tok token.Token, err error) {
 		c := s.currentChar
 		s.AdvanceChar()
 		tok = token.Token{Type: token.COLON, Literal: string(c)}
+	case '?':
+		c := s.currentChar
+		s.AdvanceChar()
+		tok = token.Token{Type: token.QUESTION, Literal: string(c)}
 	case '\r':
 		s.AdvanceChar()
 		if s.currentChar == '\n' {
@@ -194,9 +208,27 @@ func defaultScanner(s *Scanner) (tok token.Token, err error) {
 		s.AdvanceChar()
 		tok = token.Token{Type: token.NEWLINE, Literal: "\n"}
 	default:
-		if IsLetter(s.currentChar) {
-			lit := ScanIdentifier(s)
-			tok = token.Token{Type: token.IDENT, Literal: lit}
+		if s.IsIdentStart(s.currentChar) || s.currentChar == '\\' {
+			tok = token.Token{Type: token.IDENT}
+			var spelling string
+			if tok.Literal, spelling, err = scanEscapedIdentifier(s); err != nil {
+				tok.Type = token.ILLEGAL
+				tok.Literal = spelling
+				return
+			}
+			if spelling != tok.Literal {
+				tok.Raw = spelling
+			}
+			if typ, ok := s.keywords[tok.Literal]; ok {
+				if tok.Raw == "" {
+					tok.Type = typ
+				} else {
+					// an escaped keyword is only a name, as in `a.\u0069f`,
+					// so it keeps its spelling
+					tok.Literal = spelling
+					tok.Raw = ""
+				}
+			}
 		} else if IsDigit(s.currentChar) {
 			tok = token.Token{Type: token.NUMBER, Literal: string(s.currentChar)}
 			if s.currentChar == '0' {
@@ -233,6 +265,16 @@ func defaultScanner(s *Scanner) (tok token.Token, err error) {
 					return
 				}
 			}
+			if s.currentChar == 'n' {
+				tok.Literal += string(s.currentChar)
+				s.AdvanceChar()
+				tok.Type = token.BIGINT
+				if !IsBigInt(tok.Literal) {
+					err = errors.New("invalid BigInt literal")
+					tok.Type = token.ILLEGAL
+					return
+				}
+			}
 		} else if s.currentChar == utf8.RuneError {
 			c := s.currentChar
 			s.AdvanceChar()
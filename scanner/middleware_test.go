@@ -27,3 +27,117 @@ func TestUseScanner(t *testing.T) {
 		{Type: token.EOF},
 	})
 }
+
+func TestUseOperators(t *testing.T) {
+	spaceshipType := token.RegisterType("<=>")
+	powType := token.RegisterType("**")
+	powAssignType := token.RegisterType("**=")
+	sc := scanner.NewBuilder().
+		UseOperators(map[string]token.Type{
+			"<=>": spaceshipType,
+			"**":  powType,
+			"**=": powAssignType,
+		}).
+		Build([]byte("a <=> b <= c ** d **= e * f"))
+	assertLexerTokens(t, sc, []token.Token{
+		{Type: token.IDENT, Literal: "a"},
+		{Type: spaceshipType, Literal: "<=>"},
+		{Type: token.IDENT, Literal: "b"},
+		{Type: token.LTE, Literal: "<="},
+		{Type: token.IDENT, Literal: "c"},
+		{Type: powType, Literal: "**"},
+		{Type: token.IDENT, Literal: "d"},
+		{Type: powAssignType, Literal: "**="},
+		{Type: token.IDENT, Literal: "e"},
+		{Type: token.MULTIPLY, Literal: "*"},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.EOF},
+	})
+}
+
+func TestWithIdentifierRules(t *testing.T) {
+	noDollar := func(r rune) bool { return r != '$' && scanner.IsLetter(r) }
+	sc := scanner.NewBuilder().
+		WithIdentifierRules(noDollar, func(r rune) bool {
+			return noDollar(r) || scanner.IsDigit(r)
+		}).
+		Build([]byte("_a1 $b c$"))
+	assertLexerTokens(t, sc, []token.Token{
+		{Type: token.IDENT, Literal: "_a1"},
+		{Type: token.UNKNOWN, Literal: "$"},
+		{Type: token.IDENT, Literal: "b"},
+		{Type: token.IDENT, Literal: "c"},
+		{Type: token.UNKNOWN, Literal: "$"},
+		{Type: token.EOF},
+	})
+
+	t.Run("default rules", func(t *testing.T) {
+		sc := scanner.NewBuilder().WithIdentifierRules(nil, nil).Build([]byte("_a1 $b c$"))
+		assertLexerTokens(t, sc, []token.Token{
+			{Type: token.IDENT, Literal: "_a1"},
+			{Type: token.IDENT, Literal: "$b"},
+			{Type: token.IDENT, Literal: "c$"},
+			{Type: token.EOF},
+		})
+	})
+}
+
+func TestAcceptString(t *testing.T) {
+	sc := scanner.NewBuilder().Build([]byte("«»x"))
+	if sc.AcceptString("»") || sc.AcceptString("") {
+		t.Errorf("unexpected match")
+	}
+	if !sc.AcceptString("«»") {
+		t.Errorf("expected match")
+	}
+	if sc.CurrentChar() != 'x' {
+		t.Errorf("unexpected current char %q", sc.CurrentChar())
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	sc := scanner.NewBuilder().Build([]byte("«a\xffb"))
+	for _, expected := range []string{"«a\xffb", "a\xffb", "\xffb", "b", ""} {
+		if got := sc.Remaining(); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+		sc.AdvanceChar()
+	}
+	if got := sc.Remaining(); got != "" {
+		t.Errorf("expected no input after EOF, got %q", got)
+	}
+}
+
+func TestUseKeywords(t *testing.T) {
+	ifType := token.RegisterType("if")
+	elseType := token.RegisterType("else")
+	b := scanner.NewBuilder().UseKeywords(map[string]token.Type{
+		"if":   ifType,
+		"else": elseType,
+	})
+	input := "if iff else"
+	assertLexerTokens(t, b.Build([]byte(input)), []token.Token{
+		{Type: ifType, Literal: "if"},
+		{Type: token.IDENT, Literal: "iff"},
+		{Type: elseType, Literal: "else"},
+		{Type: token.EOF},
+	})
+
+	t.Run("remove keywords", func(t *testing.T) {
+		built := b.Build([]byte(input))
+		b.RemoveKeywords("else")
+		assertLexerTokens(t, b.Build([]byte(input)), []token.Token{
+			{Type: ifType, Literal: "if"},
+			{Type: token.IDENT, Literal: "iff"},
+			{Type: token.IDENT, Literal: "else"},
+			{Type: token.EOF},
+		})
+		// scanners built before the change keep their own keyword table
+		assertLexerTokens(t, built, []token.Token{
+			{Type: ifType, Literal: "if"},
+			{Type: token.IDENT, Literal: "iff"},
+			{Type: elseType, Literal: "else"},
+			{Type: token.EOF},
+		})
+	})
+}
@@ -1,6 +1,8 @@
 package scanner
 
 import (
+	"bytes"
+	"slices"
 	"strings"
 	"unicode/utf8"
 
@@ -14,7 +16,17 @@ type Scanner struct {
 	offset       int
 	line, column int
 	scanner      func(*Scanner) (token.Token, error)
+	keywords     map[string]token.Type
+	operators    []token.Token // sorted from longest to shortest
+	isIdentStart func(r rune) bool
+	isIdentPart  func(r rune) bool
+	errors       map[token.Position]error
+	maxTokens    int
+	numTokens    int
 	currentChar  rune
+	indentation  *indentation // see Builder.WithSignificantIndentation
+	macros       map[string][]token.Token
+	expansion    []token.Token // tokens of the last macro not read yet
 }
 
 func (sc *Scanner) init(input []byte) {
@@ -25,13 +37,29 @@ func (sc *Scanner) init(input []byte) {
 	sc.Reset()
 }
 
+// ResetInput starts scanning a new input, keeping the installed scanners and
+// keywords.
+func (sc *Scanner) ResetInput(input []byte) {
+	sc.init(input)
+}
+
 func (sc *Scanner) Fork() token.Scanner {
 	s := &Scanner{
-		input:       sc.input,
-		offset:      sc.offset,
-		line:        sc.line,
-		column:      sc.column,
-		currentChar: sc.currentChar,
+		input:        sc.input,
+		offset:       sc.offset,
+		line:         sc.line,
+		column:       sc.column,
+		keywords:     sc.keywords,
+		operators:    sc.operators,
+		isIdentStart: sc.isIdentStart,
+		isIdentPart:  sc.isIdentPart,
+		errors:       sc.errors,
+		maxTokens:    sc.maxTokens,
+		numTokens:    sc.numTokens,
+		currentChar:  sc.currentChar,
+		indentation:  sc.indentation.clone(),
+		macros:       sc.macros,
+		expansion:    slices.Clone(sc.expansion),
 	}
 	s.scanner = sc.scanner
 	if s.scanner == nil {
@@ -46,7 +74,10 @@ func (sc *Scanner) Apply(s token.Scanner) {
 		sc.offset = v.offset
 		sc.line = v.line
 		sc.column = v.column
+		sc.numTokens = v.numTokens
 		sc.currentChar = v.currentChar
+		sc.indentation = v.indentation.clone()
+		sc.expansion = slices.Clone(v.expansion)
 	default:
 		panic("*Scanner expected")
 	}
@@ -57,6 +88,12 @@ func (sc *Scanner) Reset() {
 		sc.scanner = defaultScanner
 	}
 	sc.offset = 0
+	sc.errors = make(map[token.Position]error)
+	sc.numTokens = 0
+	sc.expansion = nil
+	if sc.indentation != nil {
+		sc.indentation = &indentation{}
+	}
 	sc.currentChar = EOF
 	sc.line = 0
 	sc.column = -1
@@ -75,6 +112,48 @@ func (sc *Scanner) PeekChar() rune {
 	return EOF
 }
 
+// Remaining returns the input that hasn't been consumed yet, starting at the
+// current character, so scanners can match longer sequences before consuming
+// them. The result is a copy, so changing it doesn't affect the scanner.
+func (sc *Scanner) Remaining() string {
+	if sc.currentChar == EOF {
+		return ""
+	}
+	_, size := utf8.DecodeLastRune(sc.input[:sc.offset])
+	return string(sc.input[sc.offset-size:])
+}
+
+// IsIdentStart reports whether an identifier can start with r. See
+// Builder.WithIdentifierRules.
+func (sc *Scanner) IsIdentStart(r rune) bool {
+	if sc.isIdentStart == nil {
+		return IsLetter(r)
+	}
+	return sc.isIdentStart(r)
+}
+
+// IsIdentPart reports whether r can appear in an identifier after the first
+// character. See Builder.WithIdentifierRules.
+func (sc *Scanner) IsIdentPart(r rune) bool {
+	if sc.isIdentPart == nil {
+		return IsLetter(r) || IsDigit(r)
+	}
+	return sc.isIdentPart(r)
+}
+
+// AcceptString consumes lit if the input continues with it, starting at the
+// current character. It reports whether lit was consumed.
+func (sc *Scanner) AcceptString(lit string) bool {
+	r, size := utf8.DecodeRuneInString(lit)
+	if lit == "" || r != sc.currentChar || !bytes.HasPrefix(sc.input[sc.offset:], []byte(lit[size:])) {
+		return false
+	}
+	for range utf8.RuneCountInString(lit) {
+		sc.AdvanceChar()
+	}
+	return true
+}
+
 func (sc *Scanner) AdvanceChar() {
 	r, size := utf8.DecodeRune(sc.input[sc.offset:])
 	sc.offset += size
@@ -103,16 +182,28 @@ func (sc *Scanner) AdvanceChar() {
 }
 
 func (sc *Scanner) NextToken() token.Token {
+	if sc.indentation != nil {
+		if tok, ok := sc.indentation.pop(); ok {
+			return tok
+		}
+		return sc.indentation.tokens(sc, sc.expandedToken())
+	}
+	return sc.expandedToken()
+}
+
+func (sc *Scanner) nextToken() token.Token {
 	next := func() token.Token {
 		sc.skipWhitespaces()
 		line, column := sc.line, sc.column
 		tok, err := sc.scanner(sc)
-		// TODO: (medium) Scanner.NextToken converts scanner/middleware errors into token.ILLEGAL but discards the error value entirely. With the new middleware signature returning errors, callers still have no way to observe why a token is illegal other than inspecting Literal. Consider exposing the error (e.g., NextToken returning (token.Token, error) or storing the last error on Scanner) so downstream code can surface better diagnostics.
 		if err != nil {
 			tok.Type = token.ILLEGAL
 		}
 		tok.Line = line
 		tok.Column = max(0, column)
+		if err != nil {
+			sc.errors[tok.Position] = err
+		}
 		return tok
 	}
 	var trivia []token.Token
@@ -133,9 +224,42 @@ triviaLoop:
 	}
 	tok.LeadingTrivia = trivia
 	tok.AfterNewline = afterNewline
+	if sc.maxTokens > 0 && tok.Type != token.EOF {
+		sc.numTokens++
+		switch {
+		case sc.numTokens == sc.maxTokens+1:
+			tok.Type = token.ILLEGAL
+			sc.errors[tok.Position] = codedError{msg: "too many tokens", code: MaxTokensCode}
+		case sc.numTokens > sc.maxTokens+1:
+			tok = token.Token{Type: token.EOF, Position: tok.Position}
+		}
+	}
 	return tok
 }
 
+// MaxTokensCode is the code of the error reported for the token exceeding
+// the limit set with Builder.WithMaxTokens.
+const MaxTokensCode = "max-tokens"
+
+// codedError is an error that the parser reports with a code, see
+// parser.Error.
+type codedError struct {
+	msg, code string
+}
+
+func (err codedError) Error() string {
+	return err.msg
+}
+
+func (err codedError) ErrorCode() string {
+	return err.code
+}
+
+// TokenError returns the error that made the token at pos illegal, if any.
+func (sc *Scanner) TokenError(pos token.Position) error {
+	return sc.errors[pos]
+}
+
 func (sc *Scanner) skipWhitespaces() {
 	for sc.currentChar == ' ' || sc.currentChar == '\t' {
 		sc.AdvanceChar()
@@ -57,10 +57,23 @@ func ExampleBuilder_Build() {
 			tok.Literal, tok.Position)
 	}
 	// Output:
-	// {Type: hash, Literal: #, Position: {0 0}}
-	// {Type: identifier, Literal: some, Position: {0 1}}
-	// {Type: caret, Literal: ^, Position: {0 6}}
-	// {Type: identifier, Literal: input, Position: {0 7}}
+	// {Type: hash, Literal: #, Position: 0:0}
+	// {Type: identifier, Literal: some, Position: 0:1}
+	// {Type: caret, Literal: ^, Position: 0:6}
+	// {Type: identifier, Literal: input, Position: 0:7}
+}
+
+func TestMaxTokens(t *testing.T) {
+	sc := scanner.NewBuilder().WithMaxTokens(2).Build([]byte("a b /* c */ c d"))
+	testutil.AssertTokens(t, []token.Token{sc.NextToken(), sc.NextToken(), sc.NextToken(), sc.NextToken()}, []token.Token{
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.IDENT, Literal: "b"},
+		{Type: token.ILLEGAL, Literal: "c"},
+		{Type: token.EOF},
+	})
+	if err := sc.TokenError(token.Position{Line: 0, Column: 12}); err == nil || err.Error() != "too many tokens" {
+		t.Errorf("unexpected error %v", err)
+	}
 }
 
 func BenchmarkLexer(b *testing.B) {
@@ -124,6 +137,40 @@ func TestIdentifier(t *testing.T) {
 	})
 }
 
+func TestIdentifierEscapes(t *testing.T) {
+	input := `\u0061bc a\u{62}c \u{000063} \u{24}_ if \u0069f`
+	ifType := token.RegisterType("if")
+	sc := scanner.NewBuilder().UseKeywords(map[string]token.Type{"if": ifType}).Build([]byte(input))
+	assertLexerTokens(t, sc, []token.Token{
+		{Type: token.IDENT, Literal: "abc"},
+		{Type: token.IDENT, Literal: "abc"},
+		{Type: token.IDENT, Literal: "c"},
+		{Type: token.IDENT, Literal: "$_"},
+		{Type: ifType, Literal: "if"},
+		// escaped keywords are names, kept as written
+		{Type: token.IDENT, Literal: `\u0069f`},
+		{Type: token.EOF},
+	})
+
+	// the source text is kept for positions, see Token.End
+	tok := scanner.NewBuilder().Build([]byte(`a\u{62}c`)).NextToken()
+	if tok.Literal != "abc" || tok.Raw != `a\u{62}c` {
+		t.Errorf("unexpected literal %q and raw text %q", tok.Literal, tok.Raw)
+	}
+
+	for _, input := range []string{`\u00`, `\u{}`, `\u{61`, `\u{110000}`, `\uD800`, `\x61`, `a\u{2d}`, `\u{30}a`} {
+		sc := scanner.NewBuilder().Build([]byte(input))
+		tok := sc.NextToken()
+		if tok.Type != token.ILLEGAL {
+			t.Errorf("%s: expected illegal token, got %s %q", input, tok.Type, tok.Literal)
+			continue
+		}
+		if err := sc.TokenError(tok.Position); err == nil || err.Error() != "invalid escape sequence" {
+			t.Errorf("%s: unexpected error %v", input, err)
+		}
+	}
+}
+
 func TestTokenPosition(t *testing.T) {
 	input := " aaa   bbb /* block comment*/ ccc\n// comment\rddd\r\ne!\n"
 	assertInputTokens(t, input, []token.Token{
@@ -216,6 +263,167 @@ func TestAfterNewline(t *testing.T) {
 	}
 }
 
+func TestMiddlewareAfterNewline(t *testing.T) {
+	caretType := token.RegisterType("^")
+	sc := scanner.NewBuilder().
+		UseScanner(func(sc *scanner.Scanner, next func() (token.Token, error)) (token.Token, error) {
+			if sc.CurrentChar() == '^' {
+				sc.AdvanceChar()
+				return token.Token{Type: caretType, Literal: "^"}, nil
+			}
+			return next()
+		}).
+		Build([]byte("a ^\n^ // comment\n/* block\ncomment */ ^\n"))
+	assertLexerTokens(t, sc, []token.Token{
+		{Type: token.IDENT, Literal: "a"},
+		{Type: caretType, Literal: "^", Position: token.Position{Line: 0, Column: 2}},
+		{Type: caretType, Literal: "^", Position: token.Position{Line: 1, Column: 0}, AfterNewline: true},
+		{Type: caretType, Literal: "^", Position: token.Position{Line: 3, Column: 11}, AfterNewline: true},
+		{Type: token.EOF, Position: token.Position{Line: 4, Column: 0}, AfterNewline: true},
+	}, testutil.CompareAfterNewline(), testutil.CompareTokenPosition())
+}
+
+func TestSignificantIndentation(t *testing.T) {
+	input := "if x:\n  a = (1,\n2)\n  if y:\n    b\n  // comment\n\nc"
+	sc := scanner.NewBuilder().WithSignificantIndentation().Build([]byte(input))
+	assertLexerTokens(t, sc, []token.Token{
+		{Type: token.IDENT, Literal: "if"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.NEWLINE},
+		{Type: token.INDENT, Position: token.Position{Line: 1, Column: 2}},
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.NUMBER, Literal: "1"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.NUMBER, Literal: "2"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.NEWLINE},
+		{Type: token.IDENT, Literal: "if"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.NEWLINE},
+		{Type: token.INDENT, Position: token.Position{Line: 4, Column: 4}},
+		{Type: token.IDENT, Literal: "b"},
+		{Type: token.NEWLINE},
+		{Type: token.DEDENT, Position: token.Position{Line: 7, Column: 0}},
+		{Type: token.DEDENT, Position: token.Position{Line: 7, Column: 0}},
+		{Type: token.IDENT, Literal: "c"},
+		{Type: token.NEWLINE},
+		{Type: token.EOF},
+	})
+
+	t.Run("closes the levels at the end", func(t *testing.T) {
+		sc := scanner.NewBuilder().WithSignificantIndentation().Build([]byte("a\n  b\n    c\n"))
+		assertLexerTokens(t, sc, []token.Token{
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.NEWLINE},
+			{Type: token.INDENT},
+			{Type: token.IDENT, Literal: "b"},
+			{Type: token.NEWLINE},
+			{Type: token.INDENT},
+			{Type: token.IDENT, Literal: "c"},
+			{Type: token.NEWLINE},
+			{Type: token.DEDENT},
+			{Type: token.DEDENT},
+			{Type: token.EOF},
+		})
+	})
+
+	t.Run("inconsistent indentation", func(t *testing.T) {
+		sc := scanner.NewBuilder().WithSignificantIndentation().Build([]byte("a\n    b\n  c"))
+		assertLexerTokens(t, sc, []token.Token{
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.NEWLINE},
+			{Type: token.INDENT},
+			{Type: token.IDENT, Literal: "b"},
+			{Type: token.NEWLINE},
+			{Type: token.DEDENT},
+			{Type: token.ILLEGAL, Literal: "c"},
+			{Type: token.NEWLINE},
+			{Type: token.EOF},
+		})
+		if err := sc.TokenError(token.Position{Line: 2, Column: 2}); err == nil || err.Error() != "unindent does not match any outer indentation level" {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("whitespace is insignificant by default", func(t *testing.T) {
+		assertInputTokens(t, "a\n  b", []token.Token{
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.IDENT, Literal: "b"},
+			{Type: token.EOF},
+		})
+	})
+}
+
+func TestMacros(t *testing.T) {
+	ident := func(lit string) token.Token {
+		return token.Token{Type: token.IDENT, Literal: lit}
+	}
+	b := scanner.NewBuilder().UseMacros(map[string][]token.Token{
+		"PI":    {{Type: token.NUMBER, Literal: "3.14"}},
+		"TAU":   {{Type: token.LPAREN, Literal: "("}, {Type: token.NUMBER, Literal: "2"}, {Type: token.MULTIPLY, Literal: "*"}, ident("PI"), {Type: token.RPAREN, Literal: ")"}},
+		"EMPTY": nil,
+		"LOOP":  {ident("x"), ident("LOOP")},
+	})
+	sc := b.Build([]byte("x = TAU\nEMPTY /* c */ y = PI.z"))
+	assertLexerTokens(t, sc, []token.Token{
+		{Type: token.IDENT, Literal: "x", Position: token.Position{Line: 0, Column: 0}},
+		{Type: token.ASSIGN, Literal: "=", Position: token.Position{Line: 0, Column: 2}},
+		{Type: token.LPAREN, Literal: "(", Position: token.Position{Line: 0, Column: 4}},
+		{Type: token.NUMBER, Literal: "2", Position: token.Position{Line: 0, Column: 4}},
+		{Type: token.MULTIPLY, Literal: "*", Position: token.Position{Line: 0, Column: 4}},
+		{Type: token.NUMBER, Literal: "3.14", Position: token.Position{Line: 0, Column: 4}},
+		{Type: token.RPAREN, Literal: ")", Position: token.Position{Line: 0, Column: 4}},
+		{Type: token.IDENT, Literal: "y", Position: token.Position{Line: 1, Column: 14}, AfterNewline: true},
+		{Type: token.ASSIGN, Literal: "=", Position: token.Position{Line: 1, Column: 16}},
+		{Type: token.NUMBER, Literal: "3.14", Position: token.Position{Line: 1, Column: 18}},
+		{Type: token.DOT, Literal: ".", Position: token.Position{Line: 1, Column: 20}},
+		{Type: token.IDENT, Literal: "z", Position: token.Position{Line: 1, Column: 21}},
+		{Type: token.EOF, Position: token.Position{Line: 1, Column: 21}},
+	}, testutil.CompareTokenPosition(), testutil.CompareAfterNewline())
+
+	t.Run("the comments of empty expansions are kept", func(t *testing.T) {
+		sc := b.Build([]byte("// a\nEMPTY /* b */ y"))
+		tok := sc.NextToken()
+		if tok.Literal != "y" || !tok.AfterNewline || len(tok.LeadingTrivia) != 2 || tok.LeadingTrivia[1].Literal != "/* b */" {
+			t.Errorf("unexpected token %v", tok)
+		}
+	})
+
+	t.Run("recursive macros", func(t *testing.T) {
+		sc := b.Build([]byte("a LOOP b"))
+		assertLexerTokens(t, sc, []token.Token{
+			ident("a"),
+			{Type: token.ILLEGAL, Literal: "LOOP"},
+			ident("b"),
+			{Type: token.EOF},
+		})
+		if err := sc.TokenError(token.Position{Line: 0, Column: 2}); err == nil || err.Error() != "macro expansion too deep" {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("forks", func(t *testing.T) {
+		sc := b.Build([]byte("TAU"))
+		sc.NextToken()
+		fork := sc.Fork()
+		assertLexerTokens(t, fork.(*scanner.Scanner), []token.Token{
+			{Type: token.NUMBER, Literal: "2"},
+			{Type: token.MULTIPLY, Literal: "*"},
+			{Type: token.NUMBER, Literal: "3.14"},
+			{Type: token.RPAREN, Literal: ")"},
+			{Type: token.EOF},
+		})
+		sc.Apply(fork)
+		if tok := sc.NextToken(); tok.Type != token.EOF {
+			t.Errorf("unexpected token %v", tok)
+		}
+	})
+}
+
 func TestBlockComments(t *testing.T) {
 	input := "/* lorem\nipsum dolor */\n\rhello\r\n/* unfinished comment"
 	assertInputTokens(t, input, []token.Token{
@@ -297,7 +505,7 @@ func TestScanContinuesAfterNullCharacter(t *testing.T) {
 }
 
 func TestPunctuators(t *testing.T) {
-	assertInputTokens(t, "; = == ! != < <= > >= () {} + ++ - -- * / % && || | &", []token.Token{
+	assertInputTokens(t, "; = == ! != < <= > >= () {} + ++ - -- * / % && || | & ? :", []token.Token{
 		{Type: token.SEMICOLON, Literal: ";"},
 		{Type: token.ASSIGN, Literal: "="},
 		{Type: token.EQ, Literal: "=="},
@@ -322,8 +530,25 @@ func TestPunctuators(t *testing.T) {
 		{Type: token.OR, Literal: "||"},
 		{Type: token.UNKNOWN, Literal: "|"},
 		{Type: token.UNKNOWN, Literal: "&"},
+		{Type: token.QUESTION, Literal: "?"},
+		{Type: token.COLON, Literal: ":"},
 		{Type: token.EOF},
 	})
+
+	t.Run("arrow", func(t *testing.T) {
+		assertInputTokens(t, "=> >= == = > =>= ==>", []token.Token{
+			{Type: token.ARROW, Literal: "=>"},
+			{Type: token.GTE, Literal: ">="},
+			{Type: token.EQ, Literal: "=="},
+			{Type: token.ASSIGN, Literal: "="},
+			{Type: token.GT, Literal: ">"},
+			{Type: token.ARROW, Literal: "=>"},
+			{Type: token.ASSIGN, Literal: "="},
+			{Type: token.EQ, Literal: "=="},
+			{Type: token.GT, Literal: ">"},
+			{Type: token.EOF},
+		})
+	})
 }
 
 func TestSkipWhitespaces(t *testing.T) {
@@ -356,6 +581,40 @@ func TestReadNumber(t *testing.T) {
 		{Type: token.NUMBER, Literal: "0o7"},
 		{Type: token.EOF},
 	})
+
+	t.Run("member access", func(t *testing.T) {
+		// "5.toString()" is the number "5." followed by an identifier
+		assertInputTokens(t, "5 .a 5..a 0.5.a 1.5.a 5.a", []token.Token{
+			{Type: token.NUMBER, Literal: "5"},
+			{Type: token.DOT, Literal: "."},
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.NUMBER, Literal: "5."},
+			{Type: token.DOT, Literal: "."},
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.NUMBER, Literal: "0.5"},
+			{Type: token.DOT, Literal: "."},
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.NUMBER, Literal: "1.5"},
+			{Type: token.DOT, Literal: "."},
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.NUMBER, Literal: "5."},
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.EOF},
+		})
+	})
+
+	t.Run("bigint", func(t *testing.T) {
+		assertInputTokens(t, "123n 0n 0xFFn 0o7n 1.5n 0123n 1e3n", []token.Token{
+			{Type: token.BIGINT, Literal: "123n"},
+			{Type: token.BIGINT, Literal: "0n"},
+			{Type: token.BIGINT, Literal: "0xFFn"},
+			{Type: token.BIGINT, Literal: "0o7n"},
+			{Type: token.ILLEGAL, Literal: "1.5n"},
+			{Type: token.ILLEGAL, Literal: "0123n"},
+			{Type: token.ILLEGAL, Literal: "1e3n"},
+			{Type: token.EOF},
+		})
+	})
 }
 
 func TestReadString(t *testing.T) {
@@ -367,6 +626,25 @@ func TestReadString(t *testing.T) {
 			{Type: token.EOF},
 		})
 	})
+	t.Run("escapes are kept", func(t *testing.T) {
+		assertInputTokens(t, `'it\'s' "\u0041\n" `+"`\\${a}`", []token.Token{
+			{Type: token.STRING, Literal: `'it\'s'`},
+			{Type: token.STRING, Literal: `"\u0041\n"`},
+			{Type: token.STRING, Literal: "`\\${a}`"},
+			{Type: token.EOF},
+		})
+	})
+	t.Run("template substitutions", func(t *testing.T) {
+		assertInputTokens(t, "`a ${`b ${c}`} ${{d: '}'}.d} \\``;", []token.Token{
+			{Type: token.STRING, Literal: "`a ${`b ${c}`} ${{d: '}'}.d} \\``"},
+			{Type: token.SEMICOLON, Literal: ";"},
+			{Type: token.EOF},
+		})
+		assertInputTokens(t, "`a ${b", []token.Token{
+			{Type: token.ILLEGAL, Literal: "`a ${b"},
+			{Type: token.EOF},
+		})
+	})
 	t.Run("illegal string", func(t *testing.T) {
 		inputs := []string{
 			"'Hello, World",  // missing '
@@ -399,4 +677,31 @@ func TestReadString(t *testing.T) {
 			}
 		}
 	})
+	t.Run("line continuation", func(t *testing.T) {
+		for _, delimiter := range []string{"'", "\""} {
+			for _, terminator := range []string{"\n", "\r", "\r\n"} {
+				lit := delimiter + "Hello\\" + terminator + "World" + delimiter
+				assertInputTokens(t, lit+";", []token.Token{
+					{Type: token.STRING, Literal: lit},
+					{Type: token.SEMICOLON, Literal: ";"},
+					{Type: token.EOF},
+				})
+			}
+		}
+	})
+	t.Run("illegal string error", func(t *testing.T) {
+		for _, input := range []string{"'Hello", "\"Hello", "`Hello"} {
+			sc := scanner.NewBuilder().Build([]byte("x = " + input))
+			tok := sc.NextToken()
+			for ; tok.Type != token.ILLEGAL && tok.Type != token.EOF; tok = sc.NextToken() {
+			}
+			err := sc.TokenError(tok.Position)
+			if err == nil || err.Error() != "unterminated string literal" {
+				t.Errorf("%s: unexpected error %v", input, err)
+			}
+			if err := sc.TokenError(token.Position{}); err != nil {
+				t.Errorf("%s: unexpected error %v", input, err)
+			}
+		}
+	})
 }
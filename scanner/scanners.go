@@ -3,17 +3,103 @@ package scanner
 import (
 	"errors"
 	"strings"
+	"unicode/utf8"
 )
 
+var errInvalidEscape = errors.New("invalid escape sequence")
+
+// ScanIdentifier scans an identifier, starting at its first character.
+// Unlike the default scanner, it doesn't read escape sequences.
 func ScanIdentifier(sc *Scanner) string {
 	sb := strings.Builder{}
 	sb.WriteRune(sc.currentChar)
-	for sc.AdvanceChar(); IsLetter(sc.currentChar) || IsDigit(sc.currentChar); sc.AdvanceChar() {
+	for sc.AdvanceChar(); sc.IsIdentPart(sc.currentChar); sc.AdvanceChar() {
 		sb.WriteRune(sc.currentChar)
 	}
 	return sb.String()
 }
 
+// scanEscapedIdentifier scans an identifier that may contain \uXXXX and
+// \u{X...} escapes, as in `\u{61}bc`. It returns the identifier with its
+// escapes decoded, as well as its spelling, which differ if there were any.
+func scanEscapedIdentifier(sc *Scanner) (name, spelling string, err error) {
+	var sb, raw strings.Builder
+	for start := true; ; start = false {
+		r := sc.currentChar
+		isIdent := sc.IsIdentPart
+		if start {
+			isIdent = sc.IsIdentStart
+		}
+		if r == '\\' {
+			raw.WriteRune(r)
+			if r, err = scanIdentifierEscape(sc, &raw); err != nil {
+				return sb.String(), raw.String(), err
+			}
+			if !isIdent(r) {
+				return sb.String(), raw.String(), errInvalidEscape
+			}
+		} else if isIdent(r) {
+			raw.WriteRune(r)
+			sc.AdvanceChar()
+		} else {
+			break
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String(), raw.String(), nil
+}
+
+// scanIdentifierEscape decodes the escape sequence starting at the current
+// backslash, whose other characters are written to raw.
+func scanIdentifierEscape(sc *Scanner, raw *strings.Builder) (rune, error) {
+	sc.AdvanceChar()
+	if sc.currentChar != 'u' {
+		return 0, errInvalidEscape
+	}
+	raw.WriteRune(sc.currentChar)
+	sc.AdvanceChar()
+	braces := sc.currentChar == '{'
+	if braces {
+		raw.WriteRune(sc.currentChar)
+		sc.AdvanceChar()
+	}
+	n, digits := rune(0), 0
+	for IsHexDigit(sc.currentChar) && (braces || digits < 4) {
+		// past the largest code point, only the digits are read
+		if n <= utf8.MaxRune {
+			n = n<<4 | hexDigitValue(sc.currentChar)
+		}
+		digits++
+		raw.WriteRune(sc.currentChar)
+		sc.AdvanceChar()
+	}
+	if braces {
+		if digits == 0 || sc.currentChar != '}' {
+			return 0, errInvalidEscape
+		}
+		raw.WriteRune(sc.currentChar)
+		sc.AdvanceChar()
+	} else if digits < 4 {
+		return 0, errInvalidEscape
+	}
+	if !utf8.ValidRune(n) {
+		// too large, or a surrogate
+		return 0, errInvalidEscape
+	}
+	return n, nil
+}
+
+func hexDigitValue(r rune) rune {
+	switch {
+	case IsDigit(r):
+		return r - '0'
+	case r >= 'a' && r <= 'f':
+		return r - 'a' + 10
+	default:
+		return r - 'A' + 10
+	}
+}
+
 func ScanLineComment(sc *Scanner) string {
 	sb := strings.Builder{}
 	sb.WriteString("//")
@@ -52,7 +138,7 @@ func ScanBlockComment(sc *Scanner) (string, error) {
 			}
 			break
 		} else if sc.currentChar == EOF {
-			return sb.String(), errors.New("unexpected end of file")
+			return sb.String(), errors.New("unterminated comment")
 		}
 		sb.WriteRune(sc.currentChar)
 		sc.AdvanceChar()
@@ -72,13 +158,28 @@ func ScanString(sc *Scanner, delimiter rune) (string, error) {
 				sc.AdvanceChar()
 				continue
 			}
+			// line continuation
+			if sc.currentChar == '\r' {
+				sb.WriteRune(sc.currentChar)
+				sc.AdvanceChar()
+				if sc.currentChar == '\n' {
+					sb.WriteRune(sc.currentChar)
+					sc.AdvanceChar()
+				}
+				continue
+			}
+			if sc.currentChar == '\n' {
+				sb.WriteRune(sc.currentChar)
+				sc.AdvanceChar()
+				continue
+			}
 		}
 		if sc.currentChar == delimiter {
 			sb.WriteRune(sc.currentChar)
 			sc.AdvanceChar()
 			break
 		} else if sc.currentChar == EOF || sc.currentChar == '\n' || sc.currentChar == '\r' {
-			return sb.String(), errors.New("unexpected end of line")
+			return sb.String(), errors.New("unterminated string literal")
 		}
 		sb.WriteRune(sc.currentChar)
 		sc.AdvanceChar()
@@ -86,16 +187,40 @@ func ScanString(sc *Scanner, delimiter rune) (string, error) {
 	return sb.String(), nil
 }
 
+// ScanRawString scans a template literal, whose opening backtick has
+// already been consumed. Substitutions are scanned up to their closing
+// brace, so they may contain strings and template literals of their own.
 func ScanRawString(sc *Scanner) (string, error) {
 	sb := strings.Builder{}
 	sb.WriteRune('`')
 	for {
+		if sc.currentChar == '\\' {
+			sb.WriteRune(sc.currentChar)
+			sc.AdvanceChar()
+			if sc.currentChar == EOF {
+				return sb.String(), errors.New("unterminated string literal")
+			}
+			sb.WriteRune(sc.currentChar)
+			sc.AdvanceChar()
+			continue
+		}
+		if sc.currentChar == '$' && sc.PeekChar() == '{' {
+			sb.WriteString("${")
+			sc.AdvanceChar()
+			sc.AdvanceChar()
+			lit, err := scanSubstitution(sc)
+			sb.WriteString(lit)
+			if err != nil {
+				return sb.String(), err
+			}
+			continue
+		}
 		if sc.currentChar == '`' {
 			sb.WriteRune(sc.currentChar)
 			sc.AdvanceChar()
 			break
 		} else if sc.currentChar == EOF {
-			return sb.String(), errors.New("unexpected end of file")
+			return sb.String(), errors.New("unterminated string literal")
 		}
 		sb.WriteRune(sc.currentChar)
 		sc.AdvanceChar()
@@ -103,6 +228,45 @@ func ScanRawString(sc *Scanner) (string, error) {
 	return sb.String(), nil
 }
 
+// scanSubstitution scans the expression of a template substitution, up to
+// and including its closing brace.
+func scanSubstitution(sc *Scanner) (string, error) {
+	sb := strings.Builder{}
+	depth := 0
+	for {
+		switch c := sc.currentChar; c {
+		case EOF:
+			return sb.String(), errors.New("unterminated template substitution")
+		case '{':
+			depth++
+		case '}':
+			sb.WriteRune(c)
+			sc.AdvanceChar()
+			if depth == 0 {
+				return sb.String(), nil
+			}
+			depth--
+			continue
+		case '\'', '"', '`':
+			sc.AdvanceChar()
+			var lit string
+			var err error
+			if c == '`' {
+				lit, err = ScanRawString(sc)
+			} else {
+				lit, err = ScanString(sc, c)
+			}
+			sb.WriteString(lit)
+			if err != nil {
+				return sb.String(), err
+			}
+			continue
+		}
+		sb.WriteRune(sc.currentChar)
+		sc.AdvanceChar()
+	}
+}
+
 func ScanHexNumber(sc *Scanner) (string, error) {
 	sb := strings.Builder{}
 	sb.WriteRune(sc.currentChar)
@@ -138,8 +302,11 @@ func ScanNumber(sc *Scanner) (string, error) {
 			sb.WriteRune(sc.currentChar)
 		}
 	}
-	sb.WriteRune(sc.currentChar)
-	readDigits()
+	if IsDigit(sc.currentChar) {
+		sb.WriteRune(sc.currentChar)
+		readDigits()
+	}
+	// a single decimal point, so "1.5.toFixed()" is a member access
 	if sc.currentChar == '.' {
 		sb.WriteRune(sc.currentChar)
 		readDigits()
@@ -67,3 +67,12 @@ func TestScanNumber(t *testing.T) {
 		}
 	})
 }
+
+func TestIsIdentifier(t *testing.T) {
+	for _, s := range []string{"a", "_", "$", "abc123", "_$a1"} {
+		assert.True(t, scanner.IsIdentifier(s), s)
+	}
+	for _, s := range []string{"", "1a", "a-b", "a b", "ñ"} {
+		assert.False(t, scanner.IsIdentifier(s), s)
+	}
+}
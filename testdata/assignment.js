@@ -30,3 +30,11 @@ console.log(z);
 // Grouped expressions
 let result = (x + 5) * 2;
 console.log(result);
+
+// Member assignment
+obj.key = value;
+obj[key] = value;
+a.b[c].d = e;
+a[b][c + 1] = f(x)[0];
+a.b = c.d = e[f];
+a /* c1 */[ /* c2 */key /* c3 */] /* c4 */ = 1;
@@ -6,4 +6,10 @@ let x = async () => {
 let y = /*c1*/ async () => {
   //c2
   await fetchData();
-};
\ No newline at end of file
+};
+let z = async function () {
+  for await (const chunk of stream) {
+    process(chunk);
+  }
+  for /*c3*/ await /*c4*/ (let line of lines);
+};
@@ -0,0 +1,4 @@
+let big = 9007199254740993n;
+let mask = 0xFFn * factor;
+let small = 0n;
+let sum = big + 1n;
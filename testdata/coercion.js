@@ -22,3 +22,11 @@ console.log(str == "5");
 console.log(bool == true);
 console.log(num != str);
 console.log(num != 10);
+
+console.log("Unary coercions:");
+console.log(!!str);
+console.log(+str);
+console.log(- -str);
+console.log(+ +str);
+console.log(-+str);
+console.log(!!+emptyStr);
@@ -0,0 +1,5 @@
+debugger;
+function f(a) {
+  if (a) debugger;
+  debugger; // pause here
+}
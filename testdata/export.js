@@ -12,3 +12,20 @@ export //c
   b, c
 // c
 } /*c*/;
+
+// re-exports
+export { a, b as c } from "./other";
+export * from "./other";
+export * as other from "./other";
+export /*c1*/ * /*c2*/ as /*c3*/ lib /*c4*/ from /*c5*/ './lib' /*c6*/;
+export { d } /*c7*/ from /*c8*/ './lib';
+
+// default exports
+export default 42;
+export default function () {
+  return 1;
+}
+export default function named(a, b) {
+  return a + b;
+}
+export default /*c1*/ { a: 1 } /*c2*/;
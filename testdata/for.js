@@ -94,3 +94,16 @@ let numbers = [10, 20, 30];
 for (let k = 0; k < 3; k++) {
   console.log("array[" + k + "] = " + numbers[k]);
 }
+
+// multiple declarations and updates
+for (let i = 0, j = 10; i < j; i++, j--) {
+  console.log(i, j);
+}
+
+// function scoped and constant declarations
+for (var i = 0; i < 3; i++) {
+  setTimeout(() => console.log(i));
+}
+for (const start = Date.now(); Date.now() - start < 10;);
+
+for (;;);
@@ -0,0 +1,20 @@
+// const, let, var
+for (const key in obj);
+for (let key in obj);
+for (var key in obj);
+
+// left-hand side expressions
+for (key in obj);
+for (a.b in obj);
+for (a[i] in obj);
+
+// in operator
+if ('a' in obj) {
+  console.log(!(key in cache));
+}
+
+// parenthesized in operators in classic for loops
+for (let i = ('a' in obj) ? 1 : 0, keys = [key in obj]; i < 3; i++) {
+  f(x in y);
+}
+for (let i = 0; i in list; i++);
@@ -12,6 +12,10 @@ for (const row of rows.get()) {
 for (let { a, b } of rows);
 for (let [a, b] of rows);
 
+// left-hand side expressions
+for (row of rows);
+for ([a, b] of rows);
+
 // with indentation
 for (
   const row
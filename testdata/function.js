@@ -18,6 +18,14 @@ let multiply = function (a, b) {
   return a * b;
 };
 
+// named function expressions can call themselves
+let factorial = function fact(n) {
+  return n <= 1 ? 1 : n * fact(n - 1);
+};
+let timer = setTimeout(function /*c1*/ retry() {
+  setTimeout(retry, 100);
+}, 100);
+
 let result1 = multiply(4, 5);
 console.log(result1);
 
@@ -71,3 +79,11 @@ function foo() {
 }
 
 foo();
+
+// destructured parameters
+function point({ x, y }, [first, , third]) {
+  return x + y + first + third;
+}
+let norm = function ({ v: [a, b] }) {
+  return a * a + b * b;
+};
@@ -17,3 +17,29 @@ if (one) {
 } else if (two) {
   console.log("bbb");
 } else console.log("ccc");
+
+// brace-less bodies
+if (a) b(); else c();
+if (a) if (b) c(); else d();
+if (a) b(); else if (c) d(); else e();
+if (a)
+  b();
+else
+  c();
+
+
+// mixed braced and brace-less branches
+if (a) b(); else {
+  c();
+}
+if (a) {} else c();
+if (a) {
+  b();
+} else if (c) d(); else {
+  e();
+}
+if (a) b(); else if (c) {
+  d();
+} else e();
+
+if (a); else;
@@ -0,0 +1,17 @@
+search: {
+  for (let i = 0; i < n; i++) {
+    if (found(i)) break search;
+  }
+  notFound();
+}
+
+function f() {
+  // labels can be nested in blocks
+  check: {
+    if (!a) break check;
+    run(a);
+  }
+  outer: inner: while (x) {
+    continue outer;
+  }
+}
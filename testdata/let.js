@@ -2,3 +2,8 @@ let x = 100; // x coordinate
 let y = 200; // y coordinate
 let z = !true;
 let w = {};
+
+// multiple declarations
+let a = 1, b = 2;
+const c = 3, { d } = obj;
+var e, f = 4;
@@ -0,0 +1,8 @@
+a ??= b;
+a.b ||= c;
+a[b] &&= c;
+a.b ??= c?.d ?? e;
+x ||= y &&= z;
+
+// with comments
+a /* c1 */ ??= /* c2 */ b;
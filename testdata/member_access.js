@@ -46,3 +46,9 @@ foo.bar;
 a.b.c;
 a.
 b;
+
+// numeric literals
+let s1 = 5 .toString();
+let s2 = 5..toString();
+let s3 = (5).toString();
+let s4 = 0.5.toFixed(1);
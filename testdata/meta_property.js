@@ -0,0 +1,7 @@
+function Foo() {
+  if (!new.target) throw new Error("use new");
+}
+
+console.log(import.meta.url);
+import.meta.url;
+let url = new URL("data.json", import.meta.url);
@@ -2,6 +2,11 @@ new Foo;
 new Foo();
 new Foo(1, 2);
 new function () {};
+new a.b.C;
+new C().x;
+new C.D().e();
+new new C()();
+new (f())();
 
 // these errors are syntactically valid
 // although they are not semantically valid
@@ -0,0 +1,5 @@
+let a = b ?? c;
+let d = e?.f ?? "default";
+let g = h ?? i ?? j;
+let k = (l || m) ?? n;
+let o = p ?? (q && r);
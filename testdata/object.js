@@ -33,3 +33,19 @@ let x = {
   ['age']: 32,
   3.14: 'PI approx.'
 };
+
+// spread entries keep their order
+let options = { ...defaults, override: 1, ...user.settings, ...load(), last: true };
+
+// computed keys and spread values can be any expression
+let computed = { [prefix + "id"]: 1, [key()]: get, [`k${i}`]: 3, ...(flag ? a : b), ...[1, 2] };
+
+// comments around commas and colons
+let commented = {
+  a /* key */: 1 /* before comma */,
+  b: {
+    c: 2, // two
+    d: [3] // three
+  }, // after b
+  e /* shorthand */
+};
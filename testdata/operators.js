@@ -24,3 +24,8 @@ let y = false;
 console.log(x && y); // false
 console.log(x || y); // true
 console.log(!x); // false
+
+// Exponentiation (right-associative)
+console.log(2 ** 3 ** 2); // 512
+console.log((-2) ** 2); // 4
+console.log(2 ** -1); // 0.5
@@ -4,4 +4,8 @@ fn?.(a, b);
 fn?.();
 
 // with comments
-a /*c*/?.b;
\ No newline at end of file
+a /*c*/?.b;
+
+// chains
+a?.b.c?.[d]?.(e)(f);
+obj?.if;
@@ -8,3 +8,14 @@ function foo() {
 function boo() {
   return i + 2;
 }
+
+function sum(a, b, c) {
+  return a +
+    b +
+    c;
+}
+
+function isValid(x) {
+  return x > 0 && // positive
+    x < 10;
+}
@@ -2,4 +2,9 @@
 "double \"quoted\" 'string'";
 `back
 ${"quoted"}
-${'string'}`;
\ No newline at end of file
+${'string'}`;
+// line continuation
+let continued = "Hello, \
+World";
+let greeting = `Hello ${name}, you are ${age + 1}`;
+let nested = `items: ${items.map((item) => `<li>${item}</li>`).join('')}`;
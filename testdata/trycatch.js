@@ -34,3 +34,15 @@ try {
 } finally /*c4*/ {
   console.log("cleanup");
 }
+
+// destructuring
+try {
+  openFile();
+} catch ({ message, code = 0 }) {
+  console.log(message, code);
+}
+try {
+  openFile();
+} catch ([first, ...rest]) {
+  console.log(first, rest);
+}
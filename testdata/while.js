@@ -10,3 +10,11 @@ while (cond) {
   console.log("hello!");
 }
 console.log("hello!");
+
+while (a) b();
+while (a)
+  b();
+
+// empty bodies
+while (busy());
+while (busy()) /* wait */;
@@ -1,6 +1,8 @@
 package token
 
 import (
+	"maps"
+	"slices"
 	"strconv"
 	"sync"
 )
@@ -10,6 +12,11 @@ type ForkableScanner interface {
 	Apply(Scanner)
 }
 
+// ErrorScanner reports why the token at a given position is illegal.
+type ErrorScanner interface {
+	TokenError(pos Position) error
+}
+
 type Scanner interface {
 	NextToken() Token
 }
@@ -26,17 +33,92 @@ func (tt Type) String() string {
 	return lit
 }
 
+// Spelling returns the source text of a fixed token, such as "+" for PLUS or
+// "=>" for ARROW. Types whose text varies, like
+// IDENT or NUMBER, return an empty string.
+//
+// Unlike String, which names the type in messages, Spelling never returns a
+// description such as "end of file".
+func (tt Type) Spelling() string {
+	if tt >= ASSIGN && tt <= RBRACKET || tt == ARROW || tt == QUESTION || tt >= initCustomType {
+		registerMu.RLock()
+		defer registerMu.RUnlock()
+		return tokenLiterals[tt]
+	}
+	return ""
+}
+
 type Position struct {
 	Line   int `json:"line"`
 	Column int `json:"column"`
 }
 
+// String returns the position as "line:column". Both are zero-based, as in
+// parser errors.
+func (pos Position) String() string {
+	return strconv.Itoa(pos.Line) + ":" + strconv.Itoa(pos.Column)
+}
+
+// Before reports whether pos comes before other.
+func (pos Position) Before(other Position) bool {
+	return pos.Line < other.Line || pos.Line == other.Line && pos.Column < other.Column
+}
+
+// After reports whether pos comes after other.
+func (pos Position) After(other Position) bool {
+	return other.Before(pos)
+}
+
+// Equal reports whether pos and other are the same position.
+func (pos Position) Equal(other Position) bool {
+	return pos == other
+}
+
 type Token struct {
 	Position
-	Type          Type
-	Literal       string
+	Type Type
+	// Literal is the source text of the token, as written: strings keep
+	// their quotes and escape sequences, and numbers their original
+	// spelling. See js.Literal.StringValue for the decoded value of strings.
+	// Identifiers are the exception: their escapes, as in `\u{61}`, are
+	// decoded, so the literal is the name they refer to, and Raw keeps the
+	// source text.
+	Literal string
+	// Raw is the source text of the token if it differs from Literal, as
+	// for identifiers with escapes, and empty otherwise.
+	Raw string
+	// LeadingTrivia holds the comments and line breaks before the token.
 	LeadingTrivia []Token
-	AfterNewline  bool
+	// AfterNewline reports whether a line break comes before the token,
+	// which ends statements without semicolons. Like the position and the
+	// leading trivia, it's set by the scanner for every token it returns,
+	// including those built by scanner middlewares, so custom tokens
+	// needn't set it.
+	AfterNewline bool
+}
+
+// End returns the position right after the token. Tokens whose literal spans
+// lines, such as template literals and block comments, end on a later line
+// than they start. The source text is measured, so the escapes of
+// identifiers count, see Raw.
+func (tok Token) End() Position {
+	end := tok.Position
+	prev := rune(0)
+	text := tok.Literal
+	if tok.Raw != "" {
+		text = tok.Raw
+	}
+	for _, r := range text {
+		switch {
+		case r == '\r', r == '\n' && prev != '\r':
+			end.Line++
+			end.Column = 0
+		case r != '\n':
+			end.Column++
+		}
+		prev = r
+	}
+	return end
 }
 
 const (
@@ -83,6 +165,14 @@ const (
 	LINE_COMMENT  // // ..
 	BLOCK_COMMENT // /* .. */
 	STRING        // '..' or ".."
+	BIGINT        // 123n
+	// types added later go last, so that the values of the others don't
+	// change
+	ARROW // =>
+	// indentation, see scanner.Builder.WithSignificantIndentation
+	INDENT
+	DEDENT
+	QUESTION // ?
 )
 
 var tokenLiterals = map[Type]string{
@@ -129,6 +219,13 @@ var tokenLiterals = map[Type]string{
 	BLOCK_COMMENT: "block comment",
 	STRING:        "string",
 	NUMBER:        "number",
+	BIGINT:        "bigint",
+	ARROW:         "=>",
+	// indentation
+	INDENT: "indent",
+	DEDENT: "dedent",
+	// delimiters
+	QUESTION: "?",
 }
 
 const initCustomType Type = 1000
@@ -147,6 +244,14 @@ func RegisterType(lit string) Type {
 	return typ
 }
 
+// Types returns the built-in and registered token types, in ascending
+// order.
+func Types() []Type {
+	registerMu.RLock()
+	defer registerMu.RUnlock()
+	return slices.Sorted(maps.Keys(tokenLiterals))
+}
+
 var binaryOps = map[Type]int{
 	// =
 	ASSIGN: 1,
@@ -170,11 +275,13 @@ var binaryOps = map[Type]int{
 	DIVIDE:   7,
 	MODULO:   7,
 	// ( [ . ++ --
-	LPAREN:    8,
-	LBRACKET:  8,
-	DOT:       8,
-	INCREMENT: 8,
-	DECREMENT: 8,
+	// 8 is left to the prefix operators, and the operators that bind like
+	// them, such as ** in dialects that have it
+	LPAREN:    9,
+	LBRACKET:  9,
+	DOT:       9,
+	INCREMENT: 9,
+	DECREMENT: 9,
 }
 
 func (typ Type) IsBinaryOp() (ok bool) {
@@ -219,3 +326,56 @@ func RegisterUnaryType(typ Type) {
 	defer registerMu.Unlock()
 	unaryTypes[typ] = true
 }
+
+var keywordTypes = map[Type]bool{}
+
+// IsKeyword reports whether the type was registered as a keyword, such as
+// "let" or "typeof". The types that scanner.Builder.UseKeywords maps words
+// to are registered automatically.
+func (typ Type) IsKeyword() bool {
+	registerMu.RLock()
+	defer registerMu.RUnlock()
+	return keywordTypes[typ]
+}
+
+// RegisterKeywordType registers a token type as a "keyword".
+func RegisterKeywordType(typ Type) {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	keywordTypes[typ] = true
+}
+
+// IsOperator reports whether the type is a symbolic operator, such as "+" or
+// "===". Keywords acting as operators, like "typeof", are not included.
+func (typ Type) IsOperator() bool {
+	if typ >= ASSIGN && typ <= NOT || typ == ARROW {
+		return true
+	}
+	if typ < initCustomType {
+		return false
+	}
+	return (typ.IsBinaryOp() || typ.IsUnaryOp()) && !typ.IsKeyword()
+}
+
+func (typ Type) IsLiteral() bool {
+	return typ == NUMBER || typ == STRING || typ == BIGINT
+}
+
+var assignmentTypes = map[Type]bool{
+	ASSIGN: true,
+}
+
+// IsAssignment reports whether the type is an assignment operator, such as
+// "=" or a type registered with RegisterAssignmentType, like "??=".
+func (typ Type) IsAssignment() bool {
+	registerMu.RLock()
+	defer registerMu.RUnlock()
+	return assignmentTypes[typ]
+}
+
+// RegisterAssignmentType registers a token type as an "assignment operator".
+func RegisterAssignmentType(typ Type) {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	assignmentTypes[typ] = true
+}
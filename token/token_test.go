@@ -29,3 +29,139 @@ func TestConcurrentTypeAccess(t *testing.T) {
 		seen[typ] = true
 	}
 }
+
+// TestTypeValues checks that the values of the core types don't change, as
+// they may be stored or serialized.
+func TestTypeValues(t *testing.T) {
+	tests := []struct {
+		typ  token.Type
+		want int
+	}{
+		{token.EOF, 0},
+		{token.ASSIGN, 4},
+		{token.NOT, 20},
+		{token.COLON, 23},
+		{token.DOT, 24},
+		{token.NEWLINE, 31},
+		{token.NUMBER, 32},
+		{token.STRING, 35},
+		{token.BIGINT, 36},
+	}
+	for _, test := range tests {
+		if int(test.typ) != test.want {
+			t.Errorf("%s = %d, want %d", test.typ, int(test.typ), test.want)
+		}
+	}
+}
+
+func TestCategories(t *testing.T) {
+	kwTyp := token.RegisterType("unless")
+	token.RegisterKeywordType(kwTyp)
+	opTyp := token.RegisterType("<=>")
+	token.RegisterBinaryType(opTyp, token.EQ.Precedence())
+	kwOpTyp := token.RegisterType("instanceof")
+	token.RegisterBinaryType(kwOpTyp, token.LT.Precedence())
+	token.RegisterKeywordType(kwOpTyp)
+	assignTyp := token.RegisterType("+=")
+	token.RegisterBinaryType(assignTyp, token.ASSIGN.Precedence())
+	token.RegisterAssignmentType(assignTyp)
+	// the spelling of a type doesn't make it a keyword
+	wordTyp := token.RegisterType("word")
+	tests := []struct {
+		typ                                    token.Type
+		keyword, operator, literal, assignment bool
+	}{
+		{typ: token.IDENT},
+		{typ: token.ASSIGN, operator: true, assignment: true},
+		{typ: token.PLUS, operator: true},
+		{typ: token.NOT, operator: true},
+		{typ: token.LPAREN},
+		{typ: token.NUMBER, literal: true},
+		{typ: token.STRING, literal: true},
+		{typ: kwTyp, keyword: true},
+		{typ: opTyp, operator: true},
+		{typ: kwOpTyp, keyword: true},
+		{typ: assignTyp, operator: true, assignment: true},
+		{typ: wordTyp},
+	}
+	for _, test := range tests {
+		if got := test.typ.IsKeyword(); got != test.keyword {
+			t.Errorf("%s.IsKeyword() = %v", test.typ, got)
+		}
+		if got := test.typ.IsOperator(); got != test.operator {
+			t.Errorf("%s.IsOperator() = %v", test.typ, got)
+		}
+		if got := test.typ.IsLiteral(); got != test.literal {
+			t.Errorf("%s.IsLiteral() = %v", test.typ, got)
+		}
+		if got := test.typ.IsAssignment(); got != test.assignment {
+			t.Errorf("%s.IsAssignment() = %v", test.typ, got)
+		}
+	}
+}
+
+func TestSpelling(t *testing.T) {
+	tests := []struct {
+		typ  token.Type
+		want string
+	}{
+		{token.PLUS, "+"},
+		{token.EQ, "=="},
+		{token.RBRACKET, "]"},
+		{token.ARROW, "=>"},
+		{token.QUESTION, "?"},
+		{token.EOF, ""},
+		{token.IDENT, ""},
+		{token.NEWLINE, ""},
+		{token.NUMBER, ""},
+		{token.STRING, ""},
+		{token.Type(999), ""},
+	}
+	for _, test := range tests {
+		if got := test.typ.Spelling(); got != test.want {
+			t.Errorf("%s.Spelling() = %q, want %q", test.typ, got, test.want)
+		}
+	}
+}
+
+func TestPosition(t *testing.T) {
+	a := token.Position{Line: 3, Column: 14}
+	b := token.Position{Line: 3, Column: 15}
+	c := token.Position{Line: 4, Column: 0}
+	if got := a.String(); got != "3:14" {
+		t.Errorf("String() = %q, want %q", got, "3:14")
+	}
+	if !a.Before(b) || !b.Before(c) || !a.Before(c) || a.Before(a) || c.Before(a) {
+		t.Errorf("Before() is not a strict order")
+	}
+	if !c.After(a) || !b.After(a) || a.After(a) || a.After(b) {
+		t.Errorf("After() is not a strict order")
+	}
+	if !a.Equal(token.Position{Line: 3, Column: 14}) || a.Equal(b) {
+		t.Errorf("Equal() mismatch")
+	}
+}
+
+func TestTokenEnd(t *testing.T) {
+	tests := []struct {
+		literal string
+		want    token.Position
+	}{
+		{"foo", token.Position{Line: 2, Column: 7}},
+		{"`a\nbc`", token.Position{Line: 3, Column: 3}},
+		{"`a\r\nbc\rd`", token.Position{Line: 4, Column: 2}},
+		{"`a\n`", token.Position{Line: 3, Column: 1}},
+	}
+	for _, tt := range tests {
+		tok := token.Token{Position: token.Position{Line: 2, Column: 4}, Literal: tt.literal}
+		if got := tok.End(); got != tt.want {
+			t.Errorf("End() of %q = %v, want %v", tt.literal, got, tt.want)
+		}
+	}
+
+	// the source text is measured, not the decoded name
+	tok := token.Token{Type: token.IDENT, Literal: "ab", Raw: `\u{61}b`}
+	if got, want := tok.End(), (token.Position{Column: 7}); got != want {
+		t.Errorf("End() of %q = %v, want %v", tok.Raw, got, want)
+	}
+}
@@ -1,27 +1,296 @@
 package xjs
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
 	"github.com/xjslang/xjs/ast"
+	"github.com/xjslang/xjs/internal/debug"
+	"github.com/xjslang/xjs/internal/fingerprint"
 	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/jsextended"
 	"github.com/xjslang/xjs/plugin"
 	"github.com/xjslang/xjs/printer"
+	"github.com/xjslang/xjs/token"
 )
 
-func Parse(input []byte) (*js.Program, error) {
-	p := PluginBuilder().Build(input)
+// Dialect bundles the plugins and printers of a language flavor, so that they
+// can be installed at once.
+type Dialect struct {
+	Plugins  []func(*plugin.Builder)
+	Printers []func(*printer.Printer, ast.Node, func(ast.Node) error) error
+}
+
+var (
+	// Standard is the JavaScript subset supported by the js package.
+	Standard = Dialect{
+		Plugins:  []func(*plugin.Builder){js.Plugin},
+		Printers: []func(*printer.Printer, ast.Node, func(ast.Node) error) error{js.Printer},
+	}
+	// Extended adds the constructs supported by the jsextended package.
+	Extended = Dialect{
+		Plugins:  []func(*plugin.Builder){js.Plugin, jsextended.Plugin},
+		Printers: []func(*printer.Printer, ast.Node, func(ast.Node) error) error{js.Printer, jsextended.Printer},
+	}
+	// ES5 parses the same constructs as Extended, but prints ES5 compatible
+	// code, downleveled by jsextended.ES5. The constructs it can't downlevel
+	// are reported as print errors.
+	ES5 = Dialect{
+		Plugins:  []func(*plugin.Builder){js.Plugin, jsextended.Plugin},
+		Printers: []func(*printer.Printer, ast.Node, func(ast.Node) error) error{js.Printer, jsextended.Printer, jsextended.ES5},
+	}
+)
+
+func (d Dialect) PluginBuilder() *plugin.Builder {
+	b := plugin.New()
+	for _, plugin := range d.Plugins {
+		b.Install(plugin)
+	}
+	return b
+}
+
+func (d Dialect) PrinterBuilder() *printer.Builder {
+	b := printer.NewBuilder()
+	for _, printer := range d.Printers {
+		b.UsePrinter(printer)
+	}
+	return b
+}
+
+// printer builds a printer of the dialect, which parses with the dialect
+// too, see printer.WithParser.
+func (d Dialect) printer(opts ...printer.Option) *printer.Printer {
+	parse := func(src []byte) (ast.Node, error) {
+		return d.Parse(src)
+	}
+	return d.PrinterBuilder().Build(append([]printer.Option{printer.WithParser(parse)}, opts...)...)
+}
+
+// Fingerprint returns a hash of the dialect, made of the configuration its
+// plugins install and the printers it uses, so that build tools can tell
+// when cached output is stale. See parser.Builder.Fingerprint.
+func (d Dialect) Fingerprint() string {
+	h := fingerprint.New()
+	h.Add("plugins", d.PluginBuilder().Fingerprint())
+	fingerprint.AddFuncs(h, "printers", d.Printers...)
+	return h.Sum()
+}
+
+func (d Dialect) Parse(input []byte) (*js.Program, error) {
+	p := d.PluginBuilder().Build(input)
 	return js.ParseProgram(p)
 }
 
-func Print(result ast.Node, opts ...printer.Option) (string, error) {
-	pr := PrinterBuilder().Build(opts...)
+// ParseFile is like Parse, but records filename in the program, so that
+// multi-file tools know where each program came from.
+func (d Dialect) ParseFile(filename string, input []byte) (*js.Program, error) {
+	result, err := d.Parse(input)
+	if result != nil {
+		result.Filename = filename
+	}
+	return result, err
+}
+
+func (d Dialect) Print(result ast.Node, opts ...printer.Option) (string, error) {
+	pr := d.printer(opts...)
 	pr.Print(result)
 	return pr.Output()
 }
 
+// PrintTo streams the printed code to w.
+func (d Dialect) PrintTo(w io.Writer, result ast.Node, opts ...printer.Option) error {
+	pr := d.printer(append(slices.Clip(opts), printer.WithOutput(w))...)
+	pr.Print(result)
+	_, err := pr.Output()
+	return err
+}
+
+// CompileREPL compiles an input entered in a REPL. Unlike a program, an
+// input made of a single expression statement is printed as a call to
+// console.log, so that running it shows the value of the expression:
+//
+//	1 + 2  ->  console.log(1 + 2);
+//
+// Any other input, such as a declaration or several statements, is compiled
+// as a program. The statements of a prelude, see parser.Builder.WithPrelude,
+// don't count.
+func (d Dialect) CompileREPL(input []byte, opts ...printer.Option) (string, error) {
+	result, err := d.Parse(input)
+	if err != nil {
+		return "", err
+	}
+	if len(result.Stmts) == result.Prelude+1 {
+		if stmt, ok := result.Stmts[result.Prelude].(*js.ExprStmt); ok {
+			log := &js.MemberExpr{
+				Left:  &js.Variable{Token: token.Token{Type: token.IDENT, Literal: "console"}},
+				Right: &js.Ident{Token: token.Token{Type: token.IDENT, Literal: "log"}},
+			}
+			log.Layout.Dot = token.Token{Type: token.DOT, Literal: "."}
+			call := &js.CallExpr{Callee: log, Args: []ast.Expr{stmt.Expr}}
+			call.Layout.Lparen = token.Token{Type: token.LPAREN, Literal: "("}
+			call.Layout.Rparen = token.Token{Type: token.RPAREN, Literal: ")"}
+			result.Stmts[result.Prelude] = &js.ExprStmt{Layout: stmt.Layout, Expr: call}
+		}
+	}
+	return d.Print(result, opts...)
+}
+
+// ParseToJSON parses input and returns the syntax tree serialized as JSON,
+// see ast.ToJSON, for editors and analyzers that consume the tree rather than
+// the printed code.
+func (d Dialect) ParseToJSON(input []byte) ([]byte, error) {
+	result, err := d.Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return ast.ToJSON(result)
+}
+
+// Dump returns a report of what the scanner and the parser make of input,
+// meant to be pasted into bug reports: the tokens, the syntax tree and the
+// parser errors, if any.
+func (d Dialect) Dump(input []byte) string {
+	var b strings.Builder
+	b.WriteString("tokens:\n")
+	sc := d.PluginBuilder().BuildScanner(input)
+	for {
+		tok := sc.NextToken()
+		fmt.Fprintf(&b, "\t%s %s %q\n", tok.Position, tok.Type, tok.Literal)
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+	result, err := d.Parse(input)
+	b.WriteString("tree:\n")
+	b.WriteString(debug.Sprint(result))
+	b.WriteString("errors:\n")
+	if err != nil {
+		for line := range strings.Lines(err.Error()) {
+			b.WriteString("\t" + strings.TrimRight(line, "\n") + "\n")
+		}
+	}
+	return b.String()
+}
+
+// CompileBundle prints programs one after the other, so that they can be
+// shipped as a single script. Each program is preceded by a line comment with
+// its Filename, if known, unless the printer drops line comments. The
+// programs share the top-level scope, so their declarations must not clash.
+// The statements of a prelude, see parser.Builder.WithPrelude, are printed
+// only once, before the first program that has them.
+func (d Dialect) CompileBundle(programs []*js.Program, opts ...printer.Option) (string, error) {
+	pr := d.printer(opts...)
+	printed := map[ast.Stmt]bool{}
+	for _, program := range programs {
+		if program.Filename != "" {
+			pr.PrintLineComment(program.Filename)
+		}
+		if program.Prelude > 0 {
+			prelude := program.Stmts[:program.Prelude]
+			stmts := slices.DeleteFunc(slices.Clone(prelude), func(stmt ast.Stmt) bool { return printed[stmt] })
+			for _, stmt := range prelude {
+				printed[stmt] = true
+			}
+			dedup := *program
+			dedup.Stmts = append(stmts, program.Stmts[program.Prelude:]...)
+			dedup.Prelude = len(stmts)
+			program = &dedup
+		}
+		pr.Print(program)
+	}
+	return pr.Output()
+}
+
+// CompileDir compiles each .xjs file in srcDir and its subdirectories to a
+// .js file in outDir, at the same relative path. A file with errors doesn't
+// stop the others from being compiled; the errors of all files are returned
+// together, prefixed with the path of their file.
+func (d Dialect) CompileDir(srcDir, outDir string, opts ...printer.Option) error {
+	var errs []error
+	err := filepath.WalkDir(srcDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".xjs" {
+			return nil
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		if err := d.compileFile(path, filepath.Join(outDir, strings.TrimSuffix(rel, ".xjs")+".js"), opts); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+		return nil
+	})
+	return errors.Join(append(errs, err)...)
+}
+
+func (d Dialect) compileFile(src, dst string, opts []printer.Option) error {
+	input, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	result, err := d.ParseFile(src, input)
+	if err != nil {
+		return err
+	}
+	code, err := d.Print(result, opts...)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, []byte(code), 0o644)
+}
+
+func Parse(input []byte) (*js.Program, error) {
+	return Standard.Parse(input)
+}
+
+func ParseFile(filename string, input []byte) (*js.Program, error) {
+	return Standard.ParseFile(filename, input)
+}
+
+func CompileDir(srcDir, outDir string, opts ...printer.Option) error {
+	return Standard.CompileDir(srcDir, outDir, opts...)
+}
+
+func ParseToJSON(input []byte) ([]byte, error) {
+	return Standard.ParseToJSON(input)
+}
+
+func Dump(input []byte) string {
+	return Standard.Dump(input)
+}
+
+func CompileBundle(programs []*js.Program, opts ...printer.Option) (string, error) {
+	return Standard.CompileBundle(programs, opts...)
+}
+
+func CompileREPL(input []byte, opts ...printer.Option) (string, error) {
+	return Standard.CompileREPL(input, opts...)
+}
+
+func Print(result ast.Node, opts ...printer.Option) (string, error) {
+	return Standard.Print(result, opts...)
+}
+
+func PrintTo(w io.Writer, result ast.Node, opts ...printer.Option) error {
+	return Standard.PrintTo(w, result, opts...)
+}
+
 func PluginBuilder() *plugin.Builder {
-	return plugin.New().Install(js.Plugin)
+	return Standard.PluginBuilder()
 }
 
 func PrinterBuilder() *printer.Builder {
-	return printer.NewBuilder().UsePrinter(js.Printer)
+	return Standard.PrinterBuilder()
 }
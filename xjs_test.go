@@ -1,10 +1,15 @@
 package xjs_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,7 +18,9 @@ import (
 	"github.com/xjslang/xjs/ast"
 	"github.com/xjslang/xjs/internal/testutil"
 	"github.com/xjslang/xjs/js"
+	"github.com/xjslang/xjs/jsextended"
 	"github.com/xjslang/xjs/parser"
+	"github.com/xjslang/xjs/plugin"
 	"github.com/xjslang/xjs/printer"
 	"github.com/xjslang/xjs/scanner"
 	"github.com/xjslang/xjs/token"
@@ -27,6 +34,16 @@ func TestExportStmt(t *testing.T) {
 		require.Error(t, err)
 		require.Equal(t, err.Error(), "[line:0, col:7] declaration expected")
 	})
+	t.Run("default exports", func(t *testing.T) {
+		result := testutil.MustParse(t, "export default 42;\nexport default function () {} f();\nexport default function f() {}\nexport { a };")
+		stmts := result.Stmts
+		require.Len(t, stmts, 5)
+		require.True(t, stmts[0].(*js.ExportStmt).IsDefault())
+		require.IsType(t, &js.FunctionExpr{}, stmts[1].(*js.ExportStmt).Value)
+		require.IsType(t, &js.FunctionDecl{}, stmts[3].(*js.ExportStmt).Decl)
+		require.True(t, stmts[3].(*js.ExportStmt).IsDefault())
+		require.False(t, stmts[4].(*js.ExportStmt).IsDefault())
+	})
 }
 
 func TestAssignOperator(t *testing.T) {
@@ -88,6 +105,26 @@ func Example_basic() {
 	// }
 }
 
+func Example_dialect() {
+	input := "const isEmpty = (s) => s === ''"
+	// the standard dialect supports neither const nor arrow functions
+	if _, err := xjs.Standard.Parse([]byte(input)); err != nil {
+		fmt.Println("standard:", err)
+	}
+	result, err := xjs.Extended.Parse([]byte(input))
+	if err != nil {
+		panic(err)
+	}
+	out, err := xjs.Extended.Print(result)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("extended:", out)
+	// Output:
+	// standard: [line:0, col:6] ; expected
+	// extended: const isEmpty = (s) => s === '';
+}
+
 func TestExpectSemi(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -130,6 +167,43 @@ func TestExpectSemi(t *testing.T) {
 	}
 }
 
+func TestClassDeclErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		errs  []string
+	}{
+		{"class A { m() { if (a) { b } } }\nlet x = ;", []string{
+			"[line:0, col:0] class declarations are not supported",
+			"[line:1, col:8] expression expected",
+		}},
+		// without a body, only the statement is skipped
+		{"class A;\nlet x = ;\n{ let y = ; }", []string{
+			"[line:0, col:0] class declarations are not supported",
+			"[line:1, col:8] expression expected",
+			"[line:2, col:10] expression expected",
+		}},
+		{"class A extends B\nlet x = ;", []string{
+			"[line:0, col:0] class declarations are not supported",
+			"[line:1, col:8] expression expected",
+		}},
+	}
+	for _, test := range tests {
+		_, err := testutil.ParseExtended([]byte(test.input))
+		require.Error(t, err, test.input)
+		assert.Equal(t, test.errs, strings.Split(err.Error(), "\n"), test.input)
+	}
+}
+
+func TestLetDecls(t *testing.T) {
+	for _, d := range []xjs.Dialect{xjs.Standard, xjs.Extended} {
+		result, err := d.Parse([]byte("let a = 1, b, c = 2;"))
+		require.NoError(t, err)
+		out, err := d.Print(result)
+		require.NoError(t, err)
+		assert.Equal(t, "let a = 1, b, c = 2;", out)
+	}
+}
+
 func TestParserErrors(t *testing.T) {
 	input := `// program stmt
 aaa bbb // ; expected
@@ -156,9 +230,23 @@ function foo(p0; // ) expected
 if; // ( expected
 if (; // expression expected
 
+// with stmt
+with (obj) { a; } // with statements are not supported
+
+// class stmt
+class A { m() { return 1; } } // class declarations are not supported
+
+// labeled stmt
+break missing; // undefined label missing
+
 // let stmt
 let; // identifier expected
 let x =; // expression expected
+let x == 1; // unexpected ==, did you mean =?
+
+// export stmt
+export * lib; // from expected
+export { a } from b; // string expected
 
 // arr expr
 [; // ] expected
@@ -200,8 +288,9 @@ a[100; // ] expected
 .123; // expression expected (numbers cannot start with '.')
 1x123; // ; expected (invalid hex)
 2O123; // ; expected (invalid octal)
-0X; // expression expected (incomplete hex)
-0o; // expression expected (incomplete octal)
+0X; // hex digit expected (incomplete hex)
+0o; // octal digit expected (incomplete octal)
+1.5n; // invalid BigInt literal (fractions are not allowed)
 
 // member expr
 a.100; // key expected
@@ -209,7 +298,12 @@ a.(b); // key expected
 a.(b + c); // key expected
 
 // reserved keys cannot be used as identifiers
-let if = 100; // identifier expected`
+let if = 100; // unexpected keyword used as identifier
+function foo(let) {} // unexpected keyword used as identifier
+
+// strings
+let s = "unclosed; // unterminated string literal
+print('unclosed); // unterminated string literal`
 	_, errs := xjs.Parse([]byte(input))
 	require.IsType(t, parser.ErrorList{}, errs)
 	golden.Assert(t, []byte(errs.Error()))
@@ -220,6 +314,81 @@ let if = 100; // identifier expected`
 	})
 }
 
+func TestMissingSemiFix(t *testing.T) {
+	tests := []struct {
+		input string
+		at    token.Position
+	}{
+		{"let a = 1 let b = 2", token.Position{Line: 0, Column: 9}},
+		{"a()  b()", token.Position{Line: 0, Column: 3}},
+		{"x = `a\nbc` y", token.Position{Line: 1, Column: 3}},
+		{"x = \\u{61}b c", token.Position{Line: 0, Column: 11}},
+	}
+	for _, test := range tests {
+		_, err := testutil.ParseExtended([]byte(test.input))
+		var errs parser.ErrorList
+		require.ErrorAs(t, err, &errs, test.input)
+		var e parser.Error
+		require.ErrorAs(t, errs[0], &e, test.input)
+		assert.Equal(t, js.MissingSemiCode, e.Code, test.input)
+		require.NotNil(t, e.Fix, test.input)
+		assert.Equal(t, parser.Range{Start: test.at, End: test.at}, e.Fix.Range, test.input)
+		assert.Equal(t, ";", e.Fix.Text, test.input)
+	}
+}
+
+func TestEqualityInDeclFix(t *testing.T) {
+	tests := []struct {
+		input  string
+		at     token.Position
+		length int
+	}{
+		{"let a == 1;", token.Position{Line: 0, Column: 6}, 2},
+		{"const a === 1;", token.Position{Line: 0, Column: 8}, 3},
+		{"let a = 1,\n  { b } == c;", token.Position{Line: 1, Column: 8}, 2},
+	}
+	for _, test := range tests {
+		_, err := testutil.ParseExtended([]byte(test.input))
+		var errs parser.ErrorList
+		require.ErrorAs(t, err, &errs, test.input)
+		var e parser.Error
+		require.ErrorAs(t, errs[0], &e, test.input)
+		assert.Equal(t, js.EqualityInDeclCode, e.Code, test.input)
+		require.NotNil(t, e.Fix, test.input)
+		end := test.at
+		end.Column += test.length
+		assert.Equal(t, parser.Range{Start: test.at, End: end}, e.Fix.Range, test.input)
+		assert.Equal(t, "=", e.Fix.Text, test.input)
+	}
+}
+
+func TestIfElseBraces(t *testing.T) {
+	tests := []struct {
+		input, compact string
+	}{
+		{"if (a) { b() } else { c() }", "if (a) {b();} else {c();}"},
+		{"if (a) { b() } else c()", "if (a) {b();} else c();"},
+		{"if (a) b()\nelse { c() }", "if (a) b(); else {c();}"},
+		{"if (a) b()\nelse c()", "if (a) b(); else c();"},
+		{"if (a) {} else {}", "if (a) {} else {}"},
+		{"if (a) b(); else if (c) { d() } else e()", "if (a) b(); else if (c) {d();} else e();"},
+	}
+	for _, test := range tests {
+		for _, opts := range [][]printer.Option{nil, {printer.Compact()}, {printer.WithMinimalSemicolons(true)}} {
+			result := testutil.MustParse(t, test.input)
+			out, err := xjs.Extended.Print(result, opts...)
+			require.NoError(t, err)
+			// the output must parse back to the same program
+			again, err := xjs.Extended.Print(testutil.MustParse(t, out), opts...)
+			require.NoError(t, err)
+			assert.Equal(t, out, again, test.input)
+		}
+		out, err := xjs.Extended.Print(testutil.MustParse(t, test.input), printer.Compact())
+		require.NoError(t, err)
+		assert.Equal(t, test.compact, out, test.input)
+	}
+}
+
 func TestLanguageFeatures(t *testing.T) {
 	pattern := filepath.Join("testdata", "*.js")
 	files, err := filepath.Glob(pattern)
@@ -232,13 +401,13 @@ func TestLanguageFeatures(t *testing.T) {
 			dat, err := os.ReadFile(file)
 			require.NoError(t, err)
 			// parse data
-			result, err := testutil.ParseExtended(dat)
+			result, err := testutil.ParseModule(dat)
 			require.NoError(t, err)
 			// print result
 			out, err := testutil.PrintExtended(result)
 			require.NoError(t, err)
 			// re-parse the output
-			result, err = testutil.ParseExtended([]byte(out))
+			result, err = testutil.ParseModule([]byte(out))
 			require.NoError(t, err)
 			// re-print the result
 			out, err = testutil.PrintExtended(result)
@@ -338,3 +507,2054 @@ func TestMiddlewares(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expected, out)
 }
+
+func TestRemoveKeywords(t *testing.T) {
+	input := "let delete = 100"
+	_, err := xjs.Parse([]byte(input))
+	require.Error(t, err)
+
+	// a dialect can turn a keyword back into a plain identifier
+	b := xjs.PluginBuilder()
+	b.RemoveKeywords("delete")
+	result, err := js.ParseProgram(b.Build([]byte(input)))
+	require.NoError(t, err)
+	out, err := xjs.Print(result)
+	require.NoError(t, err)
+	require.Equal(t, "let delete = 100;", out)
+}
+
+func TestConcurrentDialects(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			b := xjs.PluginBuilder()
+			input := "let x = 1"
+			if i%2 == 0 {
+				// "let" is not a keyword in this dialect
+				b.RemoveKeywords("let")
+				input = "let = 1"
+			}
+			_, err := js.ParseProgram(b.Build([]byte(input)))
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestBreakContinue(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{"while (true) { break }", ""},
+		{"for (;;) { if (a) continue }", ""},
+		{"do { break } while (true)", ""},
+		{"for (let a of b) continue", ""},
+		{"switch (a) { case 1: break }", ""},
+		{"for (;;) { switch (a) { case 1: continue } }", ""},
+		{"switch (a) { default: { break } }", ""},
+		{"break", "[line:0, col:0] illegal break statement"},
+		{"if (a) { continue }", "[line:0, col:9] illegal continue statement"},
+		{"switch (a) { case 1: continue }", "[line:0, col:21] illegal continue statement"},
+		{"while (a) {}\nbreak", "[line:1, col:0] illegal break statement"},
+		{"while (x) { function f() { break; } }", "[line:0, col:27] illegal break statement"},
+		{"for (;;) { let g = () => { continue; }; }", "[line:0, col:27] illegal continue statement"},
+		{"switch (a) { case 1: let h = function () { break; }; }", "[line:0, col:43] illegal break statement"},
+		{"while (x) { function f() { while (y) { break } } }", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			_, err := testutil.ParseExtended([]byte(test.input))
+			if test.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, test.expectedErr)
+			}
+		})
+	}
+}
+
+func TestIdentifierEscapes(t *testing.T) {
+	result, err := testutil.ParseExtended([]byte(`let \u{61}b = 1; o.\u0069f = { \u0069f: ab, \u{62}: 2 };`))
+	require.NoError(t, err)
+	out, err := testutil.PrintExtended(result)
+	require.NoError(t, err)
+	assert.Equal(t, "let ab = 1;\no.\\u0069f = { \\u0069f: ab, b: 2 };", out)
+
+	_, err = testutil.ParseExtended([]byte(`let \u{110000} = 1;`))
+	require.ErrorContains(t, err, "invalid escape sequence")
+}
+
+func TestWithPrecedence(t *testing.T) {
+	pb := xjs.PluginBuilder()
+	pb.WithPrecedence(token.AND, token.OR.Precedence()-1)
+	result, err := js.ParseProgram(pb.Build([]byte("a && b || c; d || e && f;")))
+	require.NoError(t, err)
+	// a && (b || c)
+	expr := result.Stmts[0].(*js.ExprStmt).Expr.(*js.BinaryExpr)
+	assert.Equal(t, token.AND, expr.Op.Type)
+	assert.Equal(t, token.OR, expr.Right.(*js.BinaryExpr).Op.Type)
+	// (d || e) && f
+	expr = result.Stmts[1].(*js.ExprStmt).Expr.(*js.BinaryExpr)
+	assert.Equal(t, token.AND, expr.Op.Type)
+	assert.Equal(t, token.OR, expr.Left.(*js.BinaryExpr).Op.Type)
+
+	// other parsers keep the global precedence
+	result, err = xjs.Parse([]byte("a && b || c;"))
+	require.NoError(t, err)
+	expr = result.Stmts[0].(*js.ExprStmt).Expr.(*js.BinaryExpr)
+	assert.Equal(t, token.OR, expr.Op.Type)
+	assert.NotEqual(t, xjs.PluginBuilder().Fingerprint(), pb.Fingerprint())
+
+	// overrides don't affect the parsers already built
+	p := pb.Build([]byte("a + b * c;"))
+	pb.WithPrecedence(token.PLUS, token.MULTIPLY.Precedence()+1)
+	result, err = js.ParseProgram(p)
+	require.NoError(t, err)
+	assert.Equal(t, token.PLUS, result.Stmts[0].(*js.ExprStmt).Expr.(*js.BinaryExpr).Op.Type)
+
+	// ** follows the overrides too: a ** (b + c)
+	pb = xjs.Extended.PluginBuilder()
+	pb.WithPrecedence(jsextended.EXPONENT, token.PLUS.Precedence())
+	result, err = js.ParseProgram(pb.Build([]byte("a ** b + c;")))
+	require.NoError(t, err)
+	expr = result.Stmts[0].(*js.ExprStmt).Expr.(*js.BinaryExpr)
+	assert.Equal(t, jsextended.EXPONENT, expr.Op.Type)
+
+	// and so does DropRedundantParens, when told about them
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.DropRedundantParens)
+	result, err = d.Parse([]byte("x = (a || b) && c;"))
+	require.NoError(t, err)
+	out, err := d.Print(result)
+	require.NoError(t, err)
+	assert.Equal(t, "x = (a || b) && c;", out)
+	out, err = d.Print(result, printer.WithPrecedence(token.AND, token.OR.Precedence()-1))
+	require.NoError(t, err)
+	assert.Equal(t, "x = a || b && c;", out)
+}
+
+func TestWithPrelude(t *testing.T) {
+	helpers, err := xjs.Parse([]byte("function factorial(n) { if (n <= 1) { return 1 } return n * factorial(n - 1) }"))
+	require.NoError(t, err)
+	pb := xjs.PluginBuilder()
+	pb.WithPrelude(helpers.Stmts...)
+	result, err := js.ParseProgram(pb.Build([]byte("let a = factorial(5);\nlet b = 1;")))
+	require.NoError(t, err)
+	require.Len(t, result.Stmts, 3)
+	assert.Same(t, helpers.Stmts[0], result.Stmts[0])
+	out, err := xjs.Print(result)
+	require.NoError(t, err)
+	assert.Equal(t, `function factorial(n) {
+  if (n <= 1) {
+    return 1;
+  }
+  return n * factorial(n - 1);
+}
+let a = factorial(5);
+let b = 1;`, out)
+
+	// empty programs get the prelude too, and the helpers are not appended twice
+	result, err = js.ParseProgram(pb.Build(nil))
+	require.NoError(t, err)
+	assert.Len(t, result.Stmts, 1)
+	assert.Len(t, helpers.Stmts, 1)
+	assert.NotEqual(t, xjs.PluginBuilder().Fingerprint(), pb.Fingerprint())
+
+	// the REPL wraps the expression, not the prelude, and bundles print the
+	// prelude once
+	d := xjs.Standard
+	d.Plugins = append(slices.Clip(d.Plugins), func(b *plugin.Builder) { b.WithPrelude(helpers.Stmts...) })
+	out, err = d.CompileREPL([]byte("factorial(5)"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(out, "\nconsole.log(factorial(5));"), out)
+	a, err := d.Parse([]byte("let a = factorial(2);"))
+	require.NoError(t, err)
+	b, err := d.Parse([]byte("let b = factorial(3);"))
+	require.NoError(t, err)
+	out, err = d.CompileBundle([]*js.Program{a, b})
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(out, "function factorial"), out)
+	assert.True(t, strings.HasSuffix(out, "\nlet a = factorial(2);\nlet b = factorial(3);"), out)
+}
+
+func TestStrictReturn(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{"function f() { return 1 }", ""},
+		{"let f = function () { if (a) { return } }", ""},
+		{"let f = () => { return 1 }", ""},
+		{"return", "[line:0, col:0] illegal return statement"},
+		{"if (a) { return 1 }", "[line:0, col:9] illegal return statement"},
+		{"function f() {}\nreturn f", "[line:1, col:0] illegal return statement"},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			pb := xjs.Extended.PluginBuilder()
+			pb.WithStrictReturn()
+			_, err := js.ParseProgram(pb.Build([]byte(test.input)))
+			if test.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, test.expectedErr)
+			}
+		})
+	}
+	// accepted by default
+	_, err := testutil.ParseExtended([]byte("return 1"))
+	require.NoError(t, err)
+}
+
+func TestParserDialects(t *testing.T) {
+	tests := []struct {
+		dialect     parser.Dialect
+		input       string
+		expectedErr string
+	}{
+		{parser.DialectStandard, "let a = 1 let b = 2", "[line:0, col:10] ; expected"},
+		{parser.DialectStandard, "let a = 1, b; var c; if (a == b) {}", ""},
+		{parser.DialectPermissive, "let a = 1 let b = 2\nf() g()", ""},
+		{parser.DialectPermissive, "let a = 1, b; if (a == b) {}", ""},
+		{parser.DialectStrict, "let a = 1, b = 2; const [c] = d; for (let x of y) {} for (x in y) {}", ""},
+		{parser.DialectStrict, "let a = 1, b;", "[line:0, col:12] = expected"},
+		{parser.DialectStrict, "var a;", "[line:0, col:5] = expected"},
+		{parser.DialectStrict, "if (a === b && c !== d) {}", ""},
+		{parser.DialectStrict, "if (a == b) {}", "[line:0, col:6] == is not allowed, use ==="},
+		{parser.DialectStrict, "x = a != b", "[line:0, col:6] != is not allowed, use !=="},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			pb := xjs.Extended.PluginBuilder()
+			pb.WithDialect(test.dialect)
+			_, err := js.ParseProgram(pb.Build([]byte(test.input)))
+			if test.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, test.expectedErr)
+			}
+		})
+	}
+
+	// loose comparisons come with a fix
+	pb := xjs.Extended.PluginBuilder()
+	pb.WithDialect(parser.DialectStrict)
+	_, err := js.ParseProgram(pb.Build([]byte("a != b")))
+	var errs parser.ErrorList
+	require.ErrorAs(t, err, &errs)
+	var e parser.Error
+	require.ErrorAs(t, errs[0], &e)
+	assert.Equal(t, js.LooseEqualityCode, e.Code)
+	require.NotNil(t, e.Fix)
+	assert.Equal(t, "!==", e.Fix.Text)
+	assert.Equal(t, e.Range, e.Fix.Range)
+	assert.NotEqual(t, xjs.Extended.PluginBuilder().Fingerprint(), pb.Fingerprint())
+}
+
+func TestObjKeys(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{`let a = {"a-b":1,2:"x"}`, `let a = { "a-b": 1, 2: "x" };`},
+		{`let a = {'a': 1, 0x1F: 2, 3.14: 3}`, `let a = { 'a': 1, 0x1F: 2, 3.14: 3 };`},
+		{`let a = {name: 1, [key]: 2}`, `let a = { name: 1, [key]: 2 };`},
+	}
+	for _, test := range tests {
+		result, err := xjs.Parse([]byte(test.input))
+		require.NoError(t, err)
+		out, err := xjs.Print(result, printer.Compact())
+		require.NoError(t, err)
+		require.Equal(t, test.expected, out)
+		// printed keys must round-trip
+		result, err = xjs.Parse([]byte(out))
+		require.NoError(t, err)
+		out, err = xjs.Print(result, printer.Compact())
+		require.NoError(t, err)
+		require.Equal(t, test.expected, out)
+	}
+}
+
+func TestOptionalChaining(t *testing.T) {
+	result := testutil.MustParse(t, "a?.b?.[c]?.(d, e)")
+	require.Len(t, result.Stmts, 1)
+	require.IsType(t, &js.ExprStmt{}, result.Stmts[0])
+	call, ok := result.Stmts[0].(*js.ExprStmt).Expr.(*jsextended.OptionalChainingExpr)
+	require.True(t, ok)
+	require.IsType(t, &js.CallExpr{}, call.Right)
+	assert.Nil(t, call.Right.(*js.CallExpr).Callee)
+	assert.Len(t, call.Right.(*js.CallExpr).Args, 2)
+	index, ok := call.Left.(*jsextended.OptionalChainingExpr)
+	require.True(t, ok)
+	require.IsType(t, &js.ComputedExpr{}, index.Right)
+	member, ok := index.Left.(*jsextended.OptionalChainingExpr)
+	require.True(t, ok)
+	require.IsType(t, &jsextended.PropertyExpr{}, member.Right)
+	assert.Equal(t, "b", member.Right.(*jsextended.PropertyExpr).Name.Literal)
+}
+
+func TestObjectSpread(t *testing.T) {
+	result := testutil.MustParse(t, "x = { ...a, b: 1, ...c.d }")
+	obj, ok := result.Stmts[0].(*js.ExprStmt).Expr.(*js.AssignExpr).Right.(*jsextended.ObjExpr)
+	require.True(t, ok)
+	require.Len(t, obj.Entries, 3)
+	require.IsType(t, &jsextended.SpreadExpr{}, obj.Entries[0].Key)
+	assert.IsType(t, &js.Variable{}, obj.Entries[0].Key.(*jsextended.SpreadExpr).Value)
+	assert.IsType(t, &js.Ident{}, obj.Entries[1].Key)
+	require.IsType(t, &jsextended.SpreadExpr{}, obj.Entries[2].Key)
+	assert.IsType(t, &js.MemberExpr{}, obj.Entries[2].Key.(*jsextended.SpreadExpr).Value)
+}
+
+func TestNullishMixing(t *testing.T) {
+	tests := []struct {
+		input, err string
+	}{
+		{"a ?? b || c", "[line:0, col:7] cannot mix ?? with || or && without parentheses"},
+		{"a || b ?? c", "[line:0, col:7] cannot mix ?? with || or && without parentheses"},
+		{"a ?? b && c", "[line:0, col:2] cannot mix ?? with || or && without parentheses"},
+		{"a && b ?? c", "[line:0, col:7] cannot mix ?? with || or && without parentheses"},
+		{"(a ?? b) || c", ""},
+		{"a ?? (b && c)", ""},
+		{"a ?? b ?? c", ""},
+	}
+	for _, test := range tests {
+		_, err := testutil.ParseExtended([]byte(test.input))
+		if test.err == "" {
+			assert.NoError(t, err, test.input)
+		} else {
+			assert.EqualError(t, err, test.err, test.input)
+		}
+	}
+}
+
+func TestLogicalAssign(t *testing.T) {
+	result := testutil.MustParse(t, "a.b ??= c?.d ?? e;")
+	// the assignment binds the loosest: a.b ??= (c?.d ?? e)
+	assign := result.Stmts[0].(*js.ExprStmt).Expr.(*js.AssignExpr)
+	assert.Equal(t, jsextended.NULLISH_ASSIGN, assign.Layout.Assign.Type)
+	assert.IsType(t, &js.MemberExpr{}, assign.Left)
+	nullish := assign.Right.(*js.BinaryExpr)
+	assert.Equal(t, jsextended.NULLISH, nullish.Op.Type)
+	assert.IsType(t, &jsextended.OptionalChainingExpr{}, nullish.Left)
+
+	tests := []struct {
+		input, err string
+	}{
+		{"a?.b ??= c", "[line:0, col:5] invalid assignment target"},
+		{"a?.b.c ||= c", "[line:0, col:7] invalid assignment target"},
+		{"a?.[b] = c", "[line:0, col:7] invalid assignment target"},
+		{"a ?? b ??= c", "[line:0, col:7] invalid assignment target"},
+		{"[a] &&= b", "[line:0, col:4] invalid assignment target"},
+		{"a.b?.c.d", ""},
+		{"x ||= y &&= z", ""},
+	}
+	for _, test := range tests {
+		_, err := testutil.ParseExtended([]byte(test.input))
+		if test.err == "" {
+			assert.NoError(t, err, test.input)
+		} else {
+			assert.EqualError(t, err, test.err, test.input)
+		}
+	}
+}
+
+func TestES5(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{`let a = 1`, `var a = 1;`},
+		{`const {a, b} = c`, `var a = c.a, b = c.b;`},
+		{`let {a, b: [c, , ...d], e = 1} = f()`, `var _ref = f(), a = _ref.a, _ref2 = _ref.b, c = _ref2[0], d = _ref2.slice(2), _ref3 = _ref.e, e = _ref3 === void 0 ? 1 : _ref3;`},
+		{`const {a, b: [c, d, ...e]} = o`, `var a = o.a, _ref = o.b, c = _ref[0], d = _ref[1], e = _ref.slice(2);`},
+		{`let _ref; const [a] = a`, `var _ref;var __ref = a, a = __ref[0];`},
+		{`for (const x of xs) f(x)`, `for (var _i = 0, _a = xs; _i < _a.length; _i++) {var x = _a[_i];f(x);}`},
+		{
+			`for (const [k, v] of xs) { for (y of ys) g(k, v) }`,
+			`for (var _i = 0, _a = xs; _i < _a.length; _i++) {var _ref = _a[_i], k = _ref[0], v = _ref[1];for (var _i2 = 0, _a2 = ys; _i2 < _a2.length; _i2++) {y = _a2[_i2];g(k, v);}}`,
+		},
+		{
+			`for (let x of xs) fs.push(() => x)`,
+			`for (var _i = 0, _a = xs; _i < _a.length; _i++) {var x = _a[_i];(function (x) {fs.push((function () {return x;}).bind(this));}).call(this, x);}`,
+		},
+		{`for (const k in o) f(k)`, `for (var k in o) f(k);`},
+		{`x = a ** b ** 2`, `x = Math.pow(a, Math.pow(b, 2));`},
+		{`x = a.b ?? c`, `x = (a.b != null ? a.b : c);`},
+		{`a ||= b; a.b ??= c`, `(a || (a = b));(a.b != null ? a.b : a.b = c);`},
+		{`x = a?.b?.c()`, `x = (a == null ? void 0 : (a.b == null ? void 0 : a.b.c()));`},
+		{"x = `a ${b + 1} \\`c\\` ${d}`", "x = (\"a \" + (b + 1) + \" `c` \" + d);"},
+		{"x = `a\n\"b\"`", `x = "a\n\"b\"";`},
+		{`f(...a); o.m(1, ...a, 2)`, `f.apply(void 0, a);o.m.apply(o, [1].concat(a, [2]));`},
+		{`x = [...a, 1]`, `x = [].concat(a, [1]);`},
+		{`x = {a, b: 1}`, `x = { a: a, b: 1 };`},
+		{`try { f(); } catch { g(); }`, `try {f();} catch (_e) {g();}`},
+		{`let f = () => 1`, `var f = (function () {return 1;}).bind(this);`},
+		{`f(a => a * 2)`, `f((function (a) {return a * 2;}).bind(this));`},
+		{`f((a, b) => { return a + b; })`, `f((function (a, b) {return a + b;}).bind(this));`},
+		{
+			`for (let i = 0; i < 3; i++) { fs.push(function () { return i; }); }`,
+			`for (var i = 0; i < 3; i++) {(function (i) {fs.push(function () {return i;});}).call(this, i);}`,
+		},
+		{
+			`for (let i = 0, j = 1; i < 3; i++) g(() => i + j)`,
+			`for (var i = 0, j = 1; i < 3; i++) {(function (i, j) {g((function () {return i + j;}).bind(this));}).call(this, i, j);}`,
+		},
+		{
+			`for (let i = 0; i < 3; i++) { for (;;) { break; } while (a) { continue; } }`,
+			`for (var i = 0; i < 3; i++) {for (;;) {break;}while (a) {continue;}}`,
+		},
+		{`for (var i = 0; i < 3; i++) { f(() => i); }`, `for (var i = 0; i < 3; i++) {f((function () {return i;}).bind(this));}`},
+	}
+	for _, test := range tests {
+		result, err := xjs.ES5.Parse([]byte(test.input))
+		require.NoError(t, err)
+		out, err := xjs.ES5.Print(result, printer.Compact())
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out)
+	}
+}
+
+func TestES5TemplateDialect(t *testing.T) {
+	// the substitutions are parsed by the dialect, which skips the type
+	// assertion and adds no prelude to them
+	d := xjs.ES5
+	d.Plugins = append(slices.Clip(d.Plugins), func(b *plugin.Builder) {
+		b.WithIgnoreTypeAnnotations()
+		b.WithPrelude(testutil.MustParse(t, "var helper;").Stmts...)
+	})
+	result, err := d.Parse([]byte("let s = `a${x as string}b`;"))
+	require.NoError(t, err)
+	out, err := d.Print(result, printer.Compact())
+	require.NoError(t, err)
+	assert.Equal(t, `var helper;var s = ("a" + x + "b");`, out)
+}
+
+func TestES5Errors(t *testing.T) {
+	tests := []struct {
+		input, err string
+	}{
+		{`x = f() ?? a`, "[line:0, col:8] ?? with a complex left operand is not supported in ES5"},
+		{`x = f()?.a`, "[line:0, col:7] ?. after a complex expression is not supported in ES5"},
+		{`f().m(...a)`, "[line:0, col:6] spread arguments to a method of a complex expression is not supported in ES5"},
+		{`new X(...a)`, "[line:0, col:6] spread arguments to new is not supported in ES5"},
+		{`x = {...a}`, "[line:0, col:5] object spread is not supported in ES5"},
+		{`x = {[a]: 1}`, "[line:0, col:5] computed property name is not supported in ES5"},
+		{`const {...a} = b`, "[line:0, col:7] object rest is not supported in ES5"},
+		{`[a, b] = [b, a]`, "[line:0, col:0] destructuring assignment is not supported in ES5"},
+		{`f(({a}) => a)`, "[line:0, col:3] destructuring parameter is not supported in ES5"},
+		{`f((a, ...b) => a)`, "[line:0, col:6] rest parameter is not supported in ES5"},
+		{`async function f() {}`, "[line:0, col:0] async is not supported in ES5"},
+		{`let a = 10n`, "[line:0, col:8] BigInt literal is not supported in ES5"},
+		{`function F() { return new.target; }`, "[line:0, col:22] new.target is not supported in ES5"},
+	}
+	for _, test := range tests {
+		result, err := xjs.ES5.Parse([]byte(test.input))
+		require.NoError(t, err, test.input)
+		_, err = xjs.ES5.Print(result)
+		assert.EqualError(t, err, test.err, test.input)
+	}
+
+	b := xjs.ES5.PluginBuilder()
+	b.WithModule()
+	result, err := js.ParseProgram(b.Build([]byte("f(import.meta.url)")))
+	require.NoError(t, err)
+	_, err = xjs.ES5.Print(result)
+	assert.EqualError(t, err, "[line:0, col:2] import.meta is not supported in ES5")
+}
+
+func TestES5LoopClosures(t *testing.T) {
+	// let loops keep their per-iteration bindings by default
+	input := []byte(`for (let i = 0; i < 3; i++) { fs.push(() => i); }`)
+	result, err := xjs.Extended.Parse(input)
+	require.NoError(t, err)
+	out, err := xjs.Extended.Print(result, printer.Compact())
+	require.NoError(t, err)
+	assert.Equal(t, `for (let i = 0; i < 3; i++) {fs.push(() => i);}`, out)
+
+	// the let and const declared in loop bodies get a binding per iteration too
+	for _, test := range []struct{ input, expected string }{
+		{
+			`for (let i = 0; i < 3; i++) { let x = i; fs.push(() => x); }`,
+			`for (var i = 0; i < 3; i++) {(function (i) {var x = i;fs.push((function () {return x;}).bind(this));}).call(this, i);}`,
+		},
+		{
+			`while (a) { const x = f(); g(function () { return x; }); }`,
+			`while (a) {(function () {var x = f();g(function () {return x;});}).call(this);}`,
+		},
+		{`for (var i = 0; i < 3; i++) { let x = i; f(x); }`, `for (var i = 0; i < 3; i++) {var x = i;f(x);}`},
+	} {
+		result, err := xjs.ES5.Parse([]byte(test.input))
+		require.NoError(t, err)
+		out, err := xjs.ES5.Print(result, printer.Compact())
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out, test.input)
+	}
+
+	tests := []struct {
+		input, err string
+	}{
+		{`for (let i = 0; ; ) { f(() => i); return; }`, "[line:0, col:34] return prevents wrapping the loop body in a function"},
+		{`for (let i = 0; ; ) { f(() => i); break; }`, "[line:0, col:34] break prevents wrapping the loop body in a function"},
+		{`for (let i = 0; ; ) { f(() => i); if (a) continue; }`, "[line:0, col:41] continue prevents wrapping the loop body in a function"},
+		{`a: for (let i = 0; ; ) { f(() => i); for (;;) { break a; } }`, "[line:0, col:48] break prevents wrapping the loop body in a function"},
+		{`for (let i = 0; ; ) { f(() => i); var x; }`, "[line:0, col:34] var prevents wrapping the loop body in a function"},
+		{`for (let i = 0; ; ) { f(() => i); g(arguments); }`, "[line:0, col:36] arguments prevents wrapping the loop body in a function"},
+		{`for (let i = 0; ; ) { f(() => i); i = 2; }`, "[line:0, col:36] assignment to i prevents wrapping the loop body in a function"},
+		{`for (let i = 0; ; ) { f(() => i++); }`, "[line:0, col:31] update of i prevents wrapping the loop body in a function"},
+		{`while (a) { let x; f(() => x); break; }`, "[line:0, col:31] break prevents wrapping the loop body in a function"},
+	}
+	for _, test := range tests {
+		result, err := xjs.ES5.Parse([]byte(test.input))
+		require.NoError(t, err)
+		_, err = xjs.ES5.Print(result)
+		assert.EqualError(t, err, test.err, test.input)
+	}
+}
+
+func TestMaxTokens(t *testing.T) {
+	b := xjs.PluginBuilder()
+	b.WithMaxTokens(5)
+	_, err := js.ParseProgram(b.Build([]byte("let a = 1; let b = 2;")))
+	require.EqualError(t, err, "[line:0, col:11] too many tokens")
+}
+
+func TestStrictEquality(t *testing.T) {
+	var warnings []token.Position
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.StrictEquality(func(op token.Token) {
+		warnings = append(warnings, op.Position)
+	}))
+	result, err := d.Parse([]byte("if (a == b && c != d && e === f) {}"))
+	require.NoError(t, err)
+	out, err := d.Print(result, printer.Compact())
+	require.NoError(t, err)
+	assert.Equal(t, "if (a === b && c !== d && e === f) {}", out)
+	assert.Equal(t, []token.Position{{Line: 0, Column: 6}, {Line: 0, Column: 16}}, warnings)
+
+	// measuring the calls doesn't warn again
+	warnings = nil
+	result, err = d.Parse([]byte("f(g(a == b), [h(c != d)], { k: e == f }, someLongArgument);"))
+	require.NoError(t, err)
+	out, err = d.Print(result, printer.WithMaxWidth(40))
+	require.NoError(t, err)
+	assert.Equal(t, "f(\n  g(a === b),\n  [h(c !== d)],\n  { k: e === f },\n  someLongArgument\n);", out)
+	assert.Len(t, warnings, 3)
+}
+
+func TestRewriteConsoleLog(t *testing.T) {
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.RewriteConsoleLog("myLogger.debug"))
+	result, err := d.Parse([]byte(`/* c */ console.log('x', x);
+console.log(console.log(a));
+console.error(e);
+f(console.log);
+console?.log(a);
+log(a);
+function f() { console.log(a); }`))
+	require.NoError(t, err)
+	out, err := d.Print(result)
+	require.NoError(t, err)
+	assert.Equal(t, `/* c */
+myLogger.debug('x', x);
+myLogger.debug(myLogger.debug(a));
+console.error(e);
+f(console.log);
+console?.log(a);
+log(a);
+function f() {
+  myLogger.debug(a);
+}`, out)
+
+	// the links of a broken chain are printed by the printers too
+	result, err = d.Parse([]byte("p.then(f).catch(console.log).done(x => console.log(x));\nconsole.log(a).then(f).done();"))
+	require.NoError(t, err)
+	out, err = d.Print(result, printer.WithChainBreaking(2))
+	require.NoError(t, err)
+	assert.Equal(t, `p
+  .then(f)
+  .catch(console.log)
+  .done(x => myLogger.debug(x));
+myLogger
+  .debug(a)
+  .then(f)
+  .done();`, out)
+}
+
+func TestDropDebugger(t *testing.T) {
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.DropDebugger)
+	result, err := d.Parse([]byte(`f();
+// pause here
+debugger;
+g();
+if (a) debugger;
+while (b) debugger
+function h() { debugger; return 1 }
+switch (c) { case 1: debugger; k(); default: debugger }`))
+	require.NoError(t, err)
+	out, err := d.Print(result)
+	require.NoError(t, err)
+	assert.Equal(t, `f();
+// pause here
+g();
+if (a) {}
+while (b) {}
+function h() {
+  return 1;
+}
+switch (c) {
+  case 1:
+    k();
+  default:
+}`, out)
+}
+
+func TestQuotePropsAsNeeded(t *testing.T) {
+	input := `a = {"name": 1, 'data-id': 2, "class": 3, "1": 4, "\u0078": 5, "": 6, ['b']: 7, c: 8};
+let {"d": d, "e-f": ef = 9} = g;`
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.QuotePropsAsNeeded)
+	result, err := d.Parse([]byte(input))
+	require.NoError(t, err)
+	out, err := d.Print(result)
+	require.NoError(t, err)
+	assert.Equal(t, `a = { name: 1, 'data-id': 2, class: 3, "1": 4, x: 5, "": 6, ['b']: 7, c: 8 };
+let { d: d, "e-f": ef = 9 } = g;`, out)
+
+	d = xjs.Standard
+	d.Printers = append(slices.Clip(d.Printers), jsextended.QuotePropsAsNeeded)
+	result, err = d.Parse([]byte(`a = {"name": 1, "data-id": 2};`))
+	require.NoError(t, err)
+	out, err = d.Print(result)
+	require.NoError(t, err)
+	assert.Equal(t, `a = { name: 1, "data-id": 2 };`, out)
+}
+
+func TestPrecedence(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"a", js.PrimaryPrecedence()},
+		{"(a + b)", js.PrimaryPrecedence()},
+		{"a.b", token.DOT.Precedence()},
+		{"a(b)", token.LPAREN.Precedence()},
+		{"!a", js.UnaryPrecedence()},
+		{"a * b", token.MULTIPLY.Precedence()},
+		{"a ** b", jsextended.EXPONENT.Precedence()},
+		{"a + b * c", token.PLUS.Precedence()},
+		{"a || b", token.OR.Precedence()},
+		{"a = b", token.ASSIGN.Precedence()},
+		{"a ? b : c", token.ASSIGN.Precedence()},
+		{"a === b", token.EQ.Precedence()},
+	}
+	for _, test := range tests {
+		result := testutil.MustParse(t, test.input)
+		expr, ok := result.Stmts[0].(*js.ExprStmt).Expr.(ast.Precedencer)
+		require.True(t, ok, test.input)
+		assert.Equal(t, test.expected, expr.Precedence(), test.input)
+	}
+}
+
+func TestDanglingElse(t *testing.T) {
+	result, err := xjs.Parse([]byte("if (a) if (b) c(); else d();"))
+	require.NoError(t, err)
+	outer := result.Stmts[0].(*js.IfStmt)
+	assert.Nil(t, outer.Else)
+	require.IsType(t, &js.IfStmt{}, outer.Then)
+	assert.NotNil(t, outer.Then.(*js.IfStmt).Else)
+}
+
+func TestElseIfChain(t *testing.T) {
+	input := `if (a) {
+  b();
+} else {
+  if (c) {
+    d();
+  } else {
+    if (e) {
+      f();
+    } else {
+      g();
+    }
+  }
+}
+if (a) {} else {
+  // keep
+  if (b) {}
+}`
+	expected := `if (a) {
+  b();
+} else if (c) {
+  d();
+} else if (e) {
+  f();
+} else {
+  g();
+}
+if (a) {} else {
+  // keep
+  if (b) {}
+}`
+	result := testutil.MustParse(t, input)
+	out, err := testutil.PrintExtended(result)
+	require.NoError(t, err)
+	assert.Equal(t, expected, out)
+}
+
+func TestForDeclKind(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  token.Type
+		ok    bool
+	}{
+		{"for (let i = 0;;) {}", js.LET, true},
+		{"for (var i = 0;;) {}", jsextended.VAR, true},
+		{"for (const i = 0;;) {}", jsextended.CONST, true},
+		{"for (i = 0;;) {}", 0, false},
+		{"for (;;) {}", 0, false},
+	}
+	for _, test := range tests {
+		result := testutil.MustParse(t, test.input)
+		kind, ok := result.Stmts[0].(*js.ForStmt).DeclKind()
+		assert.Equal(t, test.ok, ok, test.input)
+		assert.Equal(t, test.kind, kind, test.input)
+	}
+	// the standard dialect only knows let
+	result, err := xjs.Parse([]byte("for (let i = 0;;) {}"))
+	require.NoError(t, err)
+	kind, ok := result.Stmts[0].(*js.ForStmt).DeclKind()
+	assert.True(t, ok)
+	assert.Equal(t, js.LET, kind)
+}
+
+func TestFunctionAccessors(t *testing.T) {
+	result, err := xjs.Parse([]byte("function f(a, b) {}\nlet g = function () {};\nlet h = function h(x) {};"))
+	require.NoError(t, err)
+	decl := result.Stmts[0].(*js.FunctionDecl)
+	assert.Equal(t, "f", decl.FuncName())
+	assert.Equal(t, 2, decl.Arity())
+	anon := result.Stmts[1].(*js.LetStmt).Value.(*js.FunctionExpr)
+	assert.Equal(t, "", anon.FuncName())
+	assert.Equal(t, 0, anon.Arity())
+	named := result.Stmts[2].(*js.LetStmt).Value.(*js.FunctionExpr)
+	assert.Equal(t, "h", named.FuncName())
+	assert.Equal(t, 1, named.Arity())
+	// partial results may lack a name
+	assert.Equal(t, "", (&js.FunctionDecl{}).FuncName())
+}
+
+func TestInfiniteLoops(t *testing.T) {
+	input := `while (true) {}
+for (;;) { if (a) break; }
+do { for (;;) { break; } } while (true);
+while (true) { switch (a) { case 1: break; } }
+function foo() {
+	for (; true;) { return; }
+	while (true) {
+		while (b) { break; }
+	}
+}
+outer: while (true) { while (b) { break outer; } }
+while (a) {}
+setTimeout(() => { while (true) {} });
+let f = function () { for (;;) { setTimeout(() => { return; }); } };`
+	result := testutil.MustParse(t, input)
+	var lines []int
+	for _, tok := range jsextended.InfiniteLoops(result) {
+		lines = append(lines, tok.Line)
+	}
+	assert.Equal(t, []int{0, 2, 3, 6, 12, 13}, lines)
+}
+
+func TestIsGuardClause(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"if (!valid) return;", true},
+		{"if (!valid) { return null; }", true},
+		{"if (err) throw err;", true},
+		{"if (a) f();", false},
+		{"if (a) return; else f();", false},
+		{"if (a) { f(); return; }", false},
+		{"if (a) {}", false},
+	}
+	for _, test := range tests {
+		result := testutil.MustParse(t, test.input)
+		assert.Equal(t, test.expected, jsextended.IsGuardClause(result.Stmts[0].(*js.IfStmt)), test.input)
+	}
+
+	result := testutil.MustParse(t, "while (a) { if (b) break; if (c) { continue; } }")
+	for _, stmt := range result.Stmts[0].(*js.WhileStmt).Then.(*js.BlockStmt).Stmts {
+		assert.True(t, jsextended.IsGuardClause(stmt.(*js.IfStmt)))
+	}
+}
+
+func TestLabeledJumps(t *testing.T) {
+	input := "outer: for (;;) { for (;;) { if (a) continue outer\nif (b) break outer\ncontinue\nouter } }"
+	result := testutil.MustParse(t, input)
+	out, err := testutil.PrintExtended(result)
+	require.NoError(t, err)
+	// a label on the next line is a separate statement
+	assert.Equal(t, "outer: for (;;) {\n  for (;;) {\n    if (a) continue outer;\n    if (b) break outer;\n    continue;\n    outer;\n  }\n}", out)
+	out, err = xjs.Extended.Print(result, printer.Compact())
+	require.NoError(t, err)
+	assert.Equal(t, "outer: for (;;) {for (;;) {if (a) continue outer;if (b) break outer;continue;outer;}}", out)
+	out, err = xjs.Extended.Print(result, printer.WithMinimalSemicolons(true))
+	require.NoError(t, err)
+	assert.Equal(t, "outer: for (;;) {\n  for (;;) {\n    if (a) continue outer\n    if (b) break outer\n    continue\n    outer\n  }\n}", out)
+}
+
+func TestLabeledBlocks(t *testing.T) {
+	result := testutil.MustParse(t, "found: { for (;;) { if (a) break found } missing() }")
+	out, err := testutil.PrintExtended(result)
+	require.NoError(t, err)
+	assert.Equal(t, "found: {\n  for (;;) {\n    if (a) break found;\n  }\n  missing();\n}", out)
+
+	for _, input := range []string{
+		"a: b: for (;;) { continue a; }",
+		"a: do { if (x) continue a; } while (y);",
+		"a: { b: { break a; } }",
+		"a: { } a: { }",
+	} {
+		_, err := testutil.ParseExtended([]byte(input))
+		assert.NoError(t, err, input)
+	}
+
+	tests := []struct {
+		input, err string
+	}{
+		{"a: { continue a; }", "label a does not denote a loop"},
+		{"a: if (x) for (;;) continue a;", "label a does not denote a loop"},
+		{"a: { break b; }", "undefined label b"},
+		{"a: { let f = () => { break a; }; }", "undefined label a"},
+		{"a: { a: ; }", "label a already declared"},
+	}
+	for _, test := range tests {
+		_, err := testutil.ParseExtended([]byte(test.input))
+		assert.ErrorContains(t, err, test.err, test.input)
+	}
+}
+
+func TestDestructuredParams(t *testing.T) {
+	result := testutil.MustParse(t, "function f({ x, y }, [a], b) {}")
+	params := result.Stmts[0].(*js.FunctionDecl).Params
+	require.Len(t, params, 3)
+	assert.IsType(t, &jsextended.ObjExpr{}, params[0])
+	assert.IsType(t, &js.ArrayExpr{}, params[1])
+	assert.IsType(t, &js.Ident{}, params[2])
+
+	// the core dialect has no destructuring
+	_, err := xjs.Parse([]byte("function f({ x, y }) {}"))
+	require.ErrorContains(t, err, "[line:0, col:11] identifier expected")
+}
+
+func TestForAwait(t *testing.T) {
+	result, err := testutil.ParseExtended([]byte("let f = async () => { for await (const x of xs); };"))
+	require.NoError(t, err)
+	body := result.Stmts[0].(*jsextended.VarStmt).Value.(*jsextended.AsyncExpr).Expr.(*jsextended.ArrowFuncExpr).Body
+	assert.True(t, body.(*js.BlockStmt).Stmts[0].(*jsextended.ForofStmt).IsAwait())
+
+	errs := []string{
+		"for await (const x of xs);",
+		"function f() { for await (const x of xs); }",
+		"let f = async () => { let g = () => { for await (const x of xs); }; };",
+	}
+	for _, input := range errs {
+		_, err := testutil.ParseExtended([]byte(input))
+		assert.ErrorContains(t, err, "for await is only allowed in async functions", input)
+	}
+}
+
+func TestAwaitIdentifier(t *testing.T) {
+	inputs := []string{
+		"function f(await) {\n  let x = await;\n  return await(x) + 1;\n}",
+		"let g = () => await;",
+		"async function f() {\n  function g() {\n    let await = 2;\n  }\n};",
+		"let await = 1;",
+	}
+	for _, input := range inputs {
+		result := testutil.MustParse(t, input)
+		out, err := xjs.Extended.Print(result)
+		require.NoError(t, err)
+		assert.Equal(t, input, out)
+	}
+	// await(x) calls a function outside of async functions
+	result := testutil.MustParse(t, "function f() { return await(x); }")
+	stmt := result.Stmts[0].(*js.FunctionDecl).Body.Stmts[0].(*js.ReturnStmt)
+	assert.IsType(t, &js.CallExpr{}, stmt.Value)
+
+	result = testutil.MustParse(t, "async function f() { return await(x); }\nawait y;")
+	body := result.Stmts[0].(*js.ExprStmt).Expr.(*jsextended.AsyncExpr).Expr.(*js.FunctionExpr).Body
+	assert.IsType(t, &jsextended.AwaitExpr{}, body.Stmts[0].(*js.ReturnStmt).Value)
+	assert.IsType(t, &jsextended.AwaitExpr{}, result.Stmts[1].(*js.ExprStmt).Expr)
+
+	errs := []string{
+		"async function f() { let await = 1; }",
+		"async function f(await) {}",
+		"let f = async () => { await; };",
+	}
+	for _, input := range errs {
+		_, err := testutil.ParseExtended([]byte(input))
+		assert.Error(t, err, input)
+	}
+}
+
+func TestForIn(t *testing.T) {
+	result, err := testutil.ParseExtended([]byte("for (let k in obj);\nfor (let k of obj);"))
+	require.NoError(t, err)
+	assert.True(t, result.Stmts[0].(*jsextended.ForofStmt).IsIn())
+	assert.False(t, result.Stmts[1].(*jsextended.ForofStmt).IsIn())
+
+	inputs := []string{
+		"for (let x = (a in b);;);",
+		"for (let x = [a in b], y = {a: a in b};;);",
+		"for (let x = f(a in b), y = o[a in b];;);",
+		"for (let f = function () { return a in b; };;);",
+	}
+	for _, input := range inputs {
+		_, err := testutil.ParseExtended([]byte(input))
+		assert.NoError(t, err, input)
+	}
+
+	errs := []string{
+		"for (let x = a in b;;);",
+		"for (x = a in b;;);",
+		"for (let x = 1, y = a in b;;);",
+	}
+	for _, input := range errs {
+		_, err := testutil.ParseExtended([]byte(input))
+		assert.ErrorContains(t, err, "in must be parenthesized in for loop initializers", input)
+	}
+	_, err = testutil.ParseExtended([]byte("async function f() { for await (let x in xs); }"))
+	assert.ErrorContains(t, err, "of expected")
+}
+
+func TestUnaryChains(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"a = !!x;", "a = !!x;"},
+		{"a = - -x;", "a = - -x;"},
+		{"a = -+x;", "a = -+x;"},
+		{"a = + +x;", "a = + +x;"},
+		{"a = - - -x;", "a = - - -x;"},
+		{"a = - -x--;", "a = - -x--;"},
+		{"a = b - -x;", "a = b - -x;"},
+	}
+	for _, test := range tests {
+		result := testutil.MustParse(t, test.input)
+		out, err := testutil.PrintExtended(result, printer.Compact())
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out, test.input)
+	}
+}
+
+func TestNewPrecedence(t *testing.T) {
+	expr := func(input string) ast.Expr {
+		result := testutil.MustParse(t, input)
+		return result.Stmts[0].(*js.ExprStmt).Expr
+	}
+
+	// new C().x is (new C()).x
+	member := expr("new C().x").(*js.MemberExpr)
+	assert.IsType(t, &js.CallExpr{}, member.Left.(*jsextended.NewExpr).Value)
+
+	// new C.D() is new (C.D)()
+	call := expr("new C.D()").(*jsextended.NewExpr).Value.(*js.CallExpr)
+	assert.IsType(t, &js.MemberExpr{}, call.Callee)
+
+	// new a.b.C has no arguments
+	assert.IsType(t, &js.MemberExpr{}, expr("new a.b.C").(*jsextended.NewExpr).Value)
+
+	// only the first arguments belong to new
+	call = expr("new f()()").(*js.CallExpr)
+	assert.IsType(t, &jsextended.NewExpr{}, call.Callee)
+	call = expr("new C()[0]").(*js.IndexExpr).Value.(*jsextended.NewExpr).Value.(*js.CallExpr)
+	assert.IsType(t, &js.Variable{}, call.Callee)
+
+	// new new C()() is new (new C())()
+	call = expr("new new C()()").(*jsextended.NewExpr).Value.(*js.CallExpr)
+	assert.IsType(t, &jsextended.NewExpr{}, call.Callee)
+}
+
+func TestDebuggerStmt(t *testing.T) {
+	result := testutil.MustParse(t, "debugger\nx = 1")
+	require.Len(t, result.Stmts, 2)
+	assert.IsType(t, &jsextended.DebuggerStmt{}, result.Stmts[0])
+	out, err := xjs.Extended.Print(result, printer.Compact())
+	require.NoError(t, err)
+	assert.Equal(t, "debugger;x = 1;", out)
+
+	// debugger is a keyword
+	_, err = testutil.ParseExtended([]byte("debugger(1);"))
+	require.Error(t, err)
+}
+
+func TestGlobalConstants(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"undefined", true},
+		{"NaN", true},
+		{"Infinity", true},
+		{"infinity", false},
+		{"foo", false},
+	}
+	for _, test := range tests {
+		result, err := xjs.Parse([]byte(test.input))
+		require.NoError(t, err)
+		v, ok := result.Stmts[0].(*js.ExprStmt).Expr.(*js.Variable)
+		require.True(t, ok)
+		assert.Equal(t, test.expected, v.IsGlobalConstant(), test.input)
+		out, err := xjs.Print(result)
+		require.NoError(t, err)
+		assert.Equal(t, test.input+";", out)
+	}
+}
+
+func TestNoComparisonChaining(t *testing.T) {
+	tests := []struct {
+		input, err string
+	}{
+		{"a < b < c", "[line:0, col:6] chained comparison"},
+		{"a == b < c", "[line:0, col:7] chained comparison"},
+		{"a === b !== c", "[line:0, col:8] chained comparison"},
+		{"(a < b) == c", ""},
+		{"a < b && b < c", ""},
+	}
+	for _, test := range tests {
+		b := xjs.Extended.PluginBuilder().Install(js.NoComparisonChaining)
+		_, err := js.ParseProgram(b.Build([]byte(test.input)))
+		if test.err == "" {
+			assert.NoError(t, err, test.input)
+		} else {
+			assert.EqualError(t, err, test.err, test.input)
+		}
+	}
+}
+
+func TestNoAssignInCondition(t *testing.T) {
+	tests := []struct {
+		input, err string
+	}{
+		{"if (a = 5) {}", "[line:0, col:6] assignment in condition"},
+		{"while (a = b()) {}", "[line:0, col:9] assignment in condition"},
+		{"for (; a = b();) {}", "[line:0, col:9] assignment in condition"},
+		{"if (a) { if (b = c) {} }", "[line:0, col:15] assignment in condition"},
+		{"if ((a = b())) {}", ""},
+		{"if (a == 5) {}", ""},
+		{"for (a = 0; a < 5; a = a + 1) {}", ""},
+	}
+	for _, test := range tests {
+		b := xjs.Extended.PluginBuilder().Install(js.NoAssignInCondition)
+		_, err := js.ParseProgram(b.Build([]byte(test.input)))
+		if test.err == "" {
+			assert.NoError(t, err, test.input)
+		} else {
+			assert.EqualError(t, err, test.err, test.input)
+		}
+	}
+}
+
+func TestBuildWithScanner(t *testing.T) {
+	// any token.Scanner implementation can feed the parser
+	sc := scanner.NewBuilder().
+		UseKeywords(map[string]token.Type{"let": js.LET}).
+		Build([]byte("let a = 1"))
+	p := xjs.PluginBuilder().BuildWithScanner(sc)
+	result, err := js.ParseProgram(p)
+	require.NoError(t, err)
+	out, err := xjs.Print(result)
+	require.NoError(t, err)
+	assert.Equal(t, "let a = 1;", out)
+}
+
+func TestEmptyBlocks(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"function f() {\n}", "function f() {}"},
+		{"if (a) {\n\n} else {\n}", "if (a) {} else {}"},
+		{"function f() { a() }", "function f() {\n  a();\n}"},
+		{"function f() {\n// c\n}", "function f() {\n// c\n}"},
+	}
+	for _, test := range tests {
+		result, err := xjs.Parse([]byte(test.input))
+		require.NoError(t, err)
+		out, err := xjs.Print(result)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out)
+		out, err = xjs.Print(result, printer.Compact())
+		require.NoError(t, err)
+		assert.NotContains(t, out, "{;}")
+	}
+
+	t.Run("with space", func(t *testing.T) {
+		result, err := xjs.Parse([]byte("function f() {\n}\nif (a) {} else {\n// c\n}"))
+		require.NoError(t, err)
+		out, err := xjs.Print(result, printer.WithEmptyBlockSpace(true))
+		require.NoError(t, err)
+		assert.Equal(t, "function f() { }\nif (a) { } else {\n// c\n}", out)
+	})
+}
+
+func TestMetaProperty(t *testing.T) {
+	tests := []struct {
+		input, err string
+	}{
+		{"new.target", "[line:0, col:0] new.target expression is not allowed here"},
+		{"new.foo", "[line:0, col:4] target expected"},
+		{"import.foo", "[line:0, col:7] meta expected"},
+		{"f(import.meta.url)", "[line:0, col:2] import.meta is only allowed in modules"},
+	}
+	for _, test := range tests {
+		_, err := testutil.ParseExtended([]byte(test.input))
+		assert.EqualError(t, err, test.err)
+	}
+
+	// import.meta requires a module
+	b := xjs.Extended.PluginBuilder()
+	b.WithModule()
+	result, err := js.ParseProgram(b.Build([]byte("f(import.meta.url, function () { return new.target; })")))
+	require.NoError(t, err)
+	out, err := xjs.Extended.Print(result)
+	require.NoError(t, err)
+	assert.Equal(t, "f(import.meta.url, function () {\n  return new.target;\n});", out)
+}
+
+// largeProgram generates a program of about n lines exercising the most
+// common constructs.
+func largeProgram(n int) []byte {
+	var sb strings.Builder
+	for i := 0; i*10 < n; i++ {
+		fmt.Fprintf(&sb, `// function %[1]d
+function fn%[1]d(a, b) {
+  let x = a * %[1]d + b;
+  if (x > 10 && b !== "str") {
+    x = obj.items[x %% 3](a, b);
+  } else {
+    x = [1, 2, { key: x }];
+  }
+  return x;
+}
+`, i)
+	}
+	return []byte(sb.String())
+}
+
+func BenchmarkParsePrintLarge(b *testing.B) {
+	input := largeProgram(10000)
+	for b.Loop() {
+		result, err := testutil.ParseExtended(input)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := testutil.PrintExtended(result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+var snippets = [][]byte{
+	[]byte("let a = 1;"),
+	[]byte("console.log(a + b * c);"),
+	[]byte("function f(x) { return x > 0 ? x : -x; }"),
+	[]byte("for (let i = 0; i < 10; i++) { sum = sum + i; }"),
+}
+
+func BenchmarkParseSnippets(b *testing.B) {
+	b.Run("build", func(b *testing.B) {
+		for b.Loop() {
+			for _, input := range snippets {
+				if _, err := testutil.ParseExtended(input); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("reset", func(b *testing.B) {
+		p := xjs.Extended.PluginBuilder().Build(nil)
+		for b.Loop() {
+			for _, input := range snippets {
+				if err := p.Reset(input); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := js.ParseProgram(p); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("pool", func(b *testing.B) {
+		pool, err := parser.NewPool(func() *parser.Parser {
+			return xjs.Extended.PluginBuilder().Build(nil)
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		for b.Loop() {
+			for _, input := range snippets {
+				p, err := pool.Get(input)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := js.ParseProgram(p); err != nil {
+					b.Fatal(err)
+				}
+				pool.Put(p)
+			}
+		}
+	})
+}
+
+func TestParserReset(t *testing.T) {
+	p := xjs.Extended.PluginBuilder().Build([]byte("a +"))
+	_, err := js.ParseProgram(p)
+	require.Error(t, err)
+	require.True(t, p.CanReset())
+	for _, input := range snippets {
+		require.NoError(t, p.Reset(input))
+		result, err := js.ParseProgram(p)
+		require.NoError(t, err)
+		out, err := testutil.PrintExtended(result)
+		require.NoError(t, err)
+		expected, err := testutil.ParseExtended(input)
+		require.NoError(t, err)
+		expectedOut, err := testutil.PrintExtended(expected)
+		require.NoError(t, err)
+		assert.Equal(t, expectedOut, out)
+	}
+
+	// parsers reading tokens can't be reset
+	p = parser.NewBuilder().BuildFromTokens(nil)
+	assert.False(t, p.CanReset())
+	assert.ErrorIs(t, p.Reset([]byte("a")), parser.ErrNoReset)
+}
+
+func TestParserPool(t *testing.T) {
+	var expected []string
+	for _, input := range snippets {
+		result, err := testutil.ParseExtended(input)
+		require.NoError(t, err)
+		out, err := testutil.PrintExtended(result)
+		require.NoError(t, err)
+		expected = append(expected, out)
+	}
+	pool, err := parser.NewPool(func() *parser.Parser {
+		return xjs.Extended.PluginBuilder().Build(nil)
+	})
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i, input := range snippets {
+				p, err := pool.Get(input)
+				if !assert.NoError(t, err) {
+					return
+				}
+				result, err := js.ParseProgram(p)
+				pool.Put(p)
+				assert.NoError(t, err)
+				out, err := testutil.PrintExtended(result)
+				assert.NoError(t, err)
+				assert.Equal(t, expected[i], out)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// parsers reading tokens can't be pooled
+	_, err = parser.NewPool(func() *parser.Parser {
+		return parser.NewBuilder().BuildFromTokens(nil)
+	})
+	assert.ErrorIs(t, err, parser.ErrNoReset)
+}
+
+// TestParsePrintLargeAllocs guards against performance regressions. Raise
+// the budget only when the extra allocations are justified.
+func TestParsePrintLargeAllocs(t *testing.T) {
+	const lines, allocsPerLine = 10000, 55
+	input := largeProgram(lines)
+	allocs := testing.AllocsPerRun(1, func() {
+		result, err := testutil.ParseExtended(input)
+		require.NoError(t, err)
+		_, err = testutil.PrintExtended(result)
+		require.NoError(t, err)
+	})
+	assert.LessOrEqual(t, allocs, float64(lines*allocsPerLine))
+}
+
+func TestFoldStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a = "a" + "b" + "c";`, `a = "abc";`},
+		{`a = 'a' + 'b';`, `a = 'ab';`},
+		{`a = "a" + 'b';`, `a = "a" + 'b';`},
+		{`a = "a" + 1;`, `a = "a" + 1;`},
+		{`a = x + "a" + "b";`, `a = x + "a" + "b";`},
+		{`a = "a" + ("b" + "c");`, `a = "a" + ("bc");`},
+		{`a = "\1" + "2";`, `a = "\1" + "2";`},
+		{`a = "\\1" + "2";`, `a = "\\12";`},
+		{"a = `a` + `b`;", "a = `a` + `b`;"},
+		{`a = "a" - "b";`, `a = "a" - "b";`},
+	}
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.FoldStrings)
+	for _, test := range tests {
+		result, err := d.Parse([]byte(test.input))
+		require.NoError(t, err)
+		out, err := d.Print(result, printer.Compact())
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out, test.input)
+	}
+}
+
+func TestFoldNullish(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a = null ?? b;`, `a = b;`},
+		{`a = undefined ?? b;`, `a = b;`},
+		{`a = (null) ?? b;`, `a = b;`},
+		{`a = 0 ?? b;`, `a = 0;`},
+		{`a = "" ?? b;`, `a = "";`},
+		{`a = false ?? b;`, `a = false;`},
+		{`a = x ?? b;`, `a = x ?? b;`},
+		{`a = x?.y ?? b;`, `a = x?.y ?? b;`},
+		{`a = x?.y;`, `a = x?.y;`},
+		{`a = c * (null ?? b + 1);`, `a = c * (b + 1);`},
+		{`a = /* keep */ null ?? b;`, `a = /* keep */ null ?? b;`},
+	}
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.FoldNullish)
+	for _, test := range tests {
+		result, err := d.Parse([]byte(test.input))
+		require.NoError(t, err)
+		out, err := d.Print(result)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out, test.input)
+	}
+}
+
+func TestCommonJS(t *testing.T) {
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.CommonJS)
+	tests := []struct {
+		input, expected string
+	}{
+		{"import 'm';", "require('m');"},
+		{"import * as m from 'm';", "const m = require('m');"},
+		{"import m from 'm';", "const m = require('m').default;"},
+		{"import { a, b as c } from 'm';", "const { a, b: c } = require('m');"},
+		{"// m\nimport {} from 'm';", "// m\nconst {} = require('m');"},
+		{"export let a = 1, b;", "let a = 1, b;\nexports.a = a;\nexports.b = b;"},
+		{"export const { a, b: [c, ...d], e = 1 } = x;", "const { a, b: [c, ...d], e = 1 } = x;\nexports.a = a;\nexports.c = c;\nexports.d = d;\nexports.e = e;"},
+		{"// f\nexport function f() {}", "// f\nfunction f() {}\nexports.f = f;"},
+		{"export { a, b as c };", "exports.a = a;\nexports.c = b;"},
+		{"export { a as b } from 'm';", "exports.b = require('m').a;"},
+		{"export * from 'm';", "Object.assign(exports, require('m'));"},
+		{"export * as m from 'm';", "exports.m = require('m');"},
+		{"export default 42;", "exports.default = 42;"},
+		{"export default function () {}", "exports.default = function () {};"},
+		{"export default function f() {}", "function f() {}\nexports.default = f;"},
+	}
+	for _, test := range tests {
+		result, err := d.Parse([]byte(test.input))
+		require.NoError(t, err)
+		out, err := d.Print(result)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out, test.input)
+	}
+}
+
+func TestExponent(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"a ** b ** c;", "(a ** (b ** c));"},
+		{"a * b ** c;", "(a * (b ** c));"},
+		{"a.b ** -c;", "(a.b ** -c);"},
+		{"(-a) ** b;", "((-a) ** b);"},
+	}
+	for _, test := range tests {
+		out, err := testutil.PrintExtended(testutil.MustParse(t, test.input), printer.WithLogs(true))
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out, test.input)
+	}
+
+	errs := []string{
+		"x = -a ** b;",
+		"x = !a ** b;",
+		"x = typeof a ** b;",
+		"x = delete a.b ** c;",
+		"async function f() { return await a ** b; }",
+	}
+	for _, input := range errs {
+		_, err := testutil.ParseExtended([]byte(input))
+		assert.ErrorContains(t, err, "unary operator before ** must be parenthesized", input)
+	}
+}
+
+func TestObjectKeys(t *testing.T) {
+	input := `x = { 1: 'a', 1.5: 'b', 0x10: 'c', 1e3: 'd', "a-b": 'e', 'f': 'f', g: 'g', if: 'h', [i]: 'i' };`
+	result := testutil.MustParse(t, input)
+	for _, opts := range [][]printer.Option{nil, {printer.Compact()}} {
+		out, err := xjs.Extended.Print(result, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, input, out)
+	}
+	// numeric keys are numbers too
+	out, err := xjs.Extended.Print(testutil.MustParse(t, "x = { 1.0: 'a', 1e+3: 'b', 'c': 1.0 };"), printer.WithNormalizeNumbers(true))
+	require.NoError(t, err)
+	assert.Equal(t, "x = { 1: 'a', 1e3: 'b', 'c': 1 };", out)
+}
+
+func TestOperatorSpacing(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"x=a?b:c", "x = a ? b : c;"},
+		{"x=a??b", "x = a ?? b;"},
+		{"x = a ?. b ?. [c] ?. (d)", "x = a?.b?.[c]?.(d);"},
+		{"f=a=>b", "f = a => b;"},
+		{"f=(a,b)=>a", "f = (a, b) => a;"},
+		{"x??=a||b", "x ??= a || b;"},
+		{"x=(a?b:c)?d:e", "x = (a ? b : c) ? d : e;"},
+	}
+	for _, test := range tests {
+		out, err := xjs.Extended.Print(testutil.MustParse(t, test.input))
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out, test.input)
+	}
+}
+
+func TestLiteralMembers(t *testing.T) {
+	inputs := []string{
+		"(5).toFixed(2);",
+		"5 .toFixed(2);",
+		"5..toFixed(2);",
+		"5.0.toFixed(1);",
+		"(1e3).toString();",
+		"0x10.toString();",
+		`"abc".length;`,
+		"'a'.concat('b');",
+		"true.toString();",
+	}
+	for _, input := range inputs {
+		result := testutil.MustParse(t, input)
+		for _, opts := range [][]printer.Option{nil, {printer.Compact()}} {
+			out, err := xjs.Extended.Print(result, opts...)
+			require.NoError(t, err)
+			assert.Equal(t, input, out)
+		}
+	}
+	// a normalized integer can't be followed by a dot
+	out, err := xjs.Extended.Print(testutil.MustParse(t, "5.0.toFixed(1); 5..x; (5.0).y; 1.50.z;"), printer.Compact(), printer.WithNormalizeNumbers(true))
+	require.NoError(t, err)
+	assert.Equal(t, "5 .toFixed(1);5 .x;(5).y;1.5.z;", out)
+}
+
+func TestMemberChains(t *testing.T) {
+	inputs := []string{
+		"a.b;",
+		"a[b];",
+		"a?.b;",
+		"a?.[b];",
+		"a?.();",
+		"a?.b[c]?.d;",
+		"a[b]?.c.d?.[e];",
+		"a?.b?.(c)[d];",
+		"a.b?.[c]?.(d).e;",
+		"a?.[0]?.(x)?.y();",
+		"a?.b.c(d)?.[e](f);",
+		"(a?.b).c;",
+		"(a?.[b])();",
+		"new a.b()?.c;",
+		"(new a)?.b;",
+	}
+	for _, input := range inputs {
+		result := testutil.MustParse(t, input)
+		for _, opts := range [][]printer.Option{nil, {printer.Compact()}} {
+			out, err := xjs.Extended.Print(result, opts...)
+			require.NoError(t, err)
+			assert.Equal(t, input, out)
+		}
+	}
+	for _, input := range []string{"new a?.b();", "new a.b?.c;"} {
+		_, err := testutil.ParseExtended([]byte(input))
+		assert.ErrorContains(t, err, "invalid optional chain from new expression", input)
+	}
+}
+
+func TestMinifyConstants(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a = [true, false, undefined];`, `a = [!0, !1, void 0];`},
+		{`return true;`, `return !0;`},
+		{`a = typeof undefined;`, `a = typeof void 0;`},
+		{`a = -true;`, `a = -!0;`},
+		{`a = true.toString();`, `a = (!0).toString();`},
+		{`a = false[0];`, `a = (!1)[0];`},
+		{`a = undefined?.b;`, `a = (void 0)?.b;`},
+		{`a = true ** 2 + 2 ** false;`, `a = (!0) ** 2 + 2 ** !1;`},
+		{`a = x.true + { undefined: 1 };`, `a = x.true + { undefined: 1 };`},
+		{`a = trueish;`, `a = trueish;`},
+		// assignments and bindings of undefined
+		{`undefined = 1;`, `undefined = 1;`},
+		{`[undefined, a.b] = c; x = undefined;`, `[undefined, a.b] = c;x = void 0;`},
+		{`for (undefined in o);`, `for (undefined in o);`},
+		{`function f(undefined) { return undefined; } g(undefined);`, `function f(undefined) {return undefined;}g(void 0);`},
+		{`function f() { let undefined = 1; return () => undefined; }`, `function f() {let undefined = 1;return () => undefined;}`},
+		{`f = (a, undefined) => undefined === a;`, `f = (a, undefined) => undefined === a;`},
+		{`try {} catch (undefined) { f(undefined); }`, `try {} catch (undefined) {f(undefined);}`},
+		{`function f() { function g(undefined) {} return undefined; }`, `function f() {function g(undefined) {}return void 0;}`},
+	}
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.MinifyConstants)
+	for _, test := range tests {
+		result, err := d.Parse([]byte(test.input))
+		require.NoError(t, err)
+		out, err := d.Print(result, printer.Compact())
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out, test.input)
+	}
+}
+
+func TestArrowIIFE(t *testing.T) {
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.ArrowIIFE)
+	result, err := d.Parse([]byte("let a = 1;\nconsole.log(a); // done"))
+	require.NoError(t, err)
+	out, err := d.Print(result)
+	require.NoError(t, err)
+	assert.Equal(t, "(() => {\n  let a = 1;\n  console.log(a); // done\n})();", out)
+	out, err = d.Print(result, printer.Compact())
+	require.NoError(t, err)
+	assert.Equal(t, "(() => {let a = 1;console.log(a);})();", out)
+}
+
+func TestIfToTernary(t *testing.T) {
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.IfToTernary)
+	tests := []struct {
+		input, expected string
+	}{
+		{"if (c) a = 1; else a = 2;", "a = c ? 1 : 2;"},
+		{"if (c) { a = 1 } else { a = 2 }", "a = c ? 1 : 2;"},
+		{"if (x = y) a = b = 1; else a = () => 2;", "a = (x = y) ? (b = 1) : (() => 2);"},
+		{"if (c) a = 1; else if (d) a = 2; else a = 3;", "a = c ? 1 : d ? 2 : 3;"},
+		{"if (c) a = 1; else b = 2;", "if (c) a = 1; else b = 2;"},
+		{"if (c) a ??= 1; else a = 2;", "if (c) a ??= 1; else a = 2;"},
+		{"if (c) o.a = 1; else o.a = 2;", "if (c) o.a = 1; else o.a = 2;"},
+		{"if (c) a = 1;", "if (c) a = 1;"},
+		{"if (c) { a = 1; f() } else a = 2;", "if (c) {\n  a = 1;\n  f();\n} else a = 2;"},
+		{"if (c) a = 1; // one\nelse a = 2;", "if (c) a = 1; // one\nelse a = 2;"},
+	}
+	for _, test := range tests {
+		result, err := d.Parse([]byte(test.input))
+		require.NoError(t, err)
+		out, err := d.Print(result)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out, test.input)
+	}
+}
+
+func TestRenameIdentifiers(t *testing.T) {
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.RenameIdentifiers(func(name string) string {
+		if name == "console" {
+			return name
+		}
+		return "ns_" + name
+	}))
+	input := `import def from "l";
+import { a, b as c } from "m";
+import * as all from "n";
+let x = a.b + c, y = { x, z: x };
+const { p, q: [r] } = y;
+function f(s, t) { return function g(u) { return s + u; }; }
+for (const v of x) console.log(v);
+try { f(); } catch (e) {}
+loop: for (;;) break loop;
+export { x, y as z };
+export { w } from "o";`
+	result := testutil.MustParse(t, input)
+	out, err := d.Print(result)
+	require.NoError(t, err)
+	assert.Equal(t, `import ns_def from "l";
+import { a as ns_a, b as ns_c } from "m";
+import * as ns_all from "n";
+let ns_x = ns_a.b + ns_c, ns_y = { x: ns_x, z: ns_x };
+const { p: ns_p, q: [ns_r] } = ns_y;
+function ns_f(ns_s, ns_t) {
+  return function ns_g(ns_u) {
+    return ns_s + ns_u;
+  };
+}
+for (const ns_v of ns_x) console.log(ns_v);
+try {
+  ns_f();
+} catch (ns_e) {}
+loop: for (;;) break loop;
+export { ns_x as x, ns_y as z };
+export { w } from "o";`, out)
+}
+
+func TestDropRedundantParens(t *testing.T) {
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.DropRedundantParens)
+	tests := []struct {
+		input, expected string
+	}{
+		{"x = (a * b) + (c) - ((d));", "x = a * b + c - d;"},
+		{"x = (a + b) * c;", "x = (a + b) * c;"},
+		{"x = (a - b) - (c - d);", "x = a - b - (c - d);"},
+		{"x = (-a) * (b = 1);", "x = -a * (b = 1);"},
+		{"x = (a ? b : c);", "x = a ? b : c;"},
+		{"x = (a ?? b) || c;", "x = (a ?? b) || c;"},
+		{"x = (a || b) ?? (c ?? d);", "x = (a || b) ?? (c ?? d);"},
+		{"x = ((a, b));", "x = (a, b);"},
+		{"x = /* c */ (a) + b;", "x = /* c */ (a) + b;"},
+		{"x = (a);", "x = a;"},
+		{"return (a);", "return (a);"},
+		{"x = (-a) ** (-b);", "x = (-a) ** -b;"},
+		{"x = (a ** b) ** (c ** d);", "x = (a ** b) ** c ** d;"},
+		{"x = (a.b) ** (c * d);", "x = a.b ** (c * d);"},
+		// a function or an object can't start a statement
+		{"(function () {}) + 1;", "(function () {}) + 1;"},
+		{"({}) + 1;", "({}) + 1;"},
+		{"((({}))) + 1;", "({}) + 1;"},
+		{"(function () {}).call(this) || (a);", "(function () {}).call(this) || a;"},
+		{"x = (function () {}) + ({});", "x = function () {} + {};"},
+		{"((a)) + 1;", "a + 1;"},
+	}
+	for _, test := range tests {
+		result, err := d.Parse([]byte(test.input))
+		require.NoError(t, err, test.input)
+		out, err := d.Print(result)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out, test.input)
+	}
+
+	// parentheses are kept by default
+	out, err := testutil.PrintExtended(testutil.MustParse(t, "x = (a * b) + (c);"))
+	require.NoError(t, err)
+	assert.Equal(t, "x = (a * b) + (c);", out)
+}
+
+func TestStringValue(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{`"hello"`, "hello"},
+		{`'it\'s'`, "it's"},
+		{`"\b\f\n\r\t\v\0"`, "\b\f\n\r\t\v\x00"},
+		{`"\x41\u0042\u{43}\u{1F600}"`, "ABC\U0001F600"},
+		{`"\uD83D\uDE00"`, "\U0001F600"},
+		{`"\101\12\08\400"`, "A\n\x008 0"},
+		{`"\q\"\\"`, "q\"\\"},
+		{"'é'", "é"},
+	}
+	for _, test := range tests {
+		result, err := testutil.ParseExtended([]byte(test.input))
+		require.NoError(t, err, test.input)
+		lit := result.Stmts[0].(*js.ExprStmt).Expr.(*js.Literal)
+		value, err := lit.StringValue()
+		require.NoError(t, err, test.input)
+		assert.Equal(t, test.expected, value, test.input)
+		assert.Equal(t, test.input, lit.Value.Literal)
+	}
+	// line continuations
+	value, err := js.UnquoteString("\"a\\\nb\\\r\nc\"")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", value)
+	for _, input := range []string{"`a`", "1", `"\x4"`, `"\u{110000}"`, `"\uD83D"`, `"\uDE00\uD83D"`, `"\uD83Da"`} {
+		_, err := js.UnquoteString(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestEscapeStrings(t *testing.T) {
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.EscapeStrings)
+	result, err := d.Parse([]byte(`a('it\'s \x41\u{42}', "\q\u000A\x7f", ` + "`\\q`" + `);`))
+	require.NoError(t, err)
+	out, err := d.Print(result)
+	require.NoError(t, err)
+	assert.Equal(t, `a('it\'s AB', "q\n\x7f", `+"`\\q`"+`);`, out)
+
+	// unpaired surrogates are kept as written
+	result, err = d.Parse([]byte(`a("\uD83D", '\x41\uDE00');`))
+	require.NoError(t, err)
+	out, err = d.Print(result)
+	require.NoError(t, err)
+	assert.Equal(t, `a("\uD83D", '\x41\uDE00');`, out)
+}
+
+func TestWalk(t *testing.T) {
+	result := testutil.MustParse(t, "function f(a) { return a + g(1, { b: 2 }); }")
+	var names []string
+	ast.Walk(result, func(node ast.Node) bool {
+		switch v := node.(type) {
+		case *js.Ident:
+			names = append(names, v.Literal)
+		case *js.Variable:
+			names = append(names, v.Literal)
+		case *js.CallExpr:
+			// skip the arguments
+			return false
+		}
+		return true
+	})
+	assert.Equal(t, []string{"f", "a", "a"}, names)
+}
+
+func TestToJSON(t *testing.T) {
+	data, err := ast.ToJSON(testutil.MustParse(t, "// c\nlet a = f(1);"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "Program",
+		"layout": {"eof": {"type": "end of file", "literal": "", "position": {"line": 1, "column": 12}}},
+		"stmts": [{
+			"type": "VarStmt",
+			"layout": {
+				"var": {"type": "let", "literal": "let", "position": {"line": 1, "column": 0}, "leadingTrivia": [
+					{"type": "line comment", "literal": "// c\n", "position": {"line": 0, "column": 0}}
+				]},
+				"assign": {"type": "=", "literal": "=", "position": {"line": 1, "column": 6}},
+				"semi": {"type": ";", "literal": ";", "position": {"line": 1, "column": 12}}
+			},
+			"pattern": {"type": "Ident", "token": {"type": "identifier", "literal": "a", "position": {"line": 1, "column": 4}}},
+			"value": {
+				"type": "CallExpr",
+				"layout": {
+					"lparen": {"type": "(", "literal": "(", "position": {"line": 1, "column": 9}},
+					"rparen": {"type": ")", "literal": ")", "position": {"line": 1, "column": 11}}
+				},
+				"callee": {"type": "Variable", "token": {"type": "identifier", "literal": "f", "position": {"line": 1, "column": 8}}},
+				"args": [{"type": "Literal", "value": {"type": "number", "literal": "1", "position": {"line": 1, "column": 10}}}]
+			},
+			"more": []
+		}],
+		"filename": "",
+		"prelude": 0
+	}`, string(data))
+
+	// every tree of the language features is serialized
+	files, err := filepath.Glob(filepath.Join("testdata", "*.js"))
+	require.NoError(t, err)
+	for _, file := range files {
+		dat, err := os.ReadFile(file)
+		require.NoError(t, err)
+		result, err := testutil.ParseModule(dat)
+		require.NoError(t, err, file)
+		data, err := ast.ToJSON(result)
+		require.NoError(t, err, file)
+		assert.True(t, json.Valid(data), file)
+	}
+
+	_, err = xjs.ParseToJSON([]byte("let a = ;"))
+	assert.Error(t, err)
+}
+
+func TestEqual(t *testing.T) {
+	a := testutil.MustParse(t, "let a = [1, { b: c }];\nf(a);")
+	b := testutil.MustParse(t, "let a  =  [1,{b:c}];\nf( a );")
+	assert.True(t, ast.Equal(a, b))
+	assert.False(t, ast.EqualWithPositions(a, b))
+	assert.True(t, ast.EqualWithPositions(a, testutil.MustParse(t, "let a = [1, { b: c }];\nf(a);")))
+
+	for _, input := range []string{
+		"let a = [1, { b: d }];\nf(a);",
+		"let a = [1, { b: c }, 2];\nf(a);",
+		"let a = [1, { b: c }];\nf(a, b);",
+		"let a = [1, { b: c }]; f(a);",
+		"let a = [1, { b: c }];\n// call\nf(a);",
+		"const a = [1, { b: c }];\nf(a);",
+	} {
+		assert.False(t, ast.Equal(a, testutil.MustParse(t, input)), input)
+	}
+	assert.True(t, ast.Equal(nil, nil))
+	assert.False(t, ast.Equal(a, nil))
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, xjs.Extended.Fingerprint(), xjs.Extended.Fingerprint())
+	assert.Len(t, xjs.Extended.Fingerprint(), 64)
+	assert.NotEqual(t, xjs.Standard.Fingerprint(), xjs.Extended.Fingerprint())
+	assert.NotEqual(t, xjs.Extended.Fingerprint(), xjs.ES5.Fingerprint())
+
+	d := xjs.Extended
+	d.Printers = append(slices.Clip(d.Printers), jsextended.FoldStrings)
+	assert.NotEqual(t, xjs.Extended.Fingerprint(), d.Fingerprint())
+
+	b := xjs.Extended.PluginBuilder()
+	before := b.Fingerprint()
+	b.WithIgnoreTypeAnnotations()
+	assert.NotEqual(t, before, b.Fingerprint())
+
+	// registering token types changes the dialects that could use them
+	before = xjs.Standard.Fingerprint()
+	token.RegisterBinaryType(token.RegisterType("<=>"), token.LT.Precedence())
+	assert.NotEqual(t, before, xjs.Standard.Fingerprint())
+}
+
+func TestMeasure(t *testing.T) {
+	input := `function f(a) {
+  if (a && b) {
+    for (const x of a) {
+      switch (x) {
+        case 1: g(x); break;
+        default: h();
+      }
+    }
+  }
+  return a ? () => 1 : null;
+}
+let y = 1;`
+	assert.Equal(t, ast.Metrics{
+		Statements: 14,
+		MaxDepth:   3,
+		Functions:  2,
+		Complexity: 6,
+	}, ast.Measure(testutil.MustParse(t, input)))
+	assert.Equal(t, ast.Metrics{Complexity: 1}, ast.Measure(testutil.MustParse(t, "")))
+}
+
+func TestTransform(t *testing.T) {
+	input := `trace(1);
+function f(a) {
+  trace(a);
+  return [a * 2, , a];
+}
+let b = { c: 2 * 3 };`
+	result := testutil.MustParse(t, input)
+	out := ast.Transform(result, func(node ast.Node) ast.Node {
+		switch v := node.(type) {
+		case *js.ExprStmt:
+			// remove trace calls
+			if call, ok := v.Expr.(*js.CallExpr); ok {
+				if callee, ok := call.Callee.(*js.Variable); ok && callee.Literal == "trace" {
+					return nil
+				}
+			}
+		case *js.BinaryExpr:
+			// a * 2 -> a + a
+			if lit, ok := v.Right.(*js.Literal); ok && v.Op.Type == token.MULTIPLY && lit.Value.Literal == "2" {
+				expr := *v
+				expr.Op = token.Token{Type: token.PLUS, Literal: "+"}
+				expr.Right = v.Left
+				return &expr
+			}
+		}
+		return node
+	})
+	require.Same(t, result, out)
+	printed, err := testutil.PrintExtended(result)
+	require.NoError(t, err)
+	// the line break before the function belonged to its leading trivia
+	assert.Equal(t, `
+function f(a) {
+  return [a + a, , a];
+}
+let b = { c: 2 * 3 };`, printed)
+
+	assert.PanicsWithValue(t, "ast: cannot replace *js.Ident with *js.Literal", func() {
+		ast.Transform(result, func(node ast.Node) ast.Node {
+			if _, ok := node.(*js.Ident); ok {
+				return &js.Literal{}
+			}
+			return node
+		})
+	})
+}
+
+func TestSwitchGroupedCases(t *testing.T) {
+	input := "switch (x) { case 1: case 2: doThing(); break; default: case 3: }"
+	result := testutil.MustParse(t, input)
+	stmt := result.Stmts[0].(*jsextended.SwitchStmt)
+	require.Len(t, stmt.Clauses, 4)
+	var bodies []bool
+	for _, clause := range stmt.Clauses {
+		bodies = append(bodies, clause.(interface{ HasBody() bool }).HasBody())
+	}
+	assert.Equal(t, []bool{false, true, false, false}, bodies)
+
+	output, err := testutil.PrintExtended(result)
+	require.NoError(t, err)
+	assert.Equal(t, `switch (x) {
+  case 1:
+  case 2:
+    doThing();
+    break;
+  default:
+  case 3:
+}`, output)
+}
+
+func TestVarStmtSetKind(t *testing.T) {
+	result := testutil.MustParse(t, "/* a */ let a = 1, { b } = c;")
+	stmt := result.Stmts[0].(*jsextended.VarStmt)
+	for _, kind := range []token.Type{jsextended.CONST, jsextended.VAR, js.LET} {
+		stmt.SetKind(kind)
+		assert.Equal(t, kind, stmt.Kind())
+		output, err := testutil.PrintExtended(result)
+		require.NoError(t, err)
+		assert.Equal(t, "/* a */\n"+kind.Spelling()+" a = 1, { b } = c;", output)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	result, err := xjs.Extended.ParseFile("src/main.js", []byte("let a = ;"))
+	require.Error(t, err)
+	assert.Equal(t, "src/main.js", result.Filename)
+
+	result, err = xjs.ParseFile("lib.js", []byte("let b = 1;"))
+	require.NoError(t, err)
+	assert.Equal(t, "lib.js", result.Filename)
+}
+
+func TestCompileREPL(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"1 + 2", "console.log(1 + 2);"},
+		{"f(x);", "console.log(f(x));"},
+		{"x = [1, 2]", "console.log(x = [1, 2]);"},
+		{"let a = 1", "let a = 1;"},
+		{"a(); b()", "a();\nb();"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		out, err := xjs.CompileREPL([]byte(test.input))
+		require.NoError(t, err, test.input)
+		assert.Equal(t, test.expected, out, test.input)
+	}
+	_, err := xjs.CompileREPL([]byte("1 +"))
+	assert.Error(t, err)
+}
+
+func TestDump(t *testing.T) {
+	out := xjs.Dump([]byte("let a = 1;\nf(a b)"))
+	assert.True(t, strings.HasPrefix(out, "tokens:\n\t0:0 let \"let\"\n\t0:4 identifier \"a\"\n"), out)
+	assert.Contains(t, out, "\t1:5 end of file \"\"\ntree:\n(*js.Program)(")
+	assert.Contains(t, out, "(*js.LetStmt)(")
+	assert.True(t, strings.HasSuffix(out, "errors:\n\t[line:1, col:4] ) expected\n"), out)
+
+	// the tokens are read by the scanner of the dialect
+	out = xjs.Extended.Dump([]byte("a ?? b"))
+	assert.Contains(t, out, "\t0:2 ?? \"??\"\n")
+	assert.True(t, strings.HasSuffix(out, "errors:\n"), out)
+}
+
+func TestCompileBundle(t *testing.T) {
+	a, err := xjs.ParseFile("a.xjs", []byte("// head\nlet a = 1\nfunction f() { return a }\n"))
+	require.NoError(t, err)
+	b, err := xjs.ParseFile("b.xjs", []byte("console.log(f())"))
+	require.NoError(t, err)
+	c, err := xjs.Parse([]byte("f()"))
+	require.NoError(t, err)
+	programs := []*js.Program{a, b, c}
+
+	out, err := xjs.CompileBundle(programs)
+	require.NoError(t, err)
+	assert.Equal(t, "// a.xjs\n// head\nlet a = 1;\nfunction f() {\n  return a;\n}\n// b.xjs\nconsole.log(f());\nf();", out)
+
+	out, err = xjs.CompileBundle(programs, printer.Compact())
+	require.NoError(t, err)
+	assert.Equal(t, "let a = 1;function f() {return a;}console.log(f());f();", out)
+
+	// the markers are on lines of their own, before the semicolons guarding
+	// the next program
+	d, err := xjs.ParseFile("d.xjs", []byte("x = y"))
+	require.NoError(t, err)
+	e, err := xjs.ParseFile("e.xjs", []byte("(c || d).e()"))
+	require.NoError(t, err)
+	out, err = xjs.CompileBundle([]*js.Program{d, e}, printer.WithSemicolons(printer.SemicolonsAsNeeded))
+	require.NoError(t, err)
+	assert.Equal(t, "// d.xjs\nx = y\n// e.xjs\n;(c || d).e()", out)
+	out, err = xjs.CompileBundle([]*js.Program{d, e})
+	require.NoError(t, err)
+	assert.Equal(t, "// d.xjs\nx = y;\n// e.xjs\n(c || d).e();", out)
+
+	out, err = xjs.CompileBundle(nil)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestCompileDir(t *testing.T) {
+	src, out := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		"main.xjs":         "let a = [1,2];",
+		"lib/util.xjs":     "function f() { return a === b; }",
+		"lib/broken.xjs":   "let b = ;",
+		"lib/deep/x.xjs":   "let c = 1",
+		"lib/readme.md":    "# not compiled",
+		"lib/also/bad.xjs": "function (",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	err := xjs.Extended.CompileDir(src, out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(src, "lib/broken.xjs")+": [line:0, col:8] expression expected")
+	assert.Contains(t, err.Error(), filepath.Join(src, "lib/also/bad.xjs")+": ")
+
+	expected := map[string]string{
+		"main.js":       "let a = [1, 2];",
+		"lib/util.js":   "function f() {\n  return a === b;\n}",
+		"lib/deep/x.js": "let c = 1;",
+	}
+	var compiled []string
+	require.NoError(t, filepath.WalkDir(out, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			rel, _ := filepath.Rel(out, path)
+			compiled = append(compiled, filepath.ToSlash(rel))
+		}
+		return err
+	}))
+	assert.ElementsMatch(t, slices.Collect(maps.Keys(expected)), compiled)
+	for name, code := range expected {
+		data, err := os.ReadFile(filepath.Join(out, name))
+		require.NoError(t, err)
+		assert.Equal(t, code, string(data), name)
+	}
+}
+
+func TestObjectTrailingComma(t *testing.T) {
+	input := `let o = {
+  a: 1, // one
+  b: {
+    c: 2 /* two */, // inner
+  }, // outer
+  d /* d */, /* trailing */
+};`
+	out, err := testutil.PrintExtended(testutil.MustParse(t, input))
+	require.NoError(t, err)
+	assert.Equal(t, `let o = {
+  a: 1, // one
+  b: {
+    c: 2 /* two */ // inner
+  }, // outer
+  d /* d */ /* trailing */
+};`, out)
+
+	result, err := xjs.Parse([]byte("let o = { a /* a */: 1 /* one */, b: 2, /* two */ };"))
+	require.NoError(t, err)
+	out, err = xjs.Print(result)
+	require.NoError(t, err)
+	assert.Equal(t, "let o = { a /* a */: 1 /* one */, b: 2 /* two */ };", out)
+}